@@ -0,0 +1,79 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slim4go
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/essenius/slim4go/internal/inject"
+	"github.com/essenius/slim4go/slimfixture"
+)
+
+// Fixture plugins allow a runner that is built once to use fixtures that are built separately (e.g. by other teams).
+// A plugin is a main package built with -buildmode=plugin, against the same version of slim4go as the runner.
+// It provides a function named RegisterFixtures with the signature of PluginRegistration.
+
+// Definitions
+
+// Registry is what fixture plugins use to register their fixtures, policies and stubs.
+type Registry = slimfixture.Registry
+
+// PluginRegistration is the type of the registration function of a plugin.
+type PluginRegistration = func(registry Registry) error
+
+// PluginSymbol is the name of the registration function of a plugin.
+const PluginSymbol = "RegisterFixtures"
+
+// symbolLookup finds symbols in a plugin.
+type symbolLookup interface {
+	Lookup(symbolName string) (plugin.Symbol, error)
+}
+
+// Helpers
+
+// registerPlugin looks up the registration function of a plugin and calls it.
+func registerPlugin(fileName string, loaded symbolLookup, registry Registry) error {
+	symbol, err := loaded.Lookup(PluginSymbol)
+	if err != nil {
+		return fmt.Errorf("Plugin '%v' has no %v function", fileName, PluginSymbol)
+	}
+	register, ok := symbol.(PluginRegistration)
+	if !ok {
+		return fmt.Errorf("%v in plugin '%v' is a %T. Expected func(slim4go.Registry) error", PluginSymbol, fileName, symbol)
+	}
+	if err := register(registry); err != nil {
+		return fmt.Errorf("Could not register the fixtures of plugin '%v': %v", fileName, err)
+	}
+	return nil
+}
+
+// Functions
+
+// LoadPlugin loads a fixture plugin (.so file) and registers its fixtures.
+func LoadPlugin(fileName string) error {
+	loaded, err := plugin.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("Could not load plugin '%v': %v", fileName, err)
+	}
+	return registerPlugin(fileName, loaded, Server())
+}
+
+// loadPlugins loads the plugins specified in the context.
+func loadPlugins() error {
+	for _, fileName := range inject.Context().Plugins {
+		if err := LoadPlugin(fileName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slim4go
+
+import (
+	"errors"
+	"plugin"
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/slimfixture"
+)
+
+type testPlugin map[string]plugin.Symbol
+
+func (loaded testPlugin) Lookup(symbolName string) (plugin.Symbol, error) {
+	if symbol, ok := loaded[symbolName]; ok {
+		return symbol, nil
+	}
+	return nil, errors.New("symbol not found")
+}
+
+type testRegistry struct {
+	fixtures []interface{}
+}
+
+func (registry *testRegistry) AllowMembers(fixtureName string, patterns ...string) error {
+	return nil
+}
+
+func (registry *testRegistry) DenyNonFixtureMembers() {
+}
+
+func (registry *testRegistry) RegisterAlias(alias string, fixtureName string) error {
+	return nil
+}
+
+func (registry *testRegistry) RegisterFixture(constructor interface{}) error {
+	registry.fixtures = append(registry.fixtures, constructor)
+	return nil
+}
+
+func (registry *testRegistry) RegisterFixturesFrom(factory interface{}) error {
+	return nil
+}
+
+func (registry *testRegistry) RegisterLibrary(library interface{}) error {
+	return nil
+}
+
+func (registry *testRegistry) RegisterStubs(fixture interface{}, stubs slimfixture.Stubs) error {
+	return nil
+}
+
+func (registry *testRegistry) SetReadOnlyFields(fixtureName string, fieldNames ...string) error {
+	return nil
+}
+
+type pluginFixture struct{}
+
+func newPluginFixture() *pluginFixture {
+	return new(pluginFixture)
+}
+
+func TestPluginRegister(t *testing.T) {
+	registry := new(testRegistry)
+	var register PluginRegistration = func(registry Registry) error {
+		return registry.RegisterFixture(newPluginFixture)
+	}
+	assert.Equals(t, nil, registerPlugin("team.so", testPlugin{PluginSymbol: register}, registry), "Register without error")
+	assert.Equals(t, 1, len(registry.fixtures), "Fixture registered")
+}
+
+func TestPluginRegisterErrors(t *testing.T) {
+	registry := new(testRegistry)
+	assert.Equals(t, "Plugin 'team.so' has no RegisterFixtures function",
+		registerPlugin("team.so", testPlugin{}, registry).Error(), "No registration function")
+	wrongType := func() {}
+	assert.Equals(t, "RegisterFixtures in plugin 'team.so' is a func(). Expected func(slim4go.Registry) error",
+		registerPlugin("team.so", testPlugin{PluginSymbol: wrongType}, registry).Error(), "Wrong type")
+	var failing PluginRegistration = func(registry Registry) error {
+		return errors.New("Could not add fixture")
+	}
+	assert.Equals(t, "Could not register the fixtures of plugin 'team.so': Could not add fixture",
+		registerPlugin("team.so", testPlugin{PluginSymbol: failing}, registry).Error(), "Registration failed")
+}
+
+func TestPluginLoadNonExisting(t *testing.T) {
+	err := LoadPlugin("nonexisting.so")
+	assert.IsTrue(t, strings.HasPrefix(err.Error(), "Could not load plugin 'nonexisting.so': "), "Error for missing plugin")
+}
@@ -16,34 +16,184 @@ package slim4go
 // * Use more packages, see fixture
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/essenius/slim4go/internal/catalog"
 	"github.com/essenius/slim4go/internal/inject"
 	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 	"github.com/essenius/slim4go/internal/slimserver"
+	"github.com/essenius/slim4go/internal/wikirunner"
+	"github.com/essenius/slim4go/slimfixture"
 )
 
+// Initialize initializes slim4go with the specified arguments instead of the command line, e.g. []string{"test", "1"}
+// to use pipes with the default settings in go test. The first argument is the program name. It has no effect if
+// slim4go was already initialized, so call it before the other functions.
+func Initialize(args []string) {
+	inject.Context().Initialize(args)
+}
+
 //Server provides the Slim server
 func Server() *slimserver.SlimServer {
 	// We need to do this as early as possible.
 	// It gets the command line parameters and initializes the log
-	inject.Context().Initialize(os.Args)
+	Initialize(os.Args)
 	return inject.SlimServer()
 }
 
-// Serve runs the Slim Server process.
+// Serve runs the Slim Server process. If the print-config option was specified, it prints the effective configuration instead.
+// Otherwise, it first loads the fixture plugins, if specified.
+// If the catalog option was specified, it prints the catalog instead.
+// If the wiki option was specified, it generates the FitNesse fixture reference instead.
+// If the replay option was specified, it replays a recorded session instead, exiting with 1 if the responses differ.
+// If the repl option was specified, it reads commands from the console to try out fixtures instead.
+// If the record option was specified, the session is recorded. If the metrics option was specified, a summary of the instruction
+// metrics is written at the end of the session, and if the metrics address was specified, the metrics are served at that address.
+// If the diagnostics address was specified, pprof and the state of the session are served at that address.
 func Serve() {
-	if err := Server().Serve(); err != nil {
+	server := Server()
+	if inject.Context().PrintConfig {
+		if err := inject.Context().WriteConfig(os.Stdout); err != nil {
+			slimlog.Error.Print(err)
+		}
+		return
+	}
+	if err := loadPlugins(); err != nil {
+		slimlog.Error.Fatal(err)
+	}
+	if folder := inject.Context().WikiFolder; folder != "" {
+		if err := server.WriteWiki(folder, inject.Context().SourceFolders...); err != nil {
+			slimlog.Error.Print(err)
+		}
+		return
+	}
+	if format := inject.Context().CatalogFormat; format != "" {
+		if err := server.WriteCatalog(os.Stdout, format); err != nil {
+			slimlog.Error.Print(err)
+		}
+		return
+	}
+	if inject.Context().Interactive {
+		if err := inject.Repl().Run(os.Stdin, os.Stdout); err != nil {
+			slimlog.Error.Print(err)
+		}
+		return
+	}
+	if fileName := inject.Context().ReplayFile; fileName != "" {
+		report, err := ReplaySession(fileName)
+		if err != nil {
+			slimlog.Error.Fatal(err)
+		}
+		fmt.Print(report)
+		if !report.Passed() {
+			os.Exit(1)
+		}
+		return
+	}
+	if fileName := inject.Context().RecordFile; fileName != "" {
+		recording, err := os.Create(fileName)
+		if err != nil {
+			slimlog.Error.Print(err)
+			return
+		}
+		defer recording.Close()
+		server.RecordTo(recording)
+	}
+	if fileName := inject.Context().MetricsFile; fileName != "" {
+		metricsFile, err := os.Create(fileName)
+		if err != nil {
+			slimlog.Error.Print(err)
+			return
+		}
+		defer metricsFile.Close()
+		server.ReportMetricsTo(inject.Metrics(), metricsFile, slimmetrics.FormatOf(fileName))
+	}
+	if address := inject.Context().MetricsAddress; address != "" {
+		go listenAndServe(address, inject.Metrics().Handler())
+	}
+	if address := inject.Context().DiagnosticsAddress; address != "" {
+		go listenAndServe(address, inject.Diagnostics().Handler())
+	}
+	if err := server.Serve(); err != nil {
 		slimlog.Error.Print(err)
 	}
 }
 
+// listenAndServe serves HTTP requests next to the Slim session. Failing to do that should not stop the session.
+func listenAndServe(address string, handler http.Handler) {
+	if err := http.ListenAndServe(address, handler); err != nil {
+		slimlog.Error.Print(err)
+	}
+}
+
+// ReplaySession replays a recorded session against the registered fixtures and reports the differences in the responses.
+// This allows reproducing a session recorded elsewhere (e.g. in CI), and using recordings as regression tests.
+func ReplaySession(fileName string) (*slimserver.ReplayReport, error) {
+	server := Server()
+	recording, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open recording: %v", err)
+	}
+	defer recording.Close()
+	return server.Replay(recording)
+}
+
+// Catalog returns a description of the registered fixtures and libraries.
+func Catalog() *catalog.Catalog {
+	return Server().Catalog()
+}
+
+// RunWikiPage runs a FitNesse wiki page (.wiki file) without FitNesse, using the registered fixtures.
+// Set up and tear down pages in the same folder are included. The report contains the results of the checks.
+func RunWikiPage(fileName string) (*wikirunner.Report, error) {
+	Server()
+	return inject.WikiRunner().RunFile(fileName)
+}
+
+// AllowMembers restricts the methods and fields of a registered fixture (e.g. demofixtures.TemperatureConverter) that
+// wiki pages can use to those matching one of the patterns (as in path.Match, e.g. Get*). Other members result in an
+// ACCESS_DENIED exception.
+func AllowMembers(fixtureName string, patterns ...string) error {
+	return Server().AllowMembers(fixtureName, patterns...)
+}
+
+// DenyNonFixtureMembers denies using the methods and fields of instances that are not registered fixtures or libraries,
+// e.g. objects of the system under test that fixtures return via symbols.
+func DenyNonFixtureMembers() {
+	Server().DenyNonFixtureMembers()
+}
+
+// RegisterAlias registers an alternative name for a fixture, e.g. to distinguish fixtures with the same name in packages with the same name.
+func RegisterAlias(alias string, fixtureName string) error {
+	return Server().RegisterAlias(alias, fixtureName)
+}
+
 // RegisterFixture registers a type as fixture using a constructor func.
 func RegisterFixture(constructor interface{}) error {
 	return Server().RegisterFixture(constructor)
 }
 
+// RegisterLibrary registers an object as library. Its methods are available in all script tables without the need for a library table.
+// Libraries made via library tables take precedence over registered ones; the most recently registered library wins.
+func RegisterLibrary(library interface{}) error {
+	return Server().RegisterLibrary(library)
+}
+
 // RegisterFixturesFrom registers a number of fixtures using a factory (having pointer receivers named NewXxx).
 func RegisterFixturesFrom(factory interface{}) error {
 	return Server().RegisterFixturesFrom(factory)
 }
+
+// RegisterStubs registers stubs that call methods of a registered fixture without reflection, typically generated by slim4gogen.
+// The fixture is a nil pointer of the fixture type, e.g. (*Counter)(nil).
+func RegisterStubs(fixture interface{}, stubs slimfixture.Stubs) error {
+	return Server().RegisterStubs(fixture, stubs)
+}
+
+// SetReadOnlyFields makes fields of a registered fixture read-only: wiki pages can get them, but not set them.
+func SetReadOnlyFields(fixtureName string, fieldNames ...string) error {
+	return Server().SetReadOnlyFields(fixtureName, fieldNames...)
+}
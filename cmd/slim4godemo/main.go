@@ -12,22 +12,19 @@
 package main
 
 import (
+	"log"
+
 	"github.com/essenius/slim4go"
 	"github.com/essenius/slim4go/examples/demofixtures"
 )
 
-type demoFactory struct{}
+func registerFixtures() {
+	if err := demofixtures.RegisterFixtures(slim4go.Server()); err != nil {
+		log.Fatal(err)
+	}
+}
 
 func main() {
-	slim4go.RegisterFixturesFrom(demofixtures.NewTemperatureFactory())
-	slim4go.RegisterFixture(demofixtures.NewArray)
-	slim4go.RegisterFixture(demofixtures.NewCounter)
-	slim4go.RegisterFixture(demofixtures.NewDictionary)
-	slim4go.RegisterFixture(demofixtures.NewFibonacciFixture)
-	slim4go.RegisterFixture(demofixtures.NewFixtureMapping)
-	slim4go.RegisterFixture(demofixtures.NewMemoObject)
-	slim4go.RegisterFixture(demofixtures.NewTableFixture)
-	slim4go.RegisterFixture(demofixtures.NewTestQuery)
-	slim4go.RegisterFixture(demofixtures.NewWaiter)
+	registerFixtures()
 	slim4go.Serve()
 }
@@ -0,0 +1,60 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essenius/slim4go"
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/wikirunner"
+	"github.com/essenius/slim4go/slim4gotest"
+)
+
+const suiteFolder = "../../examples/FitNesseRoot/Slim4GoSuite"
+
+// The command line contains the test flags, so we use pipes (port 1) with the default settings instead.
+var testArgs = []string{"slim4godemo", "1"}
+
+func TestMain(m *testing.M) {
+	slim4go.Initialize(testArgs)
+	registerFixtures()
+	os.Exit(m.Run())
+}
+
+func TestMainPassingPages(t *testing.T) {
+	slim4gotest.RunWikiPages(t, testArgs,
+		filepath.Join(suiteFolder, "CounterTest.wiki"),
+		filepath.Join(suiteFolder, "DictionaryTest.wiki"),
+		filepath.Join(suiteFolder, "FixtureMappingTest.wiki"))
+}
+
+// Some pages fail on purpose, to show how slim4go reports issues. Scenario and alias tables are not supported offline.
+// WaitTest is left out as it waits for a timeout.
+func TestMainPageCounts(t *testing.T) {
+	expectedCounts := map[string]wikirunner.Counts{
+		"ArrayTest":          {Right: 2, Wrong: 0, Ignores: 0, Exceptions: 1},
+		"EchoTest":           {Right: 1, Wrong: 1, Ignores: 0, Exceptions: 0},
+		"FibonacciTest":      {Right: 8, Wrong: 0, Ignores: 1, Exceptions: 1},
+		"ObjectLifeSpanTest": {Right: 3, Wrong: 0, Ignores: 1, Exceptions: 1},
+		"QueryTest":          {Right: 6, Wrong: 0, Ignores: 1, Exceptions: 1},
+		"TableTableTest":     {Right: 2, Wrong: 3, Ignores: 2, Exceptions: 1},
+		"TemperatureTest":    {Right: 7, Wrong: 0, Ignores: 2, Exceptions: 6},
+	}
+	for page, expected := range expectedCounts {
+		report, err := slim4go.RunWikiPage(filepath.Join(suiteFolder, page+".wiki"))
+		assert.Equals(t, nil, err, page+" ran")
+		assert.Equals(t, expected, report.Counts, page+" counts")
+	}
+}
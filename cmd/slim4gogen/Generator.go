@@ -0,0 +1,337 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The generator finds the fixtures of a package: constructors (named NewXxx for a type Xxx of the package, or marked
+// with a //slim:fixture comment) and factories (parameterless constructors of types with NewXxx methods).
+// For methods of fixtures that only use predefined types, it can also generate stubs that avoid reflection.
+
+// Definitions and constructors
+
+// marker marks a constructor that doesn't follow the naming convention.
+const marker = "//slim:fixture"
+
+// header marks the output as generated, so tools and linters leave it alone.
+const header = "// Code generated by slim4gogen. DO NOT EDIT."
+
+// parseFunctions contains the slimfixture functions converting arguments to the predefined types, by type name.
+var parseFunctions = map[string]string{
+	"bool": "ParseBool",
+	"int":  "ParseInt", "int8": "ParseInt", "int16": "ParseInt", "int32": "ParseInt", "int64": "ParseInt",
+	"uint": "ParseUint", "uint8": "ParseUint", "uint16": "ParseUint", "uint32": "ParseUint", "uint64": "ParseUint",
+	"float32": "ParseFloat", "float64": "ParseFloat",
+	"string": "",
+}
+
+type stubMethod struct {
+	name       string
+	paramTypes []string
+	hasResult  bool
+}
+
+type stubSet struct {
+	typeName string
+	methods  []stubMethod
+}
+
+// Fixtures contains what the generator found in a package.
+type Fixtures struct {
+	packageName  string
+	constructors []string
+	factories    []string
+	stubs        []stubSet
+}
+
+// packageScan contains the declarations of a package that are relevant for finding fixtures.
+type packageScan struct {
+	types     map[string]bool
+	methods   map[string][]*ast.FuncDecl
+	functions []*ast.FuncDecl
+}
+
+func newPackageScan(files map[string]*ast.File) *packageScan {
+	scan := new(packageScan)
+	scan.types = make(map[string]bool)
+	scan.methods = make(map[string][]*ast.FuncDecl)
+	for _, file := range files {
+		for _, declaration := range file.Decls {
+			switch value := declaration.(type) {
+			case *ast.GenDecl:
+				for _, spec := range value.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						scan.types[typeSpec.Name.Name] = true
+					}
+				}
+			case *ast.FuncDecl:
+				if !value.Name.IsExported() {
+					continue
+				}
+				if value.Recv == nil {
+					scan.functions = append(scan.functions, value)
+					continue
+				}
+				if typeName, _ := receiverType(value.Recv.List[0].Type); typeName != "" {
+					scan.methods[typeName] = append(scan.methods[typeName], value)
+				}
+			}
+		}
+	}
+	sort.Slice(scan.functions, func(i, j int) bool { return scan.functions[i].Name.Name < scan.functions[j].Name.Name })
+	for _, methods := range scan.methods {
+		sort.Slice(methods, func(i, j int) bool { return methods[i].Name.Name < methods[j].Name.Name })
+	}
+	return scan
+}
+
+// Scan finds the fixtures of the package in a directory. It skips test files and the output file.
+func Scan(dir string, outputFile string) (*Fixtures, error) {
+	filter := func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != filepath.Base(outputFile)
+	}
+	packages, err := parser.ParseDir(token.NewFileSet(), dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse '%v': %v", dir, err)
+	}
+	if len(packages) != 1 {
+		return nil, fmt.Errorf("Expected one package in '%v' but found %v", dir, len(packages))
+	}
+	fixtures := new(Fixtures)
+	for packageName, aPackage := range packages {
+		fixtures.packageName = packageName
+		fixtures.find(newPackageScan(aPackage.Files))
+	}
+	return fixtures, nil
+}
+
+// Helpers
+
+func hasMarker(function *ast.FuncDecl) bool {
+	if function.Doc == nil {
+		return false
+	}
+	for _, comment := range function.Doc.List {
+		if strings.TrimSpace(comment.Text) == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// paramTypes returns the types of the parameters of a method, if they are all predefined types we can parse.
+func paramTypes(function *ast.FuncDecl, scan *packageScan) ([]string, bool) {
+	result := []string{}
+	for _, field := range function.Type.Params.List {
+		typeName, ok := predefinedType(field.Type, scan)
+		if !ok {
+			return nil, false
+		}
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			result = append(result, typeName)
+		}
+	}
+	return result, true
+}
+
+// predefinedType returns the name of a predefined type, unless the package redefines it.
+func predefinedType(expression ast.Expr, scan *packageScan) (string, bool) {
+	identifier, ok := expression.(*ast.Ident)
+	if !ok || scan.types[identifier.Name] {
+		return "", false
+	}
+	_, ok = parseFunctions[identifier.Name]
+	return identifier.Name, ok
+}
+
+// receiverType returns the name of the type of a receiver or result, and whether it is a pointer.
+func receiverType(expression ast.Expr) (string, bool) {
+	if star, ok := expression.(*ast.StarExpr); ok {
+		typeName, _ := receiverType(star.X)
+		return typeName, true
+	}
+	if identifier, ok := expression.(*ast.Ident); ok {
+		return identifier.Name, false
+	}
+	return "", false
+}
+
+// resultType returns the local type that a function returns, if it returns exactly one.
+func resultType(function *ast.FuncDecl, scan *packageScan) (string, bool, bool) {
+	results := function.Type.Results
+	if results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return "", false, false
+	}
+	typeName, isPointer := receiverType(results.List[0].Type)
+	return typeName, isPointer, scan.types[typeName]
+}
+
+// stubMethodFor returns the stub description for a method, if it only uses predefined types.
+func stubMethodFor(method *ast.FuncDecl, scan *packageScan) (stubMethod, bool) {
+	params, ok := paramTypes(method, scan)
+	if !ok {
+		return stubMethod{}, false
+	}
+	results := method.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return stubMethod{name: method.Name.Name, paramTypes: params}, true
+	}
+	if len(results.List) > 1 || len(results.List[0].Names) > 1 {
+		return stubMethod{}, false
+	}
+	if _, ok := predefinedType(results.List[0].Type, scan); !ok {
+		return stubMethod{}, false
+	}
+	return stubMethod{name: method.Name.Name, paramTypes: params, hasResult: true}, true
+}
+
+func writeStub(source *strings.Builder, typeName string, method stubMethod) {
+	fmt.Fprintf(source, "\t%q: func(instance interface{}, args []string) ([]interface{}, error) {\n", method.name)
+	fmt.Fprintf(source, "\t\tif err := slimfixture.CheckArgs(args, %v); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", len(method.paramTypes))
+	arguments := []string{}
+	for index, paramType := range method.paramTypes {
+		parseFunction := parseFunctions[paramType]
+		if parseFunction == "" {
+			arguments = append(arguments, fmt.Sprintf("args[%v]", index))
+			continue
+		}
+		typeArgument := ""
+		if parseFunction != "ParseBool" {
+			typeArgument = fmt.Sprintf(", %q", paramType)
+		}
+		fmt.Fprintf(source, "\t\targ%v, err := slimfixture.%v(args[%v]%v)\n", index, parseFunction, index, typeArgument)
+		source.WriteString("\t\tif err != nil {\n\t\t\treturn nil, err\n\t\t}\n")
+		if parseFunction == "ParseBool" {
+			arguments = append(arguments, fmt.Sprintf("arg%v", index))
+		} else {
+			arguments = append(arguments, fmt.Sprintf("%v(arg%v)", paramType, index))
+		}
+	}
+	call := fmt.Sprintf("instance.(*%v).%v(%v)", typeName, method.name, strings.Join(arguments, ", "))
+	if method.hasResult {
+		fmt.Fprintf(source, "\t\treturn []interface{}{%v}, nil\n", call)
+	} else {
+		fmt.Fprintf(source, "\t\t%v\n\t\treturn nil, nil\n", call)
+	}
+	source.WriteString("\t},\n")
+}
+
+// Methods
+
+// addStubs adds the stubs for the methods of a fixture type, if it has methods that only use predefined types.
+func (fixtures *Fixtures) addStubs(typeName string, scan *packageScan) {
+	stubs := stubSet{typeName: typeName}
+	for _, method := range scan.methods[typeName] {
+		if stub, ok := stubMethodFor(method, scan); ok {
+			stubs.methods = append(stubs.methods, stub)
+		}
+	}
+	if len(stubs.methods) > 0 {
+		fixtures.stubs = append(fixtures.stubs, stubs)
+	}
+}
+
+func (fixtures *Fixtures) find(scan *packageScan) {
+	// Only fixtures that are pointers can have stubs, as the registry looks them up by the type of the instance.
+	pointerFixtures := make(map[string]bool)
+	for _, function := range scan.functions {
+		typeName, isPointer, isLocal := resultType(function, scan)
+		if !isLocal || (function.Name.Name != "New"+typeName && !hasMarker(function)) {
+			continue
+		}
+		if factoryMethods := fixtures.factoryMethods(function, typeName, scan); len(factoryMethods) > 0 {
+			fixtures.factories = append(fixtures.factories, function.Name.Name)
+			for _, method := range factoryMethods {
+				if fixtureType, isPointer, isLocal := resultType(method, scan); isLocal && isPointer {
+					pointerFixtures[fixtureType] = true
+				}
+			}
+			continue
+		}
+		fixtures.constructors = append(fixtures.constructors, function.Name.Name)
+		if isPointer {
+			pointerFixtures[typeName] = true
+		}
+	}
+	typeNames := []string{}
+	for typeName := range pointerFixtures {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	for _, typeName := range typeNames {
+		fixtures.addStubs(typeName, scan)
+	}
+}
+
+// factoryMethods returns the NewXxx methods of the type a constructor without parameters creates.
+func (fixtures *Fixtures) factoryMethods(function *ast.FuncDecl, typeName string, scan *packageScan) []*ast.FuncDecl {
+	if function.Type.Params.NumFields() > 0 {
+		return nil
+	}
+	result := []*ast.FuncDecl{}
+	for _, method := range scan.methods[typeName] {
+		if strings.HasPrefix(method.Name.Name, "New") {
+			result = append(result, method)
+		}
+	}
+	return result
+}
+
+// Generate returns the source of a RegisterFixtures function that registers the fixtures, and optionally the stubs.
+func (fixtures *Fixtures) Generate(withStubs bool) ([]byte, error) {
+	var source strings.Builder
+	fmt.Fprintf(&source, "%v\n\npackage %v\n\n", header, fixtures.packageName)
+	source.WriteString("import \"github.com/essenius/slim4go/slimfixture\"\n\n")
+	fmt.Fprintf(&source, "// RegisterFixtures registers the fixtures of package %v.\n", fixtures.packageName)
+	source.WriteString("func RegisterFixtures(registry slimfixture.Registry) error {\n")
+	for _, factory := range fixtures.factories {
+		fmt.Fprintf(&source, "if err := registry.RegisterFixturesFrom(%v()); err != nil {\nreturn err\n}\n", factory)
+	}
+	for _, constructor := range fixtures.constructors {
+		fmt.Fprintf(&source, "if err := registry.RegisterFixture(%v); err != nil {\nreturn err\n}\n", constructor)
+	}
+	if withStubs {
+		for _, stubs := range fixtures.stubs {
+			fmt.Fprintf(&source, "if err := registry.RegisterStubs((*%v)(nil), slim4go%vStubs); err != nil {\nreturn err\n}\n",
+				stubs.typeName, stubs.typeName)
+		}
+	}
+	source.WriteString("return nil\n}\n")
+	if withStubs {
+		for _, stubs := range fixtures.stubs {
+			fmt.Fprintf(&source, "\nvar slim4go%vStubs = slimfixture.Stubs{\n", stubs.typeName)
+			for _, method := range stubs.methods {
+				writeStub(&source, stubs.typeName, method)
+			}
+			source.WriteString("}\n")
+		}
+	}
+	formatted, err := format.Source([]byte(source.String()))
+	if err != nil {
+		return nil, fmt.Errorf("Could not format the generated code: %v", err)
+	}
+	return formatted, nil
+}
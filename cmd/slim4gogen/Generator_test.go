@@ -0,0 +1,99 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+const testSource = `package shop
+
+type Order struct{}
+
+func NewOrder() *Order { return new(Order) }
+
+func (order *Order) SetUnits(units int32, confirmed bool) {}
+func (order *Order) Price(currency string) float64 { return 0 }
+func (order *Order) Items() []string { return nil }
+func (order *Order) Split() (*Order, *Order) { return nil, nil }
+func (order *Order) note() {}
+
+type Basket struct{}
+
+//slim:fixture
+func EmptyBasket() Basket { return Basket{} }
+
+func (basket Basket) Count() int { return 0 }
+
+type Catalog struct{}
+
+func NewCatalog() *Catalog { return new(Catalog) }
+
+func (catalog *Catalog) NewProduct(name string) *Product { return new(Product) }
+
+type Product struct{}
+
+func (product *Product) Name() string { return "" }
+
+func NewHelper() string { return "" }
+`
+
+func writeTestPackage(t *testing.T) string {
+	dir := t.TempDir()
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(dir, "shop.go"), []byte(testSource), 0644), "Write source")
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(dir, "shop_test.go"), []byte("package shop_test\n"), 0644), "Write test")
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(dir, "out.go"), []byte("package other\n"), 0644), "Write previous output")
+	return dir
+}
+
+func TestGeneratorScan(t *testing.T) {
+	fixtures, err := Scan(writeTestPackage(t), "out.go")
+	assert.Equals(t, nil, err, "Scan without error")
+	assert.Equals(t, "shop", fixtures.packageName, "Package name")
+	assert.Equals(t, "EmptyBasket NewOrder", strings.Join(fixtures.constructors, " "), "Constructors by convention and marker")
+	assert.Equals(t, "NewCatalog", strings.Join(fixtures.factories, " "), "Factories")
+	assert.Equals(t, 2, len(fixtures.stubs), "Stubs for pointer fixtures only")
+	assert.Equals(t, "Order", fixtures.stubs[0].typeName, "Stubs for constructed fixture")
+	assert.Equals(t, 2, len(fixtures.stubs[0].methods), "Methods with predefined types only")
+	assert.Equals(t, "Price", fixtures.stubs[0].methods[0].name, "Methods are sorted")
+	assert.Equals(t, "int32 bool", strings.Join(fixtures.stubs[0].methods[1].paramTypes, " "), "Parameter types")
+	assert.Equals(t, "Product", fixtures.stubs[1].typeName, "Stubs for fixture made by factory")
+
+	_, err = Scan(t.TempDir(), "out.go")
+	assert.IsTrue(t, strings.HasPrefix(err.Error(), "Expected one package in "), "Empty directory")
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	dir := writeTestPackage(t)
+	assert.Equals(t, nil, generate(dir, "out.go", true), "Generate with stubs")
+	source, err := os.ReadFile(filepath.Join(dir, "out.go"))
+	assert.Equals(t, nil, err, "Read output")
+	text := string(source)
+	assert.IsTrue(t, strings.HasPrefix(text, header+"\n\npackage shop\n"), "Header and package")
+	assert.IsTrue(t, strings.Contains(text, "registry.RegisterFixturesFrom(NewCatalog())"), "Factory registered")
+	assert.IsTrue(t, strings.Contains(text, "registry.RegisterFixture(EmptyBasket)"), "Marked constructor registered")
+	assert.IsTrue(t, strings.Contains(text, `registry.RegisterStubs((*Order)(nil), slim4goOrderStubs)`), "Stubs registered")
+	assert.IsTrue(t, strings.Contains(text, `arg0, err := slimfixture.ParseInt(args[0], "int32")`), "Int parameter parsed")
+	assert.IsTrue(t, strings.Contains(text, "arg1, err := slimfixture.ParseBool(args[1])"), "Bool parameter parsed")
+	assert.IsTrue(t, strings.Contains(text, "instance.(*Order).SetUnits(int32(arg0), arg1)"), "Method called")
+	assert.IsTrue(t, strings.Contains(text, "return []interface{}{instance.(*Order).Price(args[0])}, nil"), "Result returned")
+
+	fixtures, err := Scan(dir, "out.go")
+	assert.Equals(t, nil, err, "Generated file is skipped")
+	withoutStubs, _ := fixtures.Generate(false)
+	assert.IsTrue(t, !strings.Contains(string(withoutStubs), "Stubs"), "No stubs")
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package main is slim4gogen, which generates the code registering the fixtures of a package (and optionally stubs
+// calling their methods without reflection). Use it via a go:generate directive in the fixture package, e.g.
+//
+//	//go:generate go run github.com/essenius/slim4go/cmd/slim4gogen -stubs
+//
+// It writes a RegisterFixtures function that a main package or a plugin can call with slim4go.Server() or its registry.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func generate(dir string, output string, withStubs bool) error {
+	fixtures, err := Scan(dir, output)
+	if err != nil {
+		return err
+	}
+	source, err := fixtures.Generate(withStubs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, output), source, 0644)
+}
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the fixture package")
+	output := flag.String("output", "slim4go_fixtures.go", "name of the generated file, in the directory of the package")
+	withStubs := flag.Bool("stubs", false, "also generate stubs that call methods without reflection")
+	flag.Parse()
+	if err := generate(*dir, *output, *withStubs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package demofixtures contains the fixtures of the demo. RegisterFixtures (in slim4go_fixtures.go) is generated:
+// run go generate after adding a fixture.
+package demofixtures
+
+//go:generate go run ../../cmd/slim4gogen -stubs
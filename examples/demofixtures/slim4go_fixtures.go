@@ -0,0 +1,163 @@
+// Code generated by slim4gogen. DO NOT EDIT.
+
+package demofixtures
+
+import "github.com/essenius/slim4go/slimfixture"
+
+// RegisterFixtures registers the fixtures of package demofixtures.
+func RegisterFixtures(registry slimfixture.Registry) error {
+	if err := registry.RegisterFixturesFrom(NewTemperatureFactory()); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewArray); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewCounter); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewDictionary); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewFibonacciFixture); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewFixtureMapping); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewMemoObject); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewTableFixture); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewTestQuery); err != nil {
+		return err
+	}
+	if err := registry.RegisterFixture(NewWaiter); err != nil {
+		return err
+	}
+	if err := registry.RegisterStubs((*Counter)(nil), slim4goCounterStubs); err != nil {
+		return err
+	}
+	if err := registry.RegisterStubs((*Dictionary)(nil), slim4goDictionaryStubs); err != nil {
+		return err
+	}
+	if err := registry.RegisterStubs((*FibonacciFixture)(nil), slim4goFibonacciFixtureStubs); err != nil {
+		return err
+	}
+	if err := registry.RegisterStubs((*Temperature)(nil), slim4goTemperatureStubs); err != nil {
+		return err
+	}
+	if err := registry.RegisterStubs((*Waiter)(nil), slim4goWaiterStubs); err != nil {
+		return err
+	}
+	return nil
+}
+
+var slim4goCounterStubs = slimfixture.Stubs{
+	"CountUp": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		instance.(*Counter).CountUp()
+		return nil, nil
+	},
+	"SetCount": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		arg0, err := slimfixture.ParseInt(args[0], "int64")
+		if err != nil {
+			return nil, err
+		}
+		instance.(*Counter).SetCount(int64(arg0))
+		return nil, nil
+	},
+	"Value": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*Counter).Value()}, nil
+	},
+}
+
+var slim4goDictionaryStubs = slimfixture.Stubs{
+	"AddItem": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 2); err != nil {
+			return nil, err
+		}
+		instance.(*Dictionary).AddItem(args[0], args[1])
+		return nil, nil
+	},
+	"Contains": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*Dictionary).Contains(args[0])}, nil
+	},
+	"GetValue": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*Dictionary).GetValue(args[0])}, nil
+	},
+}
+
+var slim4goFibonacciFixtureStubs = slimfixture.Stubs{
+	"Execute": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		instance.(*FibonacciFixture).Execute()
+		return nil, nil
+	},
+	"Fibonacci": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*FibonacciFixture).Fibonacci()}, nil
+	},
+	"Reset": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		instance.(*FibonacciFixture).Reset()
+		return nil, nil
+	},
+}
+
+var slim4goTemperatureStubs = slimfixture.Stubs{
+	"Parse": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		instance.(*Temperature).Parse(args[0])
+		return nil, nil
+	},
+	"ToString": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 0); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*Temperature).ToString()}, nil
+	},
+	"ValueIn": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return []interface{}{instance.(*Temperature).ValueIn(args[0])}, nil
+	},
+}
+
+var slim4goWaiterStubs = slimfixture.Stubs{
+	"Wait": func(instance interface{}, args []string) ([]interface{}, error) {
+		if err := slimfixture.CheckArgs(args, 1); err != nil {
+			return nil, err
+		}
+		arg0, err := slimfixture.ParseInt(args[0], "int64")
+		if err != nil {
+			return nil, err
+		}
+		instance.(*Waiter).Wait(int64(arg0))
+		return nil, nil
+	},
+}
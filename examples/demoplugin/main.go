@@ -0,0 +1,30 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package main is an example fixture plugin. Build it with
+//
+//	go build -buildmode=plugin -o demoplugin.so ./examples/demoplugin
+//
+// and load it with a runner, e.g. slim4gorunner -plugins demoplugin.so 8485
+package main
+
+import (
+	"github.com/essenius/slim4go"
+	"github.com/essenius/slim4go/examples/demofixtures"
+)
+
+// RegisterFixtures registers the fixtures of the plugin. The runner calls it when loading the plugin.
+func RegisterFixtures(registry slim4go.Registry) error {
+	return demofixtures.RegisterFixtures(registry)
+}
+
+// main is not used, as plugins are loaded by a runner.
+func main() {}
@@ -0,0 +1,25 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package apperrors
+
+import "fmt"
+
+// AccessDeniedError is used when an access policy doesn't allow invoking a member (method or field) of an instance.
+type AccessDeniedError struct {
+	Member  string
+	Fixture string
+	Reason  string
+}
+
+func (accessDeniedError *AccessDeniedError) Error() string {
+	return fmt.Sprintf("%v.%v: access denied (%v)", accessDeniedError.Fixture, accessDeniedError.Member, accessDeniedError.Reason)
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package apperrors
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PanicError is used when a panic was recovered. It contains the stack trace of the panic, trimmed to the relevant frames.
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+// maxStackFrames limits the size of stack traces.
+const maxStackFrames = 30
+
+// NewPanicError creates a PanicError for a recovered panic. Call it from the deferred function that recovered.
+// The stack trace starts where the panic happened, and ends before the function with the boundary suffix (the one that recovered).
+func NewPanicError(panicData interface{}, boundary string) *PanicError {
+	panicError := new(PanicError)
+	panicError.Value = panicData
+	panicError.Stack = panicStack(boundary)
+	return panicError
+}
+
+// panicStack returns the frames between the panic and the boundary, leaving out the runtime and reflection frames.
+func panicStack(boundary string) string {
+	programCounters := make([]uintptr, 100)
+	frames := runtime.CallersFrames(programCounters[:runtime.Callers(1, programCounters)])
+	var builder strings.Builder
+	panicked := false
+	for count, more := 0, true; more && count < maxStackFrames; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		switch {
+		case !panicked:
+			// skip the frames of the recovery
+			panicked = frame.Function == "runtime.gopanic"
+		case strings.HasSuffix(frame.Function, boundary):
+			more = false
+		case strings.HasPrefix(frame.Function, "runtime.") || strings.HasPrefix(frame.Function, "reflect."):
+		default:
+			builder.WriteString(fmt.Sprintf("%v\n\t%v:%v\n", frame.Function, frame.File, frame.Line))
+			count++
+		}
+	}
+	return builder.String()
+}
+
+func (panicError *PanicError) Error() string {
+	return "Panic: " + ErrorToString(panicError.Value)
+}
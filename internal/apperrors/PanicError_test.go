@@ -0,0 +1,43 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package apperrors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func panicker() {
+	panic("boom")
+}
+
+func recoverPanic() (err *PanicError) {
+	defer func() {
+		if panicData := recover(); panicData != nil {
+			err = NewPanicError(panicData, "apperrors.recoverPanic")
+		}
+	}()
+	panicker()
+	return nil
+}
+
+func TestPanicError(t *testing.T) {
+	err := recoverPanic()
+	assert.Equals(t, "Panic: boom", err.Error(), "Error")
+	lines := strings.Split(strings.TrimSpace(err.Stack), "\n")
+	assert.Equals(t, 2, len(lines), "Only the panicking frame is in the stack trace")
+	assert.Equals(t, "github.com/essenius/slim4go/internal/apperrors.panicker", lines[0], "Function")
+	assert.IsTrue(t, strings.HasSuffix(lines[1], "PanicError_test.go:22"), "File and line")
+	assert.Equals(t, "Panic: oops", (&PanicError{Value: "oops"}).Error(), "Error without stack")
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package catalog describes what is registered, so test writers know which table rows are available.
+// It is kept apart from the fixture registry, so the interfaces can use it without depending on the registry.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Catalog describes the registered fixtures and libraries.
+type Catalog struct {
+	Namespaces []string       `json:"namespaces"`
+	Fixtures   []*FixtureInfo `json:"fixtures"`
+	Libraries  []*TypeInfo    `json:"libraries"`
+}
+
+// FixtureInfo describes a registered fixture: its name, constructor parameters and the members of the type it creates.
+// The package is the full package path, which distinguishes fixtures with the same name in packages with the same name.
+type FixtureInfo struct {
+	Name        string     `json:"name"`
+	Namespace   string     `json:"namespace"`
+	ShortName   string     `json:"shortName"`
+	Package     string     `json:"package"`
+	Aliases     []string   `json:"aliases,omitempty"`
+	Constructor *Signature `json:"constructor"`
+	*TypeInfo
+}
+
+// TypeInfo describes the members of a type that can be used in tables.
+type TypeInfo struct {
+	Type    string       `json:"type"`
+	Doc     string       `json:"doc,omitempty"`
+	Methods []*Signature `json:"methods"`
+	Fields  []*FieldInfo `json:"fields"`
+}
+
+// Signature describes a method or function.
+type Signature struct {
+	Name       string   `json:"name,omitempty"`
+	Parameters []string `json:"parameters"`
+	Results    []string `json:"results"`
+	Variadic   bool     `json:"variadic"`
+	Doc        string   `json:"doc,omitempty"`
+}
+
+// FieldInfo describes an exported (and therefore settable) field of a struct.
+// The description can be specified via a doc tag, e.g. `doc:"amount in euros"`.
+type FieldInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// Helpers
+
+// SignatureOf describes a function type, leaving out the receiver if skipReceiver is set.
+func SignatureOf(name string, functionType reflect.Type, skipReceiver bool) *Signature {
+	signature := &Signature{Name: name, Parameters: []string{}, Results: []string{}, Variadic: functionType.IsVariadic()}
+	start := 0
+	if skipReceiver {
+		start = 1
+	}
+	for i := start; i < functionType.NumIn(); i++ {
+		parameterType := functionType.In(i)
+		if signature.Variadic && i == functionType.NumIn()-1 {
+			signature.Parameters = append(signature.Parameters, "..."+parameterType.Elem().String())
+		} else {
+			signature.Parameters = append(signature.Parameters, parameterType.String())
+		}
+	}
+	for i := 0; i < functionType.NumOut(); i++ {
+		signature.Results = append(signature.Results, functionType.Out(i).String())
+	}
+	return signature
+}
+
+// Describe returns the methods and fields of a type that can be used in tables.
+func Describe(instanceType reflect.Type) *TypeInfo {
+	info := &TypeInfo{Type: instanceType.String(), Methods: []*Signature{}, Fields: []*FieldInfo{}}
+	for i := 0; i < instanceType.NumMethod(); i++ {
+		method := instanceType.Method(i)
+		info.Methods = append(info.Methods, SignatureOf(method.Name, method.Type, instanceType.Kind() != reflect.Interface))
+	}
+	structType := instanceType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() == reflect.Struct {
+		for i := 0; i < structType.NumField(); i++ {
+			field := structType.Field(i)
+			if field.PkgPath == "" && !field.Anonymous {
+				info.Fields = append(info.Fields, &FieldInfo{Name: field.Name, Type: field.Type.String(), Doc: field.Tag.Get("doc")})
+			}
+		}
+	}
+	return info
+}
+
+// Signature methods
+
+func (signature *Signature) String() string {
+	result := fmt.Sprintf("%v(%v)", signature.Name, strings.Join(signature.Parameters, ", "))
+	switch len(signature.Results) {
+	case 0:
+		return result
+	case 1:
+		return result + " " + signature.Results[0]
+	default:
+		return result + " (" + strings.Join(signature.Results, ", ") + ")"
+	}
+}
+
+// Catalog methods
+
+// Write writes the catalog in the specified format (text or json).
+func (catalog *Catalog) Write(writer io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "text":
+		return catalog.WriteText(writer)
+	case "json":
+		return catalog.WriteJSON(writer)
+	default:
+		return fmt.Errorf("Unknown catalog format '%v'. Expected text or json", format)
+	}
+}
+
+// WriteJSON writes the catalog as indented JSON.
+func (catalog *Catalog) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(catalog)
+}
+
+// WriteText writes the catalog in a human readable format.
+func (catalog *Catalog) WriteText(writer io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("Fixtures:\n")
+	for _, fixture := range catalog.Fixtures {
+		builder.WriteString(fmt.Sprintf("  %v(%v)", fixture.Name, strings.Join(fixture.Constructor.Parameters, ", ")))
+		if len(fixture.Aliases) > 0 {
+			builder.WriteString(fmt.Sprintf(" alias %v", strings.Join(fixture.Aliases, ", ")))
+		}
+		builder.WriteString("\n")
+		writeMembers(&builder, fixture.TypeInfo)
+	}
+	builder.WriteString("Libraries:\n")
+	for _, library := range catalog.Libraries {
+		builder.WriteString(fmt.Sprintf("  %v\n", library.Type))
+		writeMembers(&builder, library)
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+func writeMembers(builder *strings.Builder, info *TypeInfo) {
+	for _, method := range info.Methods {
+		builder.WriteString(fmt.Sprintf("    %v\n", method))
+	}
+	for _, field := range info.Fields {
+		builder.WriteString(fmt.Sprintf("    %v %v (field)\n", field.Name, field.Type))
+	}
+}
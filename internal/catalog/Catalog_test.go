@@ -0,0 +1,90 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package catalog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+type Invoice struct {
+	Customer string
+	Lines    []string
+	total    float64
+}
+
+func NewInvoice(customer string, lines ...string) *Invoice {
+	return &Invoice{Customer: customer, Lines: lines}
+}
+
+func (invoice *Invoice) AddLine(line string, amount float64) {
+	invoice.Lines = append(invoice.Lines, line)
+	invoice.total += amount
+}
+
+func (invoice *Invoice) Total() float64 {
+	return invoice.total
+}
+
+func (invoice *Invoice) Split(parts int) (float64, error) {
+	return invoice.total / float64(parts), nil
+}
+
+type Order struct{}
+
+func NewOrder() *Order {
+	return new(Order)
+}
+
+type Library struct {
+	name string
+}
+
+func (library *Library) Name() string {
+	return library.name
+}
+
+// newTestCatalog describes the fixtures (in order of name) and libraries of this package, like the registry does.
+func newTestCatalog(namespaces []string, libraries []interface{}, constructors ...interface{}) *Catalog {
+	catalog := &Catalog{Namespaces: namespaces, Fixtures: []*FixtureInfo{}, Libraries: []*TypeInfo{}}
+	for _, constructor := range constructors {
+		constructorType := reflect.TypeOf(constructor)
+		fixtureType := constructorType.Out(0).Elem()
+		catalog.Fixtures = append(catalog.Fixtures, &FixtureInfo{
+			Name:        "catalog." + fixtureType.Name(),
+			Namespace:   "catalog",
+			ShortName:   fixtureType.Name(),
+			Package:     fixtureType.PkgPath(),
+			Constructor: SignatureOf("", constructorType, false),
+			TypeInfo:    Describe(constructorType.Out(0)),
+		})
+	}
+	for _, library := range libraries {
+		catalog.Libraries = append(catalog.Libraries, Describe(reflect.TypeOf(library)))
+	}
+	return catalog
+}
+
+func TestCatalogDescribe(t *testing.T) {
+	info := Describe(reflect.TypeOf(NewInvoice("")))
+	assert.Equals(t, "*catalog.Invoice", info.Type, "Type")
+	assert.Equals(t, 3, len(info.Methods), "Methods")
+	assert.Equals(t, "AddLine(string, float64)", info.Methods[0].String(), "Method without result")
+	assert.Equals(t, "Split(int) (float64, error)", info.Methods[1].String(), "Method with two results")
+	assert.Equals(t, "Total() float64", info.Methods[2].String(), "Method with result")
+	assert.Equals(t, 2, len(info.Fields), "Only exported fields")
+	assert.Equals(t, "Customer", info.Fields[0].Name, "Field name")
+	assert.Equals(t, "[]string", info.Fields[1].Type, "Field type")
+	assert.Equals(t, "(string, ...string) *catalog.Invoice", SignatureOf("", reflect.TypeOf(NewInvoice), false).String(), "Variadic function")
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// Reflection can't see doc comments, so we get them from the sources of the fixtures.
+// Documentation is keyed by package and type name (e.g. demofixtures.Temperature),
+// with the member name appended for methods and fields (e.g. demofixtures.Temperature.Parse).
+
+// Documentation contains the doc comments of types, methods and fields.
+type Documentation map[string]string
+
+// Helpers
+
+func firstParagraph(comment string) string {
+	paragraph := strings.SplitN(strings.TrimSpace(comment), "\n\n", 2)[0]
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+func typeKey(typeName string) string {
+	return strings.TrimLeft(typeName, "*")
+}
+
+func (documentation Documentation) addFields(typeName string, typeDoc *doc.Type) {
+	for _, spec := range typeDoc.Decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		structType, ok := typeSpec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range structType.Fields.List {
+			comment := field.Doc.Text()
+			if comment == "" {
+				comment = field.Comment.Text()
+			}
+			for _, name := range field.Names {
+				documentation[typeName+"."+name.Name] = firstParagraph(comment)
+			}
+		}
+	}
+}
+
+func (documentation Documentation) describe(info *TypeInfo) {
+	key := typeKey(info.Type)
+	if info.Doc == "" {
+		info.Doc = documentation[key]
+	}
+	for _, method := range info.Methods {
+		if method.Doc == "" {
+			method.Doc = documentation[key+"."+method.Name]
+		}
+	}
+	for _, field := range info.Fields {
+		if field.Doc == "" {
+			field.Doc = documentation[key+"."+field.Name]
+		}
+	}
+}
+
+// Methods
+
+// ReadDocumentation reads the doc comments of the exported types in the Go source folders (tests excluded).
+func ReadDocumentation(folders ...string) (Documentation, error) {
+	documentation := make(Documentation)
+	for _, folder := range folders {
+		fileNames, err := filepath.Glob(filepath.Join(folder, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		fileSet := token.NewFileSet()
+		var files []*ast.File
+		for _, fileName := range fileNames {
+			if strings.HasSuffix(fileName, "_test.go") {
+				continue
+			}
+			file, err := parser.ParseFile(fileSet, fileName, nil, parser.ParseComments)
+			if err != nil {
+				return nil, fmt.Errorf("Could not read documentation: %v", err)
+			}
+			files = append(files, file)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		packageDoc, err := doc.NewFromFiles(fileSet, files, folder)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read documentation: %v", err)
+		}
+		for _, typeDoc := range packageDoc.Types {
+			typeName := packageDoc.Name + "." + typeDoc.Name
+			documentation[typeName] = firstParagraph(typeDoc.Doc)
+			for _, method := range typeDoc.Methods {
+				documentation[typeName+"."+method.Name] = firstParagraph(method.Doc)
+			}
+			documentation.addFields(typeName, typeDoc)
+		}
+	}
+	return documentation, nil
+}
+
+// Catalog methods
+
+// AddDocumentation adds the doc comments to the fixtures and libraries in the catalog.
+// Descriptions that were already set (e.g. via doc tags) are kept.
+func (catalog *Catalog) AddDocumentation(documentation Documentation) {
+	for _, fixture := range catalog.Fixtures {
+		documentation.describe(fixture.TypeInfo)
+	}
+	for _, library := range catalog.Libraries {
+		documentation.describe(library)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+const paymentSource = `package billing
+
+// Payment is a transfer of money.
+//
+// Details that are not in the first paragraph are left out.
+type Payment struct {
+	// Amount is the amount
+	// in euros.
+	Amount float64
+	Currency string // ISO currency code
+}
+
+// Pay executes the payment.
+func (payment *Payment) Pay() bool {
+	return true
+}
+`
+
+type Payment struct {
+	Amount   float64
+	Currency string `doc:"Currency code"`
+}
+
+func (payment *Payment) Pay() bool {
+	return true
+}
+
+func TestDocumentationRead(t *testing.T) {
+	folder := t.TempDir()
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(folder, "Payment.go"), []byte(paymentSource), 0644), "Write source")
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(folder, "Payment_test.go"), []byte("package billing_test\n"), 0644), "Write test")
+	documentation, err := ReadDocumentation(folder)
+	assert.Equals(t, nil, err, "No error")
+	assert.Equals(t, "Payment is a transfer of money.", documentation["billing.Payment"], "Type doc (first paragraph)")
+	assert.Equals(t, "Pay executes the payment.", documentation["billing.Payment.Pay"], "Method doc")
+	assert.Equals(t, "Amount is the amount in euros.", documentation["billing.Payment.Amount"], "Field doc")
+	assert.Equals(t, "ISO currency code", documentation["billing.Payment.Currency"], "Field line comment")
+
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(folder, "Broken.go"), []byte("package billing\nfunc {"), 0644), "Write broken source")
+	_, err = ReadDocumentation(folder)
+	assert.IsTrue(t, err != nil, "Error with unparsable source")
+}
+
+func TestDocumentationAdd(t *testing.T) {
+	catalog := &Catalog{Libraries: []*TypeInfo{Describe(reflect.TypeOf(&Payment{}))}}
+	documentation := Documentation{
+		"catalog.Payment":          "Payment doc",
+		"catalog.Payment.Pay":      "Pay doc",
+		"catalog.Payment.Amount":   "Amount doc",
+		"catalog.Payment.Currency": "Overridden by the tag",
+	}
+	catalog.AddDocumentation(documentation)
+	library := catalog.Libraries[0]
+	assert.Equals(t, "Payment doc", library.Doc, "Type doc")
+	assert.Equals(t, "Pay doc", library.Methods[0].Doc, "Method doc")
+	assert.Equals(t, "Amount doc", library.Fields[0].Doc, "Field doc")
+	assert.Equals(t, "Currency code", library.Fields[1].Doc, "Doc tag takes precedence")
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// The wiki reference is a FitNesse page tree documenting the catalog: a FixtureReference page with a sub page per fixture
+// and one for the libraries. Each page shows the constructor, the callable rows and example tables.
+// The pages are static (see WikiPageName), so the examples in them don't get executed.
+
+// WikiReferencePage is the name of the page containing the reference.
+const WikiReferencePage = "FixtureReference"
+
+const librariesPage = "LibraryFunctions"
+
+// Helpers
+
+// wikiWord converts a name into a FitNesse page name (a word with at least two capitals and no consecutive capitals).
+func wikiWord(name string) string {
+	var builder strings.Builder
+	isSeparator := func(character rune) bool { return !unicode.IsLetter(character) && !unicode.IsDigit(character) }
+	for _, part := range strings.FieldsFunc(name, isSeparator) {
+		for _, word := range strings.Fields(tableName(part)) {
+			runes := []rune(word)
+			builder.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+		}
+	}
+	return builder.String()
+}
+
+// tableName converts a Go member name into the way it is used in tables, e.g. GetValue becomes get value.
+func tableName(name string) string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		startsWord := unicode.IsUpper(runes[i]) &&
+			(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+		if startsWord {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	return strings.ToLower(strings.Join(words, " "))
+}
+
+// literal makes sure the text isn't interpreted as wiki markup.
+func literal(text string) string {
+	if text == "" {
+		return " "
+	}
+	return "!-" + text + "-!"
+}
+
+func valueType(typeName string) string {
+	if typeName == "interface {}" || typeName == "slimentity.SlimEntity" {
+		return "value"
+	}
+	return typeName
+}
+
+func cells(values ...string) string {
+	return "|" + strings.Join(values, "|") + "|\n"
+}
+
+func literals(values []string) []string {
+	var result []string
+	for _, value := range values {
+		result = append(result, literal(valueType(value)))
+	}
+	return result
+}
+
+// resultOf returns the result type used in tables: errors are reported as exceptions, not as values.
+func resultOf(signature *Signature) string {
+	for _, result := range signature.Results {
+		if result != "error" {
+			return result
+		}
+	}
+	return ""
+}
+
+func scriptRow(method *Signature) string {
+	name := tableName(method.Name)
+	if len(method.Parameters) > 0 {
+		name += ";"
+	}
+	row := append([]string{name}, literals(method.Parameters)...)
+	switch resultOf(method) {
+	case "":
+		return cells(row...)
+	case "bool":
+		return cells(append([]string{"ensure"}, row...)...)
+	default:
+		return cells(append(append([]string{"check"}, row...), literal(valueType(resultOf(method))))...)
+	}
+}
+
+func writeMethodTable(builder *strings.Builder, methods []*Signature) {
+	builder.WriteString(cells("''row''", "''parameters''", "''result''", "''description''"))
+	for _, method := range methods {
+		builder.WriteString(cells(tableName(method.Name), literal(strings.Join(method.Parameters, ", ")),
+			literal(valueType(resultOf(method))), literal(method.Doc)))
+	}
+}
+
+// writeDecisionExample writes a decision table example if the fixture has inputs (fields or setters).
+func writeDecisionExample(builder *strings.Builder, fixture *FixtureInfo) {
+	var headers, values []string
+	inputCount := len(fixture.Fields)
+	for _, field := range fixture.Fields {
+		headers = append(headers, tableName(field.Name))
+		values = append(values, literal(field.Type))
+	}
+	for _, method := range fixture.Methods {
+		result := resultOf(method)
+		switch {
+		case method.Name == "Get" || method.Name == "Set":
+			continue
+		case strings.HasPrefix(method.Name, "Set") && len(method.Parameters) == 1 && result == "":
+			headers = append(headers, tableName(strings.TrimPrefix(method.Name, "Set")))
+			values = append(values, literal(method.Parameters[0]))
+			inputCount++
+		case len(method.Parameters) == 0 && result != "":
+			headers = append(headers, tableName(strings.TrimPrefix(method.Name, "Get"))+"?")
+			values = append(values, literal(valueType(result)))
+		}
+	}
+	if inputCount == 0 {
+		return
+	}
+	builder.WriteString("\n!4 Decision table example\n")
+	builder.WriteString(cells(append([]string{"decision:" + fixture.ShortName}, literals(fixture.Constructor.Parameters)...)...))
+	builder.WriteString(cells(headers...))
+	builder.WriteString(cells(values...))
+}
+
+func writePage(folder, pageName, content string) error {
+	return os.WriteFile(filepath.Join(folder, pageName+".wiki"), []byte(content), 0644)
+}
+
+// Catalog methods
+
+// WikiPageName returns the name of the reference page of a fixture.
+// FitNesse runs pages starting or ending with Test or Suite, so the name ends with Fixture and gets a prefix if needed.
+// Fixtures with the same short name get their namespace as prefix, or their package path if the namespaces are the same too.
+func (catalog *Catalog) WikiPageName(fixture *FixtureInfo) string {
+	prefix := ""
+	for _, other := range catalog.Fixtures {
+		if other == fixture || other.ShortName != fixture.ShortName {
+			continue
+		}
+		if other.Name == fixture.Name {
+			prefix = fixture.Package
+			break
+		}
+		prefix = fixture.Namespace
+	}
+	pageName := wikiWord(prefix) + wikiWord(fixture.ShortName)
+	if !strings.HasSuffix(pageName, "Fixture") {
+		pageName += "Fixture"
+	}
+	if strings.HasPrefix(pageName, "Test") || strings.HasPrefix(pageName, "Suite") {
+		pageName = "Reference" + pageName
+	}
+	return pageName
+}
+
+// FixturePage returns the wiki text of the reference page of a fixture.
+func (catalog *Catalog) FixturePage(fixture *FixtureInfo) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("!3 %v\n", literal(fixture.ShortName)))
+	if fixture.Doc != "" {
+		builder.WriteString(literal(fixture.Doc) + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("\nFixture %v creates %v.\n", literal(fixture.Name), literal(fixture.Type)))
+	builder.WriteString("\n!4 Constructor arguments\n")
+	if len(fixture.Constructor.Parameters) == 0 {
+		builder.WriteString("None\n")
+	} else {
+		builder.WriteString(cells(literals(fixture.Constructor.Parameters)...))
+	}
+	if len(fixture.Methods) > 0 {
+		builder.WriteString("\n!4 Rows\n")
+		writeMethodTable(&builder, fixture.Methods)
+	}
+	if len(fixture.Fields) > 0 {
+		builder.WriteString("\n!4 Fields\n")
+		builder.WriteString(cells("''column''", "''type''", "''description''"))
+		for _, field := range fixture.Fields {
+			builder.WriteString(cells(tableName(field.Name), literal(field.Type), literal(field.Doc)))
+		}
+	}
+	builder.WriteString("\n!4 Script table example\n")
+	builder.WriteString(cells(append([]string{"script", fixture.ShortName}, literals(fixture.Constructor.Parameters)...)...))
+	for _, method := range fixture.Methods {
+		builder.WriteString(scriptRow(method))
+	}
+	writeDecisionExample(&builder, fixture)
+	return builder.String()
+}
+
+// LibrariesPage returns the wiki text of the reference page of the libraries.
+func (catalog *Catalog) LibrariesPage() string {
+	var builder strings.Builder
+	builder.WriteString("!3 Library functions\n")
+	builder.WriteString("These rows can be used in any script table. If several libraries have the same row, the first one listed wins.\n")
+	for _, library := range catalog.Libraries {
+		builder.WriteString(fmt.Sprintf("\n!4 %v\n", literal(library.Type)))
+		if library.Doc != "" {
+			builder.WriteString(literal(library.Doc) + "\n")
+		}
+		writeMethodTable(&builder, library.Methods)
+		builder.WriteString("\n")
+		builder.WriteString(cells("script"))
+		for _, method := range library.Methods {
+			builder.WriteString(scriptRow(method))
+		}
+	}
+	return builder.String()
+}
+
+// RootPage returns the wiki text of the page containing the reference.
+func (catalog *Catalog) RootPage() string {
+	var builder strings.Builder
+	builder.WriteString("Reference of the fixtures and libraries that slim4go can use.\n")
+	builder.WriteString("Generated from the Go code (via the -wiki option); edits will be overwritten when it is generated again.\n")
+	if len(catalog.Namespaces) > 0 {
+		builder.WriteString(fmt.Sprintf("\nImported namespaces: %v\n", literal(strings.Join(catalog.Namespaces, ", "))))
+	}
+	builder.WriteString("\n")
+	builder.WriteString(cells("''fixture''", "''constructor arguments''", "''description''"))
+	for _, fixture := range catalog.Fixtures {
+		builder.WriteString(cells(fmt.Sprintf("[[%v][>%v]]", fixture.Name, catalog.WikiPageName(fixture)),
+			literal(strings.Join(fixture.Constructor.Parameters, ", ")), literal(fixture.Doc)))
+	}
+	if len(catalog.Libraries) > 0 {
+		builder.WriteString(fmt.Sprintf("\nSee >%v for the rows available in all script tables.\n", librariesPage))
+	}
+	return builder.String()
+}
+
+// WriteWiki writes the wiki reference into a FitNesse page folder, replacing a previously generated reference.
+func (catalog *Catalog) WriteWiki(parentFolder string) error {
+	folder := filepath.Join(parentFolder, WikiReferencePage)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return err
+	}
+	existingPages, err := filepath.Glob(filepath.Join(folder, "*.wiki"))
+	if err != nil {
+		return err
+	}
+	for _, page := range existingPages {
+		if err := os.Remove(page); err != nil {
+			return err
+		}
+	}
+	if err := writePage(folder, "_root", catalog.RootPage()); err != nil {
+		return err
+	}
+	for _, fixture := range catalog.Fixtures {
+		if err := writePage(folder, catalog.WikiPageName(fixture), catalog.FixturePage(fixture)); err != nil {
+			return err
+		}
+	}
+	if len(catalog.Libraries) > 0 {
+		return writePage(folder, librariesPage, catalog.LibrariesPage())
+	}
+	return nil
+}
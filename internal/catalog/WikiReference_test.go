@@ -0,0 +1,105 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+type TestQuery struct{}
+
+func NewTestQuery() *TestQuery {
+	return new(TestQuery)
+}
+
+type TableFixture struct{}
+
+func NewTableFixture() *TableFixture {
+	return new(TableFixture)
+}
+
+func TestWikiReferenceNames(t *testing.T) {
+	assert.Equals(t, "HttpClient", wikiWord("HTTPClient"), "Consecutive capitals")
+	assert.Equals(t, "MyFixtures", wikiWord("my.fixtures"), "Separators removed")
+	assert.Equals(t, "get value", tableName("GetValue"), "Table name")
+	assert.Equals(t, "unique id", tableName("UniqueID"), "Acronym at end")
+	assert.Equals(t, "http client", tableName("HTTPClient"), "Acronym at start")
+
+	catalog := newTestCatalog(nil, nil, NewInvoice, NewTableFixture, NewTestQuery)
+	assert.Equals(t, "InvoiceFixture", catalog.WikiPageName(catalog.Fixtures[0]), "Fixture suffix")
+	assert.Equals(t, "TableFixture", catalog.WikiPageName(catalog.Fixtures[1]), "No double suffix")
+	assert.Equals(t, "ReferenceTestQueryFixture", catalog.WikiPageName(catalog.Fixtures[2]), "No test page")
+}
+
+func TestWikiReferenceFixturePage(t *testing.T) {
+	catalog := newTestCatalog(nil, nil, NewInvoice)
+	invoice := catalog.Fixtures[0]
+	invoice.Doc = "Invoice for a customer"
+	invoice.Methods[2].Doc = "Total amount"
+	assert.Equals(t, "!3 !-Invoice-!\n"+
+		"!-Invoice for a customer-!\n"+
+		"\nFixture !-catalog.Invoice-! creates !-*catalog.Invoice-!.\n"+
+		"\n!4 Constructor arguments\n"+
+		"|!-string-!|!-...string-!|\n"+
+		"\n!4 Rows\n"+
+		"|''row''|''parameters''|''result''|''description''|\n"+
+		"|add line|!-string, float64-!| | |\n"+
+		"|split|!-int-!|!-float64-!| |\n"+
+		"|total| |!-float64-!|!-Total amount-!|\n"+
+		"\n!4 Fields\n"+
+		"|''column''|''type''|''description''|\n"+
+		"|customer|!-string-!| |\n"+
+		"|lines|!-[]string-!| |\n"+
+		"\n!4 Script table example\n"+
+		"|script|Invoice|!-string-!|!-...string-!|\n"+
+		"|add line;|!-string-!|!-float64-!|\n"+
+		"|check|split;|!-int-!|!-float64-!|\n"+
+		"|check|total|!-float64-!|\n"+
+		"\n!4 Decision table example\n"+
+		"|decision:Invoice|!-string-!|!-...string-!|\n"+
+		"|customer|lines|total?|\n"+
+		"|!-string-!|!-[]string-!|!-float64-!|\n", catalog.FixturePage(invoice), "Fixture page")
+}
+
+func TestWikiReferenceWrite(t *testing.T) {
+	catalog := newTestCatalog([]string{"catalog"}, []interface{}{&Library{"library"}}, NewInvoice, NewOrder)
+	folder := t.TempDir()
+	referenceFolder := filepath.Join(folder, WikiReferencePage)
+	assert.Equals(t, nil, os.MkdirAll(referenceFolder, 0755), "Create reference folder")
+	assert.Equals(t, nil, os.WriteFile(filepath.Join(referenceFolder, "RemovedFixture.wiki"), []byte("old"), 0644), "Write stale page")
+
+	assert.Equals(t, nil, catalog.WriteWiki(folder), "Write wiki")
+	pages, _ := filepath.Glob(filepath.Join(referenceFolder, "*.wiki"))
+	assert.Equals(t, 4, len(pages), "Root page, two fixture pages and a library page")
+	_, err := os.Stat(filepath.Join(referenceFolder, "RemovedFixture.wiki"))
+	assert.IsTrue(t, os.IsNotExist(err), "Stale page removed")
+	root, _ := os.ReadFile(filepath.Join(referenceFolder, "_root.wiki"))
+	assert.Equals(t, "Reference of the fixtures and libraries that slim4go can use.\n"+
+		"Generated from the Go code (via the -wiki option); edits will be overwritten when it is generated again.\n"+
+		"\nImported namespaces: !-catalog-!\n"+
+		"\n|''fixture''|''constructor arguments''|''description''|\n"+
+		"|[[catalog.Invoice][>InvoiceFixture]]|!-string, ...string-!| |\n"+
+		"|[[catalog.Order][>OrderFixture]]| | |\n"+
+		"\nSee >LibraryFunctions for the rows available in all script tables.\n", string(root), "Root page")
+	library, _ := os.ReadFile(filepath.Join(referenceFolder, "LibraryFunctions.wiki"))
+	assert.Equals(t, "!3 Library functions\n"+
+		"These rows can be used in any script table. If several libraries have the same row, the first one listed wins.\n"+
+		"\n!4 !-*catalog.Library-!\n"+
+		"|''row''|''parameters''|''result''|''description''|\n"+
+		"|name| |!-string-!| |\n"+
+		"\n|script|\n"+
+		"|check|name|!-string-!|\n", string(library), "Library page")
+}
@@ -0,0 +1,351 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package context
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Settings can be specified in a configuration file, in SLIM4GO_* environment variables and on the command line.
+// Each of these overrides the previous ones, and all of them override the defaults.
+
+// Definitions
+
+// Setting is the effective value of a configurable setting, and where that value came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// configurable contains the keys of the settings (as used in configuration files) and their command line flags.
+// The port is the positional argument on the command line, so it has no flag.
+var configurable = []struct {
+	key  string
+	flag string
+}{
+	{"port", ""},
+	{"instructionTimeout", "s"},
+	{"connectionTimeout", "t"},
+	{"stackTrace", "stacktrace"},
+	{"parallel", "parallel"},
+	{"plugins", "plugins"},
+	{"source", "source"},
+	{"record", "record"},
+	{"metrics", "metrics"},
+	{"metricsAddress", "metricsaddress"},
+	{"diagnostics", "diagnostics"},
+	{"namespaces", "namespaces"},
+	{"denyNonFixtureMembers", "denynonfixtures"},
+	{"dateFormat", "dateformat"},
+	{"log", "log"},
+	{"logFormat", "logformat"},
+	{"logLevel", "loglevel"},
+}
+
+// ConfigFileVariable is the environment variable that can specify the configuration file, if the command line doesn't.
+const ConfigFileVariable = "SLIM4GO_CONFIG"
+
+// Helpers
+
+// EnvironmentVariable returns the name of the environment variable for a setting, e.g. SLIM4GO_LOG_LEVEL for logLevel.
+func EnvironmentVariable(key string) string {
+	var builder strings.Builder
+	builder.WriteString("SLIM4GO_")
+	for i, character := range key {
+		if i > 0 && unicode.IsUpper(character) {
+			builder.WriteRune('_')
+		}
+		builder.WriteRune(unicode.ToUpper(character))
+	}
+	return builder.String()
+}
+
+// normalizedKey makes keys case insensitive, and allows for different conventions (logLevel, log_level, log-level).
+func normalizedKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// indexOutsideQuotes returns the index of the first occurrence of the character that is not within quotes, or -1.
+func indexOutsideQuotes(text string, character byte) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch {
+		case quote != 0:
+			if text[i] == quote {
+				quote = 0
+			}
+		case text[i] == '"' || text[i] == '\'':
+			quote = text[i]
+		case text[i] == character:
+			return i
+		}
+	}
+	return -1
+}
+
+// isListItem returns whether a line is an item of a YAML block list, e.g. - fixtures.
+func isListItem(line string) bool {
+	return line == "-" || strings.HasPrefix(line, "- ")
+}
+
+// isSkipped returns whether a line contains no setting: empty lines, comments and YAML document starts.
+func isSkipped(line string) bool {
+	return line == "" || strings.HasPrefix(line, "#") || line == "---"
+}
+
+// parseArray parses an array (e.g. [a, "b"]), joining the items with commas.
+func parseArray(text string) (string, error) {
+	end := indexOutsideQuotes(text, ']')
+	if rest := strings.TrimSpace(text[end+1:]); rest != "" {
+		return "", fmt.Errorf("unexpected '%v' after %v", rest, text[:end+1])
+	}
+	var items []string
+	for remaining := text[1:end]; strings.TrimSpace(remaining) != ""; {
+		itemEnd := indexOutsideQuotes(remaining, ',')
+		if itemEnd == -1 {
+			itemEnd = len(remaining)
+		}
+		// a trailing comma (allowed in TOML) results in an empty last item, which we skip
+		if rawItem := strings.TrimSpace(remaining[:itemEnd]); rawItem != "" {
+			item, err := parseValue(rawItem)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		if itemEnd == len(remaining) {
+			break
+		}
+		remaining = remaining[itemEnd+1:]
+	}
+	return strings.Join(items, ","), nil
+}
+
+// parseFlatConfig parses a file with top level settings only, e.g. key: value (YAML) or key = value (TOML).
+// Values can be arrays (e.g. [a, b], which can span lines) or YAML block lists (with an item per line, e.g. - a).
+// The items are joined with commas, as they are in JSON files.
+func parseFlatConfig(data []byte, separator string) (map[string]string, error) {
+	settings := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(lines[i])
+		if isSkipped(line) {
+			continue
+		}
+		key, rawValue, found := strings.Cut(line, separator)
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("Line %v: '%v' is not a top level setting", lineNumber, line)
+		}
+		rawValue = withoutComment(strings.TrimSpace(rawValue))
+		var value string
+		var err error
+		switch {
+		case strings.HasPrefix(rawValue, "["):
+			for indexOutsideQuotes(rawValue, ']') == -1 && i+1 < len(lines) {
+				i++
+				rawValue += " " + withoutComment(strings.TrimSpace(lines[i]))
+			}
+			if indexOutsideQuotes(rawValue, ']') == -1 {
+				return nil, fmt.Errorf("Line %v: missing ']' in %v", lineNumber, strings.TrimSpace(rawValue))
+			}
+			value, err = parseArray(rawValue)
+		case rawValue == "":
+			var items []string
+			for i+1 < len(lines) && isListItem(strings.TrimSpace(lines[i+1])) {
+				i++
+				var item string
+				if item, err = parseValue(withoutComment(strings.TrimSpace(strings.TrimSpace(lines[i])[1:]))); err != nil {
+					break
+				}
+				items = append(items, item)
+			}
+			value = strings.Join(items, ",")
+		default:
+			value, err = parseValue(rawValue)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Line %v: %v", lineNumber, err)
+		}
+		settings[normalizedKey(key)] = value
+	}
+	return settings, nil
+}
+
+// parseJSONConfig parses a JSON object with top level settings. Lists (e.g. of source folders) are joined with commas.
+func parseJSONConfig(data []byte) (map[string]string, error) {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	for key, value := range values {
+		switch typedValue := value.(type) {
+		case map[string]interface{}:
+			return nil, fmt.Errorf("Setting '%v' is nested, which is not supported", key)
+		case []interface{}:
+			items := make([]string, len(typedValue))
+			for i, item := range typedValue {
+				items[i] = fmt.Sprint(item)
+			}
+			settings[normalizedKey(key)] = strings.Join(items, ",")
+		case nil:
+			settings[normalizedKey(key)] = ""
+		default:
+			settings[normalizedKey(key)] = fmt.Sprint(typedValue)
+		}
+	}
+	return settings, nil
+}
+
+// parseValue removes the quotes around a value, and a trailing comment.
+func parseValue(rawValue string) (string, error) {
+	if rawValue == "" || (rawValue[0] != '"' && rawValue[0] != '\'') {
+		value, _, _ := strings.Cut(rawValue, " #")
+		return strings.TrimSpace(value), nil
+	}
+	end := strings.IndexByte(rawValue[1:], rawValue[0]) + 1
+	if end == 0 {
+		return "", fmt.Errorf("missing closing quote in %v", rawValue)
+	}
+	if rest := strings.TrimSpace(rawValue[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected '%v' after %v", rest, rawValue[:end+1])
+	}
+	return rawValue[1:end], nil
+}
+
+// withoutComment removes a comment: a # at the start or after a space, which is not within quotes.
+func withoutComment(text string) string {
+	for offset := 0; offset < len(text); {
+		start := indexOutsideQuotes(text[offset:], '#')
+		if start == -1 {
+			break
+		}
+		start += offset
+		if start == 0 || text[start-1] == ' ' || text[start-1] == '\t' {
+			return strings.TrimSpace(text[:start])
+		}
+		offset = start + 1
+	}
+	return text
+}
+
+// quoted returns the value as it should be written in a configuration file: numbers and booleans as is, the rest quoted.
+func quoted(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// Methods
+
+// ReadConfigFile reads the settings in a JSON, YAML or TOML file, depending on the extension.
+// Only top level settings are supported, which is all we need.
+func ReadConfigFile(fileName string) (map[string]string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read config file: %v", err)
+	}
+	var settings map[string]string
+	switch extension := strings.ToLower(filepath.Ext(fileName)); extension {
+	case ".json":
+		settings, err = parseJSONConfig(data)
+	case ".yaml", ".yml":
+		settings, err = parseFlatConfig(data, ":")
+	case ".toml":
+		settings, err = parseFlatConfig(data, "=")
+	default:
+		return nil, fmt.Errorf("Config file '%v' should have extension .json, .yaml, .yml or .toml", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse config file '%v': %v", fileName, err)
+	}
+	return settings, nil
+}
+
+// applyConfig sets the flags that were not specified on the command line from the configuration file and the environment,
+// and returns the effective settings. The port setting is only used if the command line doesn't contain a port.
+func applyConfig(commandLine *flag.FlagSet, configFile string) ([]Setting, error) {
+	onCommandLine := make(map[string]bool)
+	commandLine.Visit(func(commandLineFlag *flag.Flag) {
+		onCommandLine[commandLineFlag.Name] = true
+	})
+	fileSettings := make(map[string]string)
+	if configFile != "" {
+		var err error
+		if fileSettings, err = ReadConfigFile(configFile); err != nil {
+			return nil, err
+		}
+	}
+	known := make(map[string]bool)
+	var settings []Setting
+	for _, entry := range configurable {
+		known[normalizedKey(entry.key)] = true
+		setting := Setting{Key: entry.key, Source: "default"}
+		value, found := fileSettings[normalizedKey(entry.key)]
+		if found {
+			setting.Source = "config file"
+		}
+		if environmentValue, ok := os.LookupEnv(EnvironmentVariable(entry.key)); ok {
+			value, found = environmentValue, true
+			setting.Source = "environment"
+		}
+		switch {
+		case entry.flag == "":
+			if len(commandLine.Args()) > 0 {
+				value = commandLine.Args()[0]
+				setting.Source = "command line"
+			}
+			setting.Value = value
+			settings = append(settings, setting)
+			continue
+		case onCommandLine[entry.flag]:
+			setting.Source = "command line"
+		case found:
+			if err := commandLine.Set(entry.flag, value); err != nil {
+				return nil, fmt.Errorf("Invalid value '%v' for %v (%v): %v", value, entry.key, setting.Source, err)
+			}
+		}
+		setting.Value = commandLine.Lookup(entry.flag).Value.String()
+		settings = append(settings, setting)
+	}
+	for key := range fileSettings {
+		if !known[key] {
+			return nil, fmt.Errorf("Unknown setting '%v' in config file '%v'", key, configFile)
+		}
+	}
+	return settings, nil
+}
+
+// WriteConfig writes the effective settings in TOML format, with their sources as comments. The result can be used as config file.
+func (context *Context) WriteConfig(writer io.Writer) error {
+	for _, setting := range context.Config {
+		if _, err := fmt.Fprintf(writer, "%v = %v # %v\n", setting.Key, quoted(setting.Value), setting.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	fileName := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(fileName, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func TestConfigEnvironmentVariable(t *testing.T) {
+	assert.Equals(t, "SLIM4GO_PORT", EnvironmentVariable("port"), "Single word")
+	assert.Equals(t, "SLIM4GO_INSTRUCTION_TIMEOUT", EnvironmentVariable("instructionTimeout"), "Two words")
+	assert.Equals(t, "SLIM4GO_LOG_LEVEL", EnvironmentVariable("logLevel"), "Log level")
+}
+
+func TestConfigReadConfigFile(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"port": 8485, "instructionTimeout": 7.5, "source": ["fixtures", "library"], "stack_trace": true}`,
+		"config.yaml": "---\n# comment\nport: 8485\ninstructionTimeout: 7.5 # seconds\nsource: 'fixtures,library'\nstack_trace: true\n",
+		"config.toml": "port = 8485\ninstruction-timeout = 7.5\n\nsource = \"fixtures,library\" # folders\nstackTrace = true\n",
+	}
+	for name, content := range files {
+		settings, err := ReadConfigFile(writeConfigFile(t, name, content))
+		assert.Equals(t, nil, err, name+" without error")
+		assert.Equals(t, 4, len(settings), name+" settings")
+		assert.Equals(t, "8485", settings["port"], name+" port")
+		assert.Equals(t, "7.5", settings["instructiontimeout"], name+" instruction timeout")
+		assert.Equals(t, "fixtures,library", settings["source"], name+" source")
+		assert.Equals(t, "true", settings["stacktrace"], name+" stack trace")
+	}
+}
+
+func TestConfigReadConfigFileArrays(t *testing.T) {
+	files := map[string]string{
+		"config.json":       `{"source": ["fixtures", "library"]}`,
+		"config.yaml":       "source: [fixtures, 'library']\n",
+		"block-list.yaml":   "source:\n  - fixtures # local\n  - \"library\"\nport: 8485\n",
+		"config.toml":       "source = [\"fixtures\", \"library\"]\n",
+		"multi-line.toml":   "source = [\n  \"fixtures\", # local\n  \"library\",\n]\nport = 8485\n",
+		"comma-inside.toml": "source = [\"fixtures,library\"]\n",
+	}
+	for name, content := range files {
+		settings, err := ReadConfigFile(writeConfigFile(t, name, content))
+		assert.Equals(t, nil, err, name+" without error")
+		assert.Equals(t, "fixtures,library", settings["source"], name+" source")
+	}
+}
+
+func TestConfigReadConfigFileErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"config.ini", "port=8485", "should have extension .json, .yaml, .yml or .toml"},
+		{"config.json", `{"log": {"level": "debug"}}`, "Setting 'log' is nested, which is not supported"},
+		{"config.json", `{"port": `, "unexpected end of JSON input"},
+		{"config.yaml", "port: 8485\n  - fixtures\n", "Line 2: '- fixtures' is not a top level setting"},
+		{"config.toml", "source = [\"fixtures\",\n  \"library\"\n", "Line 1: missing ']' in [\"fixtures\", \"library\""},
+		{"config.toml", "source = [\"fixtures\"] extra\n", "Line 1: unexpected 'extra' after [\"fixtures\"]"},
+		{"config.toml", "[server]\nport = 8485\n", "Line 1: '[server]' is not a top level setting"},
+		{"config.toml", "log = \"slim.log\n", "Line 1: missing closing quote in \"slim.log"},
+		{"config.toml", "log = \"slim.log\" extra\n", "Line 1: unexpected 'extra' after \"slim.log\""},
+	}
+	for _, testCase := range testCases {
+		_, err := ReadConfigFile(writeConfigFile(t, testCase.name, testCase.content))
+		assert.IsTrue(t, err != nil && strings.HasSuffix(err.Error(), testCase.expected), testCase.name+": "+testCase.expected)
+	}
+	_, err := ReadConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.IsTrue(t, strings.HasPrefix(err.Error(), "Could not read config file: "), "Missing file")
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	configFile := writeConfigFile(t, "slim4go.toml", "port = 8485\ninstructionTimeout = 5\nconnectionTimeout = 20\nlogLevel = \"warn\"\nlog = \"none\"\n")
+	t.Setenv(ConfigFileVariable, configFile)
+	t.Setenv("SLIM4GO_CONNECTION_TIMEOUT", "25")
+	t.Setenv("SLIM4GO_LOG_LEVEL", "error")
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-loglevel", "debug"})
+	assert.Equals(t, 8485, context.Port, "Port from config file")
+	assert.Equals(t, 5*time.Second, context.InstructionTimeout, "Instruction timeout from config file")
+	assert.Equals(t, 25*time.Second, context.ConnectionTimeout, "Connection timeout from environment overrides config file")
+	assert.Equals(t, "debug", context.setting("logLevel"), "Log level from command line overrides environment")
+
+	var output strings.Builder
+	assert.Equals(t, nil, context.WriteConfig(&output), "WriteConfig without error")
+	expected := "port = 8485 # config file\n" +
+		"instructionTimeout = 5 # config file\n" +
+		"connectionTimeout = 25 # environment\n" +
+		"stackTrace = false # default\n" +
+		"parallel = 1 # default\n" +
+		"plugins = \"\" # default\n" +
+		"source = \"\" # default\n" +
+		"record = \"\" # default\n" +
+		"metrics = \"\" # default\n" +
+		"metricsAddress = \"\" # default\n" +
+		"diagnostics = \"\" # default\n" +
+		"namespaces = \"\" # default\n" +
+		"denyNonFixtureMembers = false # default\n" +
+		"dateFormat = \"\" # default\n" +
+		"log = \"none\" # config file\n" +
+		"logFormat = \"text\" # default\n" +
+		"logLevel = \"debug\" # command line\n"
+	assert.Equals(t, expected, output.String(), "Effective configuration")
+
+	printed := writeConfigFile(t, "printed.toml", output.String())
+	settings, err := ReadConfigFile(printed)
+	assert.Equals(t, nil, err, "Printed configuration can be read back")
+	assert.Equals(t, "debug", settings["loglevel"], "Read back log level")
+
+	commandLineContext := New()
+	commandLineContext.ErrorAction = noCallback
+	commandLineContext.Initialize([]string{"slim4go", "8486"})
+	assert.Equals(t, 8486, commandLineContext.Port, "Port from command line overrides config file")
+}
+
+func TestConfigErrors(t *testing.T) {
+	var errors []string
+	collect := func(err error) {
+		errors = append(errors, err.Error())
+	}
+	t.Setenv("SLIM4GO_INSTRUCTION_TIMEOUT", "soon")
+	context := New()
+	context.ErrorAction = collect
+	context.Initialize([]string{"slim4go", "-log", "none", "8485"})
+	assert.Equals(t, 1, len(errors), "One error for an invalid environment variable")
+	assert.Equals(t, "Invalid value 'soon' for instructionTimeout (environment): parse error", errors[0], "Invalid value")
+
+	errors = nil
+	os.Unsetenv("SLIM4GO_INSTRUCTION_TIMEOUT")
+	unknownContext := New()
+	unknownContext.ErrorAction = collect
+	configFile := writeConfigFile(t, "slim4go.yaml", "color: blue\n")
+	unknownContext.Initialize([]string{"slim4go", "-config", configFile, "-log", "none", "8485"})
+	assert.Equals(t, 1, len(errors), "One error for an unknown setting")
+	assert.Equals(t, "Unknown setting 'color' in config file '"+configFile+"'", errors[0], "Unknown setting")
+}
+
+func TestConfigPrintConfig(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "--print-config", "-log", "none"})
+	assert.IsTrue(t, context.PrintConfig, "Print config")
+	assert.Equals(t, "1", context.setting("port"), "No port needed to print the configuration")
+}
@@ -14,7 +14,11 @@ package context
 import (
 	"flag"
 	"fmt"
+	"log/slog"
+	"net"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/essenius/slim4go/internal/slimlog"
@@ -27,20 +31,55 @@ type Context struct {
 	Port               int
 	InstructionTimeout time.Duration
 	ConnectionTimeout  time.Duration
+	// CatalogFormat is set if the catalog needs to be printed (text or json) instead of running the server
+	CatalogFormat string
+	// WikiFolder is set if a FitNesse fixture reference needs to be generated in that page folder instead of running the server
+	WikiFolder string
+	// Plugins contains the fixture plugins (.so files) to load
+	Plugins []string
+	// SourceFolders contains the folders with fixture sources, used to add doc comments to the fixture reference
+	SourceFolders []string
+	// RecordFile is set if the requests and responses of the session need to be recorded in that file
+	RecordFile string
+	// ReplayFile is set if a recorded session needs to be replayed instead of running the server
+	ReplayFile string
+	// Interactive is set if commands need to be read from the console (REPL) instead of running the server
+	Interactive bool
+	// StackTraces is set if exceptions caused by panics need to include a stack trace
+	StackTraces bool
+	// Parallel is the number of instructions on different instances that may be executed in parallel (1 means in order)
+	Parallel int
+	// Logger logs diagnostic messages as specified by the log options
+	Logger *slog.Logger
+	// MetricsFile is set if a summary of the instruction metrics needs to be written to that file (json or csv) at the end of the session
+	MetricsFile string
+	// MetricsAddress is set if the instruction metrics need to be served in Prometheus format at that address (sockets only)
+	MetricsAddress string
+	// DiagnosticsAddress is set if the state of the session needs to be served (with pprof) at that local address
+	DiagnosticsAddress string
+	// Namespaces contains the namespaces that are imported for all pages, so fixture names can leave them out
+	Namespaces []string
+	// DenyNonFixtureMembers is set if members of instances that are not registered fixtures or libraries can't be used
+	DenyNonFixtureMembers bool
+	// DateFormat is the layout (Go reference time) of the dates the standard library returns. Empty means RFC 3339
+	DateFormat string
+	// Config contains the effective configurable settings, and where they came from
+	Config []Setting
+	// PrintConfig is set if the effective configuration needs to be printed instead of running the server
+	PrintConfig bool
 
 	// ErrorAction enables overriding exit in tests
 	ErrorAction func(err error)
 }
 
 func exit(err error) {
-	slimlog.Trace.Println(err.Error())
 	slimlog.Error.Fatalln(err.Error())
 }
 
 var theContext *Context
 
-// Initialize injects the command line arguments. We can't do that in the constructor
-// because we want to replace os.Args by a plain string slice during testing
+// Initialize injects the command line arguments, completed with the configuration file and the environment variables.
+// We can't do that in the constructor because we want to replace os.Args by a plain string slice during testing
 // (os.Args returns somthing different during testing)
 func (context *Context) Initialize(args []string) {
 	// prevent multiple initializations
@@ -50,10 +89,67 @@ func (context *Context) Initialize(args []string) {
 	var commandLine = flag.NewFlagSet("slim", flag.ContinueOnError)
 	var instructionTimeoutPtr = commandLine.Float64("s", 10, "Instruction timeout")
 	var connectionTimeoutPtr = commandLine.Float64("t", 30, "Connection timeout")
+	var catalogFormatPtr = commandLine.String("catalog", "", "Print the fixture catalog (text or json) instead of serving")
+	var wikiFolderPtr = commandLine.String("wiki", "", "Generate the FitNesse fixture reference in this page folder instead of serving")
+	var pluginsPtr = commandLine.String("plugins", "", "Comma separated fixture plugins (.so files) to load")
+	var sourceFoldersPtr = commandLine.String("source", "", "Comma separated fixture source folders to take doc comments from")
+	var recordFilePtr = commandLine.String("record", "", "Record the requests and responses of the session in this file")
+	var replayFilePtr = commandLine.String("replay", "", "Replay the session recorded in this file and report differences instead of serving")
+	var interactivePtr = commandLine.Bool("repl", false, "Read commands from the console to try out fixtures instead of serving")
+	var stackTracesPtr = commandLine.Bool("stacktrace", false, "Include stack traces in exceptions caused by panics")
+	var parallelPtr = commandLine.Int("parallel", 1, "Number of instructions on different instances that may be executed in parallel")
+	var logFilePtr = commandLine.String("log", "none", "Log file name, or stdout, stderr or none")
+	var logFormatPtr = commandLine.String("logformat", "text", "Log format (text or json)")
+	var logLevelPtr = commandLine.String("loglevel", "info", "Minimum log level (debug, info, warn or error)")
+	var metricsFilePtr = commandLine.String("metrics", "", "Write a summary of the instruction metrics to this file (json or csv) at the end of the session")
+	var metricsAddressPtr = commandLine.String("metricsaddress", "", "Serve the instruction metrics in Prometheus format at this address, e.g. :9090 (sockets only)")
+	var diagnosticsAddressPtr = commandLine.String("diagnostics", "", "Serve pprof and the state of the session at this loopback address, e.g. localhost:6060")
+	var namespacesPtr = commandLine.String("namespaces", "", "Comma separated namespaces to import for all pages")
+	var denyNonFixturesPtr = commandLine.Bool("denynonfixtures", false, "Deny using members of instances that are not registered fixtures or libraries")
+	var dateFormatPtr = commandLine.String("dateformat", "", "Layout of the dates the standard library returns, e.g. 2006-01-02 (default RFC 3339)")
+	var configFilePtr = commandLine.String("config", os.Getenv(ConfigFileVariable), "Configuration file (json, yaml or toml)")
+	var printConfigPtr = commandLine.Bool("print-config", false, "Print the effective configuration instead of serving")
 	// we handle errors after initializing the logger
 	err1 := commandLine.Parse(args[1:])
+	var err4 error
+	if err1 == nil {
+		context.Config, err4 = applyConfig(commandLine, *configFilePtr)
+	}
+	context.PrintConfig = *printConfigPtr
+	context.CatalogFormat = *catalogFormatPtr
+	context.WikiFolder = *wikiFolderPtr
+	context.RecordFile = *recordFilePtr
+	context.ReplayFile = *replayFilePtr
+	context.Interactive = *interactivePtr
+	context.StackTraces = *stackTracesPtr
+	context.Parallel = *parallelPtr
+	context.MetricsFile = *metricsFilePtr
+	context.MetricsAddress = *metricsAddressPtr
+	context.DiagnosticsAddress = *diagnosticsAddressPtr
+	context.DenyNonFixtureMembers = *denyNonFixturesPtr
+	context.DateFormat = *dateFormatPtr
+	if *pluginsPtr != "" {
+		context.Plugins = strings.Split(*pluginsPtr, ",")
+	}
+	if *sourceFoldersPtr != "" {
+		context.SourceFolders = strings.Split(*sourceFoldersPtr, ",")
+	}
+	if *namespacesPtr != "" {
+		context.Namespaces = strings.Split(*namespacesPtr, ",")
+	}
+	portArgs := commandLine.Args()
+	if len(portArgs) == 0 && context.setting("port") != "" {
+		portArgs = []string{context.setting("port")}
+	}
 	var err2 error
-	context.Port, err2 = parsePort(commandLine.Args())
+	context.Port, err2 = parsePort(portArgs)
+	// The catalog, the fixture reference, replays, the REPL and printing the configuration don't need a connection,
+	// so the port is optional then
+	if (context.CatalogFormat != "" || context.WikiFolder != "" || context.ReplayFile != "" || context.Interactive || context.PrintConfig) &&
+		len(portArgs) == 0 {
+		err2 = nil
+	}
+	context.setSetting("port", strconv.Itoa(context.Port))
 	slimlog.Initialize(context.Port == 1)
 	if err1 != nil {
 		context.ErrorAction(err1)
@@ -61,17 +157,80 @@ func (context *Context) Initialize(args []string) {
 	if err2 != nil {
 		context.ErrorAction(err2)
 	}
+	if err3 := context.initializeLogger(*logFilePtr, *logFormatPtr, *logLevelPtr); err3 != nil {
+		context.ErrorAction(err3)
+	}
+	if err4 != nil {
+		context.ErrorAction(err4)
+	}
+	if context.MetricsAddress != "" && context.Port == 1 {
+		context.ErrorAction(fmt.Errorf("The metrics address can only be used with sockets"))
+	}
+	if context.DiagnosticsAddress != "" {
+		if err5 := checkLoopback(context.DiagnosticsAddress); err5 != nil {
+			context.ErrorAction(err5)
+		}
+	}
 	context.InstructionTimeout = time.Duration(*instructionTimeoutPtr * float64(time.Second))
 	context.ConnectionTimeout = time.Duration(*connectionTimeoutPtr * float64(time.Second))
 }
 
-// New creates a new Context
+// New creates a new Context. Until it is initialized, nothing is logged.
 func New() *Context {
 	context := new(Context)
 	context.ErrorAction = exit
+	context.Logger = slimlog.Discard()
 	return context
 }
 
+// initializeLogger creates the logger. With pipes, standard output carries the protocol, so we can't log there.
+func (context *Context) initializeLogger(destination string, format string, levelName string) error {
+	level, err := slimlog.ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	if context.Port == 1 && strings.EqualFold(destination, "stdout") {
+		return fmt.Errorf("Cannot log to stdout when using pipes")
+	}
+	logger, err := slimlog.New(slimlog.Options{Destination: destination, Format: format, Level: level})
+	if err != nil {
+		return err
+	}
+	context.Logger = logger
+	return nil
+}
+
+// setting returns the value of a configurable setting.
+func (context *Context) setting(key string) string {
+	for _, setting := range context.Config {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+	return ""
+}
+
+// setSetting updates the value of a configurable setting (keeping its source).
+func (context *Context) setSetting(key string, value string) {
+	for i := range context.Config {
+		if context.Config[i].Key == key {
+			context.Config[i].Value = value
+		}
+	}
+}
+
+// checkLoopback makes sure the diagnostics address is on the loopback interface, as the diagnostics expose the session.
+func checkLoopback(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("Invalid diagnostics address '%v': %v", address, err)
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("Diagnostics address '%v' is not on the loopback interface. Use e.g. localhost:6060", address)
+}
+
 func parsePort(args []string) (int, error) {
 	// default the port to 1, as then a fatal error comes through no matter if pipes or sockets are used
 	port := 1
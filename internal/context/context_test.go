@@ -12,8 +12,11 @@
 package context
 
 import (
+	gocontext "context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,6 +67,29 @@ func TestContextNew(t *testing.T) {
 
 }
 
+func TestContextCatalog(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-catalog", "json"})
+	assert.Equals(t, "json", context.CatalogFormat, "Catalog format")
+	assert.Equals(t, 1, context.Port, "No port needed for the catalog")
+}
+
+func TestContextWiki(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-wiki", "FitNesseRoot/MySuite", "-source", "fixtures,more"})
+	assert.Equals(t, "FitNesseRoot/MySuite", context.WikiFolder, "Wiki folder")
+	assert.Equals(t, "fixtures|more", strings.Join(context.SourceFolders, "|"), "Source folders")
+	assert.Equals(t, 1, context.Port, "No port needed for the fixture reference")
+}
+
 func TestContextParsePort(t *testing.T) {
 	args := []string{}
 	port1, err1 := parsePort(args)
@@ -93,3 +119,170 @@ func TestContextInject(t *testing.T) {
 	assert.Equals(t, time.Duration(0), context.InstructionTimeout, "Timeout not initialized yet")
 	assert.Equals(t, time.Duration(0), context.ConnectionTimeout, "Timeout not initialized yet")
 }
+
+func TestContextRecordReplay(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	recordContext := New()
+	recordContext.ErrorAction = noCallback
+	recordContext.Initialize([]string{"slim4go", "-record", "session.jsonl", "8485"})
+	assert.Equals(t, "session.jsonl", recordContext.RecordFile, "Record file")
+	assert.Equals(t, 8485, recordContext.Port, "Port needed for recording")
+	replayContext := New()
+	replayContext.ErrorAction = noCallback
+	replayContext.Initialize([]string{"slim4go", "-replay", "session.jsonl"})
+	assert.Equals(t, "session.jsonl", replayContext.ReplayFile, "Replay file")
+	assert.Equals(t, 1, replayContext.Port, "No port needed for a replay")
+}
+
+func TestContextRepl(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-repl"})
+	assert.IsTrue(t, context.Interactive, "Interactive")
+	assert.Equals(t, 1, context.Port, "No port needed for the REPL")
+}
+
+func TestContextStackTraces(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-stacktrace", "8485"})
+	assert.IsTrue(t, context.StackTraces, "Stack traces")
+	defaultContext := New()
+	defaultContext.ErrorAction = noCallback
+	defaultContext.Initialize([]string{"slim4go", "8485"})
+	assert.IsTrue(t, !defaultContext.StackTraces, "No stack traces by default")
+}
+
+func TestContextPlugins(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-plugins", "team1.so,team2.so", "8485"})
+	assert.Equals(t, "team1.so|team2.so", strings.Join(context.Plugins, "|"), "Plugins")
+	defaultContext := New()
+	defaultContext.ErrorAction = noCallback
+	defaultContext.Initialize([]string{"slim4go", "8485"})
+	assert.Equals(t, 0, len(defaultContext.Plugins), "No plugins by default")
+}
+
+func TestContextFixtureOptions(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	configFile := writeConfigFile(t, "slim4go.yaml", "namespaces:\n  - fixtures\n  - helpers\ndenyNonFixtureMembers: true\ndateFormat: '2006-01-02'\n")
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-config", configFile, "-log", "none", "8485"})
+	assert.Equals(t, "fixtures|helpers", strings.Join(context.Namespaces, "|"), "Namespaces from config file")
+	assert.IsTrue(t, context.DenyNonFixtureMembers, "Deny non-fixture members from config file")
+	assert.Equals(t, "2006-01-02", context.DateFormat, "Date format from config file")
+	defaultContext := New()
+	defaultContext.ErrorAction = noCallback
+	defaultContext.Initialize([]string{"slim4go", "8485"})
+	assert.Equals(t, 0, len(defaultContext.Namespaces), "No namespaces by default")
+	assert.IsTrue(t, !defaultContext.DenyNonFixtureMembers, "Non-fixture members allowed by default")
+	assert.Equals(t, "", defaultContext.DateFormat, "No date format by default")
+}
+
+func TestContextParallel(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-parallel", "4", "8485"})
+	assert.Equals(t, 4, context.Parallel, "Parallel workers")
+	defaultContext := New()
+	defaultContext.ErrorAction = noCallback
+	defaultContext.Initialize([]string{"slim4go", "8485"})
+	assert.Equals(t, 1, defaultContext.Parallel, "In order by default")
+}
+
+func TestContextLogging(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	assert.IsTrue(t, !New().Logger.Enabled(gocontext.Background(), slog.LevelError), "Nothing is logged before initialization")
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-log", "none", "-loglevel", "debug", "8485"})
+	assert.IsTrue(t, !context.Logger.Enabled(gocontext.Background(), slog.LevelError), "Nothing is logged with destination none")
+	debugContext := New()
+	debugContext.ErrorAction = noCallback
+	debugContext.Initialize([]string{"slim4go", "-log", "stderr", "-loglevel", "debug", "-logformat", "json", "8485"})
+	assert.IsTrue(t, debugContext.Logger.Enabled(gocontext.Background(), slog.LevelDebug), "Debug messages are logged")
+
+	var errors []string
+	collect := func(err error) {
+		errors = append(errors, err.Error())
+	}
+	for _, args := range [][]string{
+		{"slim4go", "-loglevel", "verbose", "8485"},
+		{"slim4go", "-logformat", "xml", "8485"},
+		{"slim4go", "-log", "stdout", "1"},
+	} {
+		errorContext := New()
+		errorContext.ErrorAction = collect
+		errorContext.Initialize(args)
+	}
+	assert.Equals(t, "Unknown log level 'verbose'. Expected debug, info, warn or error", errors[0], "Wrong level")
+	assert.Equals(t, "Unknown log format 'xml'. Expected text or json", errors[1], "Wrong format")
+	assert.Equals(t, "Cannot log to stdout when using pipes", errors[2], "Stdout with pipes")
+}
+
+func TestContextMetrics(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	context := New()
+	context.ErrorAction = noCallback
+	context.Initialize([]string{"slim4go", "-log", "none", "-metrics", "metrics.csv", "-metricsaddress", ":9090", "8485"})
+	assert.Equals(t, "metrics.csv", context.MetricsFile, "Metrics file")
+	assert.Equals(t, ":9090", context.MetricsAddress, "Metrics address")
+
+	callbackCount := 0
+	pipeContext := New()
+	pipeContext.ErrorAction = func(err error) {
+		assert.Equals(t, "The metrics address can only be used with sockets", err.Error(), "Metrics address with pipes")
+		callbackCount++
+	}
+	pipeContext.Initialize([]string{"slim4go", "-log", "none", "-metricsaddress", ":9090", "1"})
+	assert.Equals(t, 1, callbackCount, "Callback called once")
+}
+
+func TestContextDiagnostics(t *testing.T) {
+	noCallback := func(err error) {
+		t.Fatalf("Unexpected callback with error '%v'", err.Error())
+	}
+	for _, address := range []string{"localhost:6060", "127.0.0.1:6060", "[::1]:6060"} {
+		context := New()
+		context.ErrorAction = noCallback
+		context.Initialize([]string{"slim4go", "-log", "none", "-diagnostics", address, "8485"})
+		assert.Equals(t, address, context.DiagnosticsAddress, "Diagnostics address "+address)
+	}
+
+	var errors []string
+	collect := func(err error) {
+		errors = append(errors, err.Error())
+	}
+	for _, address := range []string{":6060", "0.0.0.0:6060", "example.com:6060", "localhost"} {
+		errorContext := New()
+		errorContext.ErrorAction = collect
+		errorContext.Initialize([]string{"slim4go", "-log", "none", "-diagnostics", address, "8485"})
+	}
+	assert.Equals(t, 4, len(errors), "Four errors")
+	assert.Equals(t, "Diagnostics address ':6060' is not on the loopback interface. Use e.g. localhost:6060", errors[0], "All interfaces")
+	assert.Equals(t, "Diagnostics address '0.0.0.0:6060' is not on the loopback interface. Use e.g. localhost:6060", errors[1], "Unspecified IP")
+	assert.Equals(t, "Diagnostics address 'example.com:6060' is not on the loopback interface. Use e.g. localhost:6060", errors[2], "Other host")
+	assert.Equals(t, "Invalid diagnostics address 'localhost': address localhost: missing port in address", errors[3], "No port")
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/catalog"
+)
+
+// Helpers
+
+func splitFixtureName(name string) (string, string) {
+	if lastDot := strings.LastIndex(name, "."); lastDot >= 0 {
+		return name[:lastDot], name[lastDot+1:]
+	}
+	return "", name
+}
+
+// Registry methods
+
+// Catalog returns a description of all registered fixtures (sorted by name) and libraries (in order of precedence).
+func (registry *Registry) Catalog() *catalog.Catalog {
+	result := &catalog.Catalog{Namespaces: append([]string{}, registry.namespace...), Fixtures: []*catalog.FixtureInfo{}, Libraries: []*catalog.TypeInfo{}}
+	for fullName, constructor := range registry.constructor {
+		name := registry.shortName[fullName]
+		namespace, shortName := splitFixtureName(name)
+		packagePath, _ := splitFixtureName(fullName)
+		constructorType := reflect.TypeOf(constructor)
+		result.Fixtures = append(result.Fixtures, &catalog.FixtureInfo{
+			Name:        name,
+			Namespace:   namespace,
+			ShortName:   shortName,
+			Package:     packagePath,
+			Aliases:     registry.aliasesOf(fullName),
+			Constructor: catalog.SignatureOf("", constructorType, false),
+			TypeInfo:    catalog.Describe(constructorType.Out(0)),
+		})
+	}
+	sort.Slice(result.Fixtures, func(i, j int) bool {
+		if result.Fixtures[i].Name == result.Fixtures[j].Name {
+			return result.Fixtures[i].Package < result.Fixtures[j].Package
+		}
+		return result.Fixtures[i].Name < result.Fixtures[j].Name
+	})
+	for _, library := range registry.Libraries() {
+		result.Libraries = append(result.Libraries, catalog.Describe(reflect.TypeOf(library)))
+	}
+	return result
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+type Invoice struct {
+	Customer string
+	Lines    []string
+	total    float64
+}
+
+func NewInvoice(customer string, lines ...string) *Invoice {
+	return &Invoice{Customer: customer, Lines: lines}
+}
+
+func (invoice *Invoice) AddLine(line string, amount float64) {
+	invoice.Lines = append(invoice.Lines, line)
+	invoice.total += amount
+}
+
+func (invoice *Invoice) Total() float64 {
+	return invoice.total
+}
+
+func (invoice *Invoice) Split(parts int) (float64, error) {
+	return invoice.total / float64(parts), nil
+}
+
+func TestCatalogRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(NewInvoice)
+	registry.AddFixture(NewOrder)
+	registry.AddNamespace("fixture")
+	registry.AddLibrary(&Library{"library"})
+	catalog := registry.Catalog()
+	assert.Equals(t, 1, len(catalog.Namespaces), "Namespaces")
+	assert.Equals(t, 2, len(catalog.Fixtures), "Fixtures")
+	invoice := catalog.Fixtures[0]
+	assert.Equals(t, "fixture.Invoice", invoice.Name, "Fixtures sorted by name")
+	assert.Equals(t, "fixture", invoice.Namespace, "Namespace")
+	assert.Equals(t, "Invoice", invoice.ShortName, "Short name")
+	assert.Equals(t, "(string, ...string) *fixture.Invoice", invoice.Constructor.String(), "Variadic constructor")
+	assert.IsTrue(t, invoice.Constructor.Variadic, "Constructor is variadic")
+	assert.Equals(t, 1, len(catalog.Libraries), "Libraries")
+	assert.Equals(t, "Name() string", catalog.Libraries[0].Methods[0].String(), "Library method")
+
+	var buffer bytes.Buffer
+	assert.Equals(t, nil, catalog.Write(&buffer, "text"), "Write text")
+	assert.Equals(t, "Fixtures:\n"+
+		"  fixture.Invoice(string, ...string)\n"+
+		"    AddLine(string, float64)\n"+
+		"    Split(int) (float64, error)\n"+
+		"    Total() float64\n"+
+		"    Customer string (field)\n"+
+		"    Lines []string (field)\n"+
+		"  fixture.Order()\n"+
+		"Libraries:\n"+
+		"  *fixture.Library\n"+
+		"    Name() string\n", buffer.String(), "Text format")
+
+	buffer.Reset()
+	assert.Equals(t, nil, catalog.Write(&buffer, "JSON"), "Write JSON")
+	var decoded map[string]interface{}
+	assert.Equals(t, nil, json.Unmarshal(buffer.Bytes(), &decoded), "JSON can be decoded")
+	fixtures := decoded["fixtures"].([]interface{})
+	assert.Equals(t, "Invoice", fixtures[0].(map[string]interface{})["shortName"], "JSON contains short name")
+	assert.Equals(t, 3, len(fixtures[0].(map[string]interface{})["methods"].([]interface{})), "JSON contains flattened type info")
+
+	assert.Equals(t, "Unknown catalog format 'xml'. Expected text or json", catalog.Write(&buffer, "xml").Error(), "Unknown format")
+}
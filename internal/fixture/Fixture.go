@@ -16,7 +16,7 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/slimfixture"
 )
 
 // Definitions and constructors for Fixture
@@ -30,15 +30,26 @@ type anyMap map[string]interface{}
 
 // Registry defines the fixture registry.
 type Registry struct {
-	constructor anyMap
-	namespace   []string
+	constructor     anyMap
+	shortName       map[string]string
+	alias           map[string]string
+	namespace       []string
+	library         []interface{}
+	policy          map[string]*policy
+	denyNonFixtures bool
+	stubs           map[string]slimfixture.Stubs
 }
 
 // NewRegistry creates a new fixture registry.
 func NewRegistry() *Registry {
 	registry := new(Registry)
 	registry.constructor = make(anyMap)
+	registry.shortName = make(map[string]string)
+	registry.alias = make(map[string]string)
 	registry.namespace = []string{}
+	registry.library = []interface{}{}
+	registry.policy = make(map[string]*policy)
+	registry.stubs = make(map[string]slimfixture.Stubs)
 	return registry
 }
 
@@ -54,22 +65,22 @@ func typeWithoutPointer(name string) string {
 }
 
 // This assumes there is only one return parameter, which makes sense for constructors
-func fixtureNameFromConstructor(constructor interface{}) string {
+func fixtureTypeFromConstructor(constructor interface{}) reflect.Type {
 	constructorType := reflect.TypeOf(constructor)
-	if constructorType.Kind() != reflect.Func {
-		return ""
+	if constructorType == nil || constructorType.Kind() != reflect.Func || constructorType.NumOut() == 0 {
+		return nil
 	}
-	firstOutFieldType := constructorType.Out(0).String()
-	return typeWithoutPointer(firstOutFieldType)
+	return constructorType.Out(0)
 }
 
 // Registry methods
 
 // AddFixture registers a fixture definition via its constructor function.
 func (registry *Registry) AddFixture(fixtureConstructor interface{}) error {
-	fixtureName := fixtureNameFromConstructor(fixtureConstructor)
-	if fixtureName != "" {
-		registry.constructor[fixtureName] = fixtureConstructor
+	if fixtureType := fixtureTypeFromConstructor(fixtureConstructor); fixtureType != nil {
+		fullName := fullTypeName(fixtureType)
+		registry.constructor[fullName] = fixtureConstructor
+		registry.shortName[fullName] = shortTypeName(fixtureType)
 		return nil
 	}
 	return fmt.Errorf("Could not add fixture '%v'", fixtureConstructor)
@@ -81,14 +92,23 @@ func (registry *Registry) AddFixturesFrom(fixtureFactory interface{}) error {
 	for i := 0; i < factoryType.NumMethod(); i++ {
 		method := factoryType.Method(i)
 		if strings.HasPrefix(method.Name, "New") {
-			fixtureName := method.Name[3:]
-			slimlog.Trace.Printf("Found %v", fixtureName)
 			registry.AddFixture(reflect.ValueOf(fixtureFactory).Method(i).Interface())
 		}
 	}
 	return nil
 }
 
+// AddLibrary registers an instantiated object as library, without the need for a library table.
+func (registry *Registry) AddLibrary(library interface{}) error {
+	if library == nil || reflect.TypeOf(library).NumMethod() == 0 {
+		return fmt.Errorf("Could not add library '%v': it has no methods", library)
+	}
+	registry.library = append(registry.library, library)
+	libraryType := reflect.TypeOf(library)
+	registry.shortName[fullTypeName(libraryType)] = shortTypeName(libraryType)
+	return nil
+}
+
 // AddNamespace adds a namespace to the registry (fixture prefix to take into account with searching).
 func (registry *Registry) AddNamespace(newNamespace string) {
 	for _, value := range registry.namespace {
@@ -99,20 +119,27 @@ func (registry *Registry) AddNamespace(newNamespace string) {
 	registry.namespace = append(registry.namespace, newNamespace)
 }
 
-// FixtureNamed returns the fixture with the specified name.
-func (registry *Registry) FixtureNamed(fixtureName string) interface{} {
-	prefixes := []string{""}
+// FixtureNamed returns the constructor of the fixture with the specified name, taking the namespaces into account.
+// It returns a NotFoundError if there is no such fixture, and an AmbiguousFixtureError if there is more than one.
+func (registry *Registry) FixtureNamed(fixtureName string) (interface{}, error) {
+	names := []string{fixtureName}
 	for _, namespace := range registry.namespace {
-		prefixes = append(prefixes, namespace+".")
+		names = append(names, namespace+"."+fixtureName)
 	}
-	for _, prefix := range prefixes {
-		nameWithNamespace := prefix + fixtureName
-		fixture, ok := registry.constructor[nameWithNamespace]
-		if ok {
-			return fixture
-		}
+	fullName, err := registry.resolve(fixtureName, names, registry.fixtureNames())
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return registry.constructor[fullName], nil
+}
+
+// Libraries returns the registered libraries, most recently registered first.
+func (registry *Registry) Libraries() []interface{} {
+	result := make([]interface{}, 0, len(registry.library))
+	for i := len(registry.library) - 1; i >= 0; i-- {
+		result = append(result, registry.library[i])
+	}
+	return result
 }
 
 // Length returns the number of items in the fixture registry.
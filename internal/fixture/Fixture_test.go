@@ -40,6 +40,14 @@ type Messenger struct {
 type Order struct {
 }
 
+type Library struct {
+	name string
+}
+
+func (library *Library) Name() string {
+	return library.name
+}
+
 func NewOrder() *Order {
 	return new(Order)
 }
@@ -57,12 +65,14 @@ func TestFixtureNamespace(t *testing.T) {
 	assert.Equals(t, "test2", registry.namespace[1], "Second namespace OK")
 	registry.AddNamespace("fixture")
 	registry.AddFixture(NewOrder)
-	order1 := registry.FixtureNamed("Order")
-	assert.IsTrue(t, order1 != nil, "Order found without namespace spec")
-	order2 := registry.FixtureNamed("fixture.Order")
-	assert.IsTrue(t, order2 != nil, "Order found with namespace spec")
+	order1, err1 := registry.FixtureNamed("Order")
+	assert.IsTrue(t, order1 != nil && err1 == nil, "Order found without namespace spec")
+	order2, err2 := registry.FixtureNamed("fixture.Order")
+	assert.IsTrue(t, order2 != nil && err2 == nil, "Order found with namespace spec")
 	assert.Equals(t, reflect.TypeOf(order1).String(), reflect.TypeOf(order2).String(), "Order constructor signatures are equal")
-	assert.Equals(t, nil, registry.FixtureNamed("bogus"), "Unknown fixture name returns nil")
+	bogus, err := registry.FixtureNamed("bogus")
+	assert.Equals(t, nil, bogus, "Unknown fixture name returns nil")
+	assert.Equals(t, "bogus: fixture not found", err.Error(), "Unknown fixture name returns error")
 }
 
 func TestFixtureRegisterFixtures(t *testing.T) {
@@ -72,9 +82,9 @@ func TestFixtureRegisterFixtures(t *testing.T) {
 	assert.Equals(t, 1, len(registry.constructor), "one fixture added")
 	assert.Equals(t, nil, registry.AddFixturesFrom(NewFixtureFactory()), "AddFixturesFromFactory succeeded")
 	assert.Equals(t, 2, len(registry.constructor), "two more fixtures added, but one already existed")
-	order := registry.FixtureNamed("fixture.Order")
+	order, _ := registry.FixtureNamed("fixture.Order")
 	assert.IsTrue(t, order != nil, "Order constructor exists")
-	messenger := registry.FixtureNamed("fixture.Messenger")
+	messenger, _ := registry.FixtureNamed("fixture.Messenger")
 	assert.IsTrue(t, messenger != nil, "Messenger constructor exists")
 	messengerValue := reflect.ValueOf(messenger)
 	assert.Equals(t, "func", messengerValue.Kind().String(), "Messenger constructor is a func")
@@ -83,6 +93,20 @@ func TestFixtureRegisterFixtures(t *testing.T) {
 	assert.Equals(t, "Could not add fixture '1'", registry.AddFixture(1).Error(), "Add invalid fixture")
 }
 
+func TestFixtureLibraries(t *testing.T) {
+	registry := NewRegistry()
+	assert.Equals(t, 0, len(registry.Libraries()), "No libraries at start")
+	order := NewOrder()
+	assert.Equals(t, "Could not add library '<nil>': it has no methods", registry.AddLibrary(nil).Error(), "Add nil library fails")
+	assert.Equals(t, "Could not add library '&{}': it has no methods", registry.AddLibrary(order).Error(), "Add library without methods fails")
+	assert.Equals(t, nil, registry.AddLibrary(&Library{"first"}), "Add first library succeeds")
+	assert.Equals(t, nil, registry.AddLibrary(&Library{"second"}), "Add second library succeeds")
+	libraries := registry.Libraries()
+	assert.Equals(t, 2, len(libraries), "Two libraries")
+	assert.Equals(t, "second", libraries[0].(*Library).Name(), "Most recently added library comes first")
+	assert.Equals(t, "first", libraries[1].(*Library).Name(), "First added library comes last")
+}
+
 func TestFixtureTypeWithoutPointer(t *testing.T) {
 	assert.Equals(t, "test1.test2", typeWithoutPointer("*test1.test2"), "with pointer")
 	assert.Equals(t, "test1.test2", typeWithoutPointer("test1.test2"), "without pointer")
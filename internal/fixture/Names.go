@@ -0,0 +1,252 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/apperrors"
+)
+
+// Fixtures are registered by their full name: the package path and the type name, e.g.
+// github.com/essenius/slim4go/examples/demofixtures.Temperature. So fixtures of packages with the same name don't collide.
+// A name refers to a fixture if it is the full name, the full name without the start of the package path
+// (e.g. demofixtures.Temperature, which is also what reflection calls the type), or an alias of it.
+// Imports work the same way, so both import github.com/acme/fixtures and import fixtures work.
+// If a name refers to fixtures of more than one package, looking it up results in an AmbiguousFixtureError.
+// If a name doesn't refer to any fixture exactly, the lookup is graceful: case, spaces and underscores don't matter,
+// slashes and dots are both namespace separators, and a Fixture suffix is optional. So temperature converter refers to
+// TemperatureConverter, and Fibonacci to FibonacciFixture. Exact matches take precedence, so that Order and OrderFixture
+// can both be used.
+
+// Helpers
+
+// maxClosestMatches is the maximum number of fixtures that ClosestFixtures returns.
+const maxClosestMatches = 3
+
+// distance returns the Levenshtein distance between two strings: the number of runes to insert, delete or replace.
+func distance(source string, target string) int {
+	sourceRunes := []rune(source)
+	targetRunes := []rune(target)
+	previous := make([]int, len(targetRunes)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(sourceRunes); i++ {
+		current := make([]int, len(targetRunes)+1)
+		current[0] = i
+		for j := 1; j <= len(targetRunes); j++ {
+			cost := 1
+			if sourceRunes[i-1] == targetRunes[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(targetRunes)]
+}
+
+// fullTypeName returns the package path and the name of a type, without pointer.
+func fullTypeName(aType reflect.Type) string {
+	if aType.Kind() == reflect.Ptr {
+		aType = aType.Elem()
+	}
+	if aType.PkgPath() == "" || aType.Name() == "" {
+		return aType.String()
+	}
+	return aType.PkgPath() + "." + aType.Name()
+}
+
+// matching returns the full names (out of fullNames) that any of the names refers to according to the match function.
+func matching(names []string, fullNames []string, refersTo func(name string, fullName string) bool) []string {
+	result := []string{}
+	for _, fullName := range fullNames {
+		for _, name := range names {
+			if refersTo(name, fullName) {
+				result = append(result, fullName)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// maxDistance returns the largest distance for which a name is still considered similar to a fixture name.
+func maxDistance(fixtureName string) int {
+	if len(fixtureName) < 6 {
+		return 2
+	}
+	return len(fixtureName) / 3
+}
+
+// normalized returns the graceful form of a name: lower case, without spaces, underscores and Fixture suffix,
+// and with dots as namespace separators.
+func normalized(name string) string {
+	name = strings.ToLower(strings.NewReplacer(" ", "", "_", "", "/", ".").Replace(name))
+	typeName := typePart(name)
+	if strings.HasSuffix(typeName, "fixture") && len(typeName) > len("fixture") {
+		name = strings.TrimSuffix(name, "fixture")
+	}
+	return name
+}
+
+// shortTypeName returns the package name and the name of a type, without pointer.
+func shortTypeName(aType reflect.Type) string {
+	return typeWithoutPointer(aType.String())
+}
+
+// typePart returns the part of a (normalized) name after the last namespace separator.
+func typePart(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// Registry methods
+
+// AddAlias registers an alternative name for a fixture, e.g. to distinguish fixtures with the same short name.
+func (registry *Registry) AddAlias(alias string, fixtureName string) error {
+	fullName, err := registry.resolve(fixtureName, []string{fixtureName}, registry.fixtureNames())
+	if err != nil {
+		return fmt.Errorf("Could not add alias '%v': %v", alias, err)
+	}
+	if existing, ok := registry.alias[alias]; ok && existing != fullName {
+		return fmt.Errorf("Could not add alias '%v': it is already used for '%v'", alias, existing)
+	}
+	registry.alias[alias] = fullName
+	return nil
+}
+
+// aliasesOf returns the aliases of a fixture, sorted.
+func (registry *Registry) aliasesOf(fullName string) []string {
+	aliases := []string{}
+	for alias, aliasFullName := range registry.alias {
+		if aliasFullName == fullName {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// ClosestFixtures returns the names of the fixtures that are most similar to a name that doesn't refer to a fixture,
+// most similar first. The similarity is based on the type names, so it also finds fixtures in other packages.
+func (registry *Registry) ClosestFixtures(fixtureName string) []string {
+	wanted := typePart(normalized(fixtureName))
+	distances := make(map[string]int)
+	closest := []string{}
+	for fullName := range registry.constructor {
+		candidate := typePart(normalized(registry.shortName[fullName]))
+		candidateDistance := distance(wanted, candidate)
+		isPart := len(wanted) > 2 && strings.Contains(candidate, wanted)
+		if candidateDistance > maxDistance(candidate) && !isPart {
+			continue
+		}
+		name := registry.displayName(fullName)
+		distances[name] = candidateDistance
+		closest = append(closest, name)
+	}
+	sort.Slice(closest, func(i, j int) bool {
+		if distances[closest[i]] == distances[closest[j]] {
+			return closest[i] < closest[j]
+		}
+		return distances[closest[i]] < distances[closest[j]]
+	})
+	if len(closest) > maxClosestMatches {
+		closest = closest[:maxClosestMatches]
+	}
+	return closest
+}
+
+// displayName returns the short name of a fixture, or the full name if other fixtures have the same short name.
+func (registry *Registry) displayName(fullName string) string {
+	shortName := registry.shortName[fullName]
+	for otherFullName := range registry.constructor {
+		if otherFullName != fullName && registry.shortName[otherFullName] == shortName {
+			return fullName
+		}
+	}
+	return shortName
+}
+
+// fixtureNames returns the full names of the registered fixtures.
+func (registry *Registry) fixtureNames() []string {
+	fullNames := []string{}
+	for fullName := range registry.constructor {
+		fullNames = append(fullNames, fullName)
+	}
+	return fullNames
+}
+
+// refersGracefullyTo returns whether the normalized form of a name refers to the registered type with the full name.
+// Only names with a namespace can match the end of the full name, so names without one still need an import.
+func (registry *Registry) refersGracefullyTo(name string, fullName string) bool {
+	normalizedName := normalized(name)
+	normalizedFullName := normalized(fullName)
+	if normalizedName == normalizedFullName || normalizedName == normalized(registry.shortName[fullName]) {
+		return true
+	}
+	if strings.Contains(normalizedName, ".") && strings.HasSuffix(normalizedFullName, "."+normalizedName) {
+		return true
+	}
+	for alias, aliasFullName := range registry.alias {
+		if aliasFullName == fullName && normalized(alias) == normalizedName {
+			return true
+		}
+	}
+	return false
+}
+
+// refersTo returns whether a name refers to the registered type with the full name.
+func (registry *Registry) refersTo(name string, fullName string) bool {
+	return name == fullName || name == registry.shortName[fullName] || strings.HasSuffix(fullName, "/"+name) ||
+		registry.alias[name] == fullName
+}
+
+// registeredName returns the full name of the registered fixture or library type that a name refers to.
+func (registry *Registry) registeredName(fixtureName string) (string, error) {
+	typeNames := []string{}
+	for fullName := range registry.shortName {
+		typeNames = append(typeNames, fullName)
+	}
+	fullName, err := registry.resolve(fixtureName, []string{fixtureName}, typeNames)
+	if _, ok := err.(*apperrors.NotFoundError); ok {
+		return "", fmt.Errorf("fixture '%v' is not registered", fixtureName)
+	}
+	return fullName, err
+}
+
+// resolve returns the one full name (out of fullNames) that any of the names (variants of fixtureName) refers to,
+// exactly or else gracefully.
+func (registry *Registry) resolve(fixtureName string, names []string, fullNames []string) (string, error) {
+	matches := matching(names, fullNames, registry.refersTo)
+	if len(matches) == 0 {
+		matches = matching(names, fullNames, registry.refersGracefullyTo)
+	}
+	switch len(matches) {
+	case 0:
+		return "", &apperrors.NotFoundError{Entity: "fixture", Description: fixtureName}
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", &apperrors.AmbiguousFixtureError{Fixture: fixtureName, Candidates: matches}
+	}
+}
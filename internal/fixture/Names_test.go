@@ -0,0 +1,167 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	htmltemplate "html/template"
+	"reflect"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/essenius/slim4go/internal/apperrors"
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+const orderFullName = "github.com/essenius/slim4go/internal/fixture.Order"
+
+func TestNamesTypeNames(t *testing.T) {
+	assert.Equals(t, orderFullName, fullTypeName(reflect.TypeOf(NewOrder())), "Full name of pointer")
+	assert.Equals(t, orderFullName, fullTypeName(reflect.TypeOf(Order{})), "Full name of value")
+	assert.Equals(t, "fixture.Order", shortTypeName(reflect.TypeOf(NewOrder())), "Short name")
+	assert.Equals(t, "int", fullTypeName(reflect.TypeOf(1)), "Full name of predefined type")
+	assert.Equals(t, "[]string", fullTypeName(reflect.TypeOf([]string{})), "Full name of unnamed type")
+}
+
+func TestNamesFullPathImport(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(NewOrder)
+	for _, name := range []string{orderFullName, "internal/fixture.Order", "fixture.Order"} {
+		order, err := registry.FixtureNamed(name)
+		assert.IsTrue(t, order != nil && err == nil, name+" found")
+	}
+	_, err := registry.FixtureNamed("Order")
+	assert.Equals(t, "Order: fixture not found", err.Error(), "Not found without import")
+	_, err = registry.FixtureNamed("ture.Order")
+	assert.Equals(t, "ture.Order: fixture not found", err.Error(), "Path elements must match completely")
+	registry.AddNamespace("github.com/essenius/slim4go/internal/fixture")
+	order, err := registry.FixtureNamed("Order")
+	assert.IsTrue(t, order != nil && err == nil, "Found via full path import")
+}
+
+func TestNamesSameShortName(t *testing.T) {
+	registry := NewRegistry()
+	assert.Equals(t, nil, registry.AddFixture(texttemplate.New), "Add text template")
+	assert.Equals(t, nil, registry.AddFixture(htmltemplate.New), "Add html template")
+	assert.Equals(t, 2, registry.Length(), "Both fixtures registered")
+
+	_, err := registry.FixtureNamed("template.Template")
+	ambiguousErr, ok := err.(*apperrors.AmbiguousFixtureError)
+	assert.IsTrue(t, ok, "Short name is ambiguous")
+	assert.Equals(t, "html/template.Template, text/template.Template", strings.Join(ambiguousErr.Candidates, ", "), "Candidates")
+	textTemplate, err := registry.FixtureNamed("text/template.Template")
+	assert.Equals(t, nil, err, "Full name is not ambiguous")
+	assert.Equals(t, reflect.ValueOf(texttemplate.New).Pointer(), reflect.ValueOf(textTemplate).Pointer(), "Right fixture")
+
+	registry.AddNamespace("html/template")
+	htmlTemplate, err := registry.FixtureNamed("Template")
+	assert.Equals(t, nil, err, "Import of full path selects the package")
+	assert.Equals(t, reflect.ValueOf(htmltemplate.New).Pointer(), reflect.ValueOf(htmlTemplate).Pointer(), "Right fixture via import")
+	registry.AddNamespace("text/template")
+	_, err = registry.FixtureNamed("Template")
+	assert.Equals(t, "Template: ambiguous fixture name, matching html/template.Template, text/template.Template", err.Error(),
+		"Ambiguous via imports")
+	assert.Equals(t, "Could not set policy: template.Template: ambiguous fixture name, matching html/template.Template, text/template.Template",
+		registry.AllowMembers("template.Template", "Execute").Error(), "Policy for ambiguous name")
+	assert.Equals(t, nil, registry.AllowMembers("html/template.Template", "Execute"), "Policy for full name")
+}
+
+func TestNamesAlias(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(texttemplate.New)
+	registry.AddFixture(htmltemplate.New)
+	registry.AddFixture(NewOrder)
+	assert.Equals(t, nil, registry.AddAlias("HtmlTemplate", "html/template.Template"), "Add alias")
+	assert.Equals(t, nil, registry.AddAlias("HtmlTemplate", "html/template.Template"), "Add same alias again")
+	htmlTemplate, err := registry.FixtureNamed("HtmlTemplate")
+	assert.Equals(t, nil, err, "Found via alias")
+	assert.Equals(t, reflect.ValueOf(htmltemplate.New).Pointer(), reflect.ValueOf(htmlTemplate).Pointer(), "Right fixture via alias")
+	assert.Equals(t, "Could not add alias 'HtmlTemplate': it is already used for 'html/template.Template'",
+		registry.AddAlias("HtmlTemplate", "fixture.Order").Error(), "Alias used for other fixture")
+	assert.Equals(t, "Could not add alias 'Template': template.Template: ambiguous fixture name, matching html/template.Template, text/template.Template",
+		registry.AddAlias("Template", "template.Template").Error(), "Alias for ambiguous name")
+	assert.Equals(t, "Could not add alias 'Bogus': fixture.Bogus: fixture not found",
+		registry.AddAlias("Bogus", "fixture.Bogus").Error(), "Alias for unknown fixture")
+	catalog := registry.Catalog()
+	assert.Equals(t, "fixture.Order", catalog.Fixtures[0].Name, "Order first")
+	assert.Equals(t, "html/template", catalog.Fixtures[1].Package, "Same names sorted by package")
+	assert.Equals(t, "HtmlTemplate", strings.Join(catalog.Fixtures[1].Aliases, ","), "Alias in catalog")
+	assert.Equals(t, "text/template", catalog.Fixtures[2].Package, "Other package")
+	assert.Equals(t, "HtmlTemplateTemplateFixture", catalog.WikiPageName(catalog.Fixtures[1]), "Wiki page name with package")
+}
+
+type OrderFixture struct{}
+
+func NewOrderFixture() *OrderFixture {
+	return new(OrderFixture)
+}
+
+func TestNamesNormalized(t *testing.T) {
+	assert.Equals(t, "demofixtures.temperatureconverter", normalized("demofixtures/Temperature_Converter"), "Case, underscores and slashes")
+	assert.Equals(t, "fibonacci", normalized("fibonacci fixture"), "Spaces and Fixture suffix")
+	assert.Equals(t, "fixture", normalized("Fixture"), "Fixture only")
+	assert.Equals(t, "fixture.order", normalized("fixture.Order"), "Suffix only in type part")
+}
+
+func TestNamesDistance(t *testing.T) {
+	assert.Equals(t, 0, distance("order", "order"), "Same")
+	assert.Equals(t, 1, distance("ordr", "order"), "Insert")
+	assert.Equals(t, 3, distance("kitten", "sitting"), "Replace and insert")
+	assert.Equals(t, 5, distance("", "order"), "Empty")
+}
+
+func TestNamesGraceful(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(NewOrder)
+	registry.AddFixture(NewFixtureFactory().NewMessenger)
+	registry.AddNamespace("fixture")
+	for _, name := range []string{"messenger", "Messenger Fixture", "fixture/messenger", "internal.fixture.MESSENGER", "mess_enger"} {
+		messenger, err := registry.FixtureNamed(name)
+		assert.IsTrue(t, messenger != nil && err == nil, name+" found")
+	}
+	_, err := registry.FixtureNamed("ixture.Messenger")
+	assert.Equals(t, "ixture.Messenger: fixture not found", err.Error(), "Namespace must match completely")
+
+	registry = NewRegistry()
+	registry.AddFixture(NewFixtureFactory().NewMessenger)
+	_, err = registry.FixtureNamed("messenger")
+	assert.Equals(t, "messenger: fixture not found", err.Error(), "Still needs namespace")
+
+	registry.AddFixture(NewOrder)
+	registry.AddFixture(NewOrderFixture)
+	order, _ := registry.FixtureNamed("fixture.Order")
+	assert.Equals(t, reflect.ValueOf(NewOrder).Pointer(), reflect.ValueOf(order).Pointer(), "Exact match first")
+	orderFixture, _ := registry.FixtureNamed("fixture.OrderFixture")
+	assert.Equals(t, reflect.ValueOf(NewOrderFixture).Pointer(), reflect.ValueOf(orderFixture).Pointer(), "Exact match with suffix first")
+	_, err = registry.FixtureNamed("fixture.order")
+	_, ok := err.(*apperrors.AmbiguousFixtureError)
+	assert.IsTrue(t, ok, "Graceful match can be ambiguous")
+	registry.AddAlias("Purchase Order", "fixture.Order")
+	order, _ = registry.FixtureNamed("purchase_order")
+	assert.Equals(t, reflect.ValueOf(NewOrder).Pointer(), reflect.ValueOf(order).Pointer(), "Graceful alias")
+}
+
+func TestNamesClosestFixtures(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(NewOrder)
+	registry.AddFixture(NewOrderFixture)
+	registry.AddFixture(NewFixtureFactory().NewMessenger)
+	registry.AddFixture(texttemplate.New)
+	registry.AddFixture(htmltemplate.New)
+	assert.Equals(t, "fixture.Order, fixture.OrderFixture", strings.Join(registry.ClosestFixtures("fixture.Ordr"), ", "), "Similar names")
+	assert.Equals(t, "fixture.Messenger", strings.Join(registry.ClosestFixtures("other.massenger"), ", "), "Other namespace")
+	assert.Equals(t, "html/template.Template, text/template.Template", strings.Join(registry.ClosestFixtures("templates"), ", "),
+		"Full names for same short names")
+	assert.Equals(t, "fixture.Messenger", strings.Join(registry.ClosestFixtures("Mess"), ", "), "Part of the name")
+	assert.Equals(t, 0, len(registry.ClosestFixtures("Warehouse")), "Nothing similar")
+	assert.Equals(t, 0, len(registry.ClosestFixtures("x.")), "Empty type name")
+}
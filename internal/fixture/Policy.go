@@ -0,0 +1,115 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+
+	"github.com/essenius/slim4go/internal/apperrors"
+	"github.com/essenius/slim4go/internal/interfaces"
+)
+
+// Without policies, a wiki page can invoke any exported method or field of any instance, including objects of the system
+// under test that fixtures return via symbols. Policies restrict that at registration time. They apply to the Go names of
+// the members (e.g. ConvertTo), and the fixture names refer to registered fixtures (e.g. demofixtures.TemperatureConverter).
+
+// Definitions
+
+// policy restricts the members of a fixture.
+type policy struct {
+	// allowed contains the patterns of the members that can be used. If empty, all members can be used.
+	allowed  []string
+	readOnly map[string]bool
+}
+
+// Helpers
+
+func denied(fixtureName string, memberName string, reason string) error {
+	return &apperrors.AccessDeniedError{Member: memberName, Fixture: fixtureName, Reason: reason}
+}
+
+// Methods
+
+// AllowMembers restricts the members (methods and fields) of a registered fixture that can be used to those matching
+// one of the patterns (as in path.Match, e.g. Get*). Calling it again for the same fixture adds patterns.
+func (registry *Registry) AllowMembers(fixtureName string, patterns ...string) error {
+	fixturePolicy, err := registry.policyFor(fixtureName)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid member pattern '%v' for fixture '%v'", pattern, fixtureName)
+		}
+	}
+	fixturePolicy.allowed = append(fixturePolicy.allowed, patterns...)
+	return nil
+}
+
+// CheckAccess returns an AccessDeniedError if the policies don't allow using the member of an instance of the type.
+func (registry *Registry) CheckAccess(instanceType reflect.Type, memberName string, access interfaces.Access) error {
+	fullName := fullTypeName(instanceType)
+	fixtureName := shortTypeName(instanceType)
+	if _, ok := registry.shortName[fullName]; registry.denyNonFixtures && !ok {
+		return denied(fixtureName, memberName, "not a fixture")
+	}
+	fixturePolicy, ok := registry.policy[fullName]
+	if !ok {
+		return nil
+	}
+	if access == interfaces.FieldSet && fixturePolicy.readOnly[memberName] {
+		return denied(fixtureName, memberName, "read-only field")
+	}
+	if len(fixturePolicy.allowed) == 0 {
+		return nil
+	}
+	for _, pattern := range fixturePolicy.allowed {
+		if matched, _ := path.Match(pattern, memberName); matched {
+			return nil
+		}
+	}
+	return denied(fixtureName, memberName, "not an allowed member")
+}
+
+// DenyNonFixtureMembers denies using the members of instances that are not registered fixtures or libraries,
+// e.g. objects of the system under test that fixtures return via symbols.
+func (registry *Registry) DenyNonFixtureMembers() {
+	registry.denyNonFixtures = true
+}
+
+// policyFor returns the policy of a registered fixture, creating it if needed.
+func (registry *Registry) policyFor(fixtureName string) (*policy, error) {
+	fullName, err := registry.registeredName(fixtureName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not set policy: %v", err)
+	}
+	fixturePolicy, ok := registry.policy[fullName]
+	if !ok {
+		fixturePolicy = &policy{readOnly: make(map[string]bool)}
+		registry.policy[fullName] = fixturePolicy
+	}
+	return fixturePolicy, nil
+}
+
+// SetReadOnlyFields makes fields of a registered fixture read-only: a wiki page can get them, but not set them.
+func (registry *Registry) SetReadOnlyFields(fixtureName string, fieldNames ...string) error {
+	fixturePolicy, err := registry.policyFor(fixtureName)
+	if err != nil {
+		return err
+	}
+	for _, fieldName := range fieldNames {
+		fixturePolicy.readOnly[fieldName] = true
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/interfaces"
+)
+
+type Account struct {
+	Owner   string
+	Balance int
+}
+
+func NewAccount() *Account {
+	return new(Account)
+}
+
+func TestPolicyAllowMembers(t *testing.T) {
+	registry := NewRegistry()
+	accountType := reflect.TypeOf(NewAccount())
+	assert.Equals(t, "Could not set policy: fixture 'fixture.Account' is not registered",
+		registry.AllowMembers("fixture.Account", "Get*").Error(), "Policy for unregistered fixture")
+	registry.AddFixture(NewAccount)
+	assert.Equals(t, nil, registry.CheckAccess(accountType, "Close", interfaces.MethodCall), "All members allowed without policy")
+	assert.Equals(t, "Invalid member pattern '[' for fixture 'fixture.Account'",
+		registry.AllowMembers("fixture.Account", "[").Error(), "Invalid pattern")
+	assert.Equals(t, nil, registry.AllowMembers("fixture.Account", "Get*"), "Allow getters")
+	assert.Equals(t, nil, registry.AllowMembers("fixture.Account", "Owner"), "Allow owner")
+	assert.Equals(t, nil, registry.CheckAccess(accountType, "GetBalance", interfaces.MethodCall), "Matching pattern")
+	assert.Equals(t, nil, registry.CheckAccess(accountType, "Owner", interfaces.FieldSet), "Pattern added later")
+	assert.Equals(t, "fixture.Account.Close: access denied (not an allowed member)",
+		registry.CheckAccess(accountType, "Close", interfaces.MethodCall).Error(), "Method not allowed")
+	assert.Equals(t, "fixture.Account.Balance: access denied (not an allowed member)",
+		registry.CheckAccess(reflect.TypeOf(Account{}), "Balance", interfaces.FieldGet).Error(), "Field not allowed on struct value")
+}
+
+func TestPolicyReadOnlyFields(t *testing.T) {
+	registry := NewRegistry()
+	accountType := reflect.TypeOf(NewAccount())
+	registry.AddFixture(NewAccount)
+	assert.Equals(t, nil, registry.SetReadOnlyFields("fixture.Account", "Balance"), "Set read-only field")
+	assert.Equals(t, nil, registry.CheckAccess(accountType, "Balance", interfaces.FieldGet), "Read-only field can be read")
+	assert.Equals(t, "fixture.Account.Balance: access denied (read-only field)",
+		registry.CheckAccess(accountType, "Balance", interfaces.FieldSet).Error(), "Read-only field can't be set")
+	assert.Equals(t, nil, registry.CheckAccess(accountType, "Owner", interfaces.FieldSet), "Other fields can be set")
+	assert.IsTrue(t, registry.SetReadOnlyFields("fixture.Order", "Id") != nil, "Read-only field for unregistered fixture")
+}
+
+func TestPolicyDenyNonFixtureMembers(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(NewAccount)
+	registry.AddLibrary(&Library{})
+	orderType := reflect.TypeOf(NewOrder())
+	assert.Equals(t, nil, registry.CheckAccess(orderType, "Cancel", interfaces.MethodCall), "Non-fixture allowed by default")
+	registry.DenyNonFixtureMembers()
+	assert.Equals(t, "fixture.Order.Cancel: access denied (not a fixture)",
+		registry.CheckAccess(orderType, "Cancel", interfaces.MethodCall).Error(), "Non-fixture denied")
+	assert.Equals(t, nil, registry.CheckAccess(reflect.TypeOf(NewAccount()), "Close", interfaces.MethodCall), "Fixture allowed")
+	assert.Equals(t, nil, registry.CheckAccess(reflect.TypeOf(&Library{}), "Name", interfaces.MethodCall), "Library allowed")
+	assert.Equals(t, nil, registry.AllowMembers("fixture.Library", "Name"), "Policy for library")
+}
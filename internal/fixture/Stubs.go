@@ -0,0 +1,51 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/essenius/slim4go/slimfixture"
+)
+
+// Stubs call fixture methods without reflection. They are generated by slim4gogen for methods with simple parameter
+// and result types. Only fixtures made by constructors returning pointers have stubs, so they apply to pointer instances.
+// Stubs are registered by type rather than by name, as fixtures of different packages can have the same name.
+
+// AddStubs registers the stubs of the methods of a registered fixture. The fixture is a (nil) pointer of its type.
+func (registry *Registry) AddStubs(fixture interface{}, stubs slimfixture.Stubs) error {
+	fixtureType := reflect.TypeOf(fixture)
+	if fixtureType == nil || fixtureType.Kind() != reflect.Ptr {
+		return fmt.Errorf("Could not add stubs: expected a pointer to a fixture but got '%v'", fixtureType)
+	}
+	fullName := fullTypeName(fixtureType)
+	if _, ok := registry.shortName[fullName]; !ok {
+		return fmt.Errorf("Could not add stubs: fixture '%v' is not registered", fullName)
+	}
+	if registry.stubs[fullName] == nil {
+		registry.stubs[fullName] = make(slimfixture.Stubs)
+	}
+	for methodName, stub := range stubs {
+		registry.stubs[fullName][methodName] = stub
+	}
+	return nil
+}
+
+// StubFor returns the stub of a method of an instance of the type, if there is one.
+func (registry *Registry) StubFor(instanceType reflect.Type, methodName string) (slimfixture.Stub, bool) {
+	if instanceType.Kind() != reflect.Ptr {
+		return nil, false
+	}
+	stub, ok := registry.stubs[fullTypeName(instanceType)][methodName]
+	return stub, ok
+}
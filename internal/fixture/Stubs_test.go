@@ -0,0 +1,70 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package fixture
+
+import (
+	htmltemplate "html/template"
+	"reflect"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/slimfixture"
+)
+
+func TestStubsAddAndFind(t *testing.T) {
+	registry := NewRegistry()
+	owner := func(instance interface{}, args []string) ([]interface{}, error) {
+		return []interface{}{instance.(*Account).Owner}, nil
+	}
+	assert.Equals(t, "Could not add stubs: fixture 'github.com/essenius/slim4go/internal/fixture.Account' is not registered",
+		registry.AddStubs((*Account)(nil), slimfixture.Stubs{"GetOwner": owner}).Error(), "Stubs for unregistered fixture")
+	assert.Equals(t, "Could not add stubs: expected a pointer to a fixture but got 'string'",
+		registry.AddStubs("fixture.Account", slimfixture.Stubs{"GetOwner": owner}).Error(), "Stubs by name")
+	assert.Equals(t, "Could not add stubs: expected a pointer to a fixture but got '<nil>'",
+		registry.AddStubs(nil, slimfixture.Stubs{"GetOwner": owner}).Error(), "Stubs for nil")
+	registry.AddFixture(NewAccount)
+	assert.Equals(t, nil, registry.AddStubs((*Account)(nil), slimfixture.Stubs{"GetOwner": owner}), "Add stubs")
+	assert.Equals(t, nil, registry.AddStubs((*Account)(nil), slimfixture.Stubs{"Close": owner}), "Add more stubs")
+	stub, ok := registry.StubFor(reflect.TypeOf(&Account{}), "GetOwner")
+	assert.IsTrue(t, ok, "Stub found for pointer")
+	result, err := stub(&Account{Owner: "Alice"}, nil)
+	assert.Equals(t, nil, err, "Stub without error")
+	assert.Equals(t, "Alice", result[0], "Stub result")
+	_, ok = registry.StubFor(reflect.TypeOf(&Account{}), "Close")
+	assert.IsTrue(t, ok, "Stubs are merged")
+	_, ok = registry.StubFor(reflect.TypeOf(Account{}), "GetOwner")
+	assert.IsTrue(t, !ok, "No stub for value")
+	_, ok = registry.StubFor(reflect.TypeOf(&Account{}), "Open")
+	assert.IsTrue(t, !ok, "No stub for other method")
+}
+
+func TestStubsSameNameInDifferentPackages(t *testing.T) {
+	registry := NewRegistry()
+	registry.AddFixture(texttemplate.New)
+	registry.AddFixture(htmltemplate.New)
+	stubReturning := func(value string) slimfixture.Stub {
+		return func(instance interface{}, args []string) ([]interface{}, error) {
+			return []interface{}{value}, nil
+		}
+	}
+	assert.Equals(t, nil, registry.AddStubs((*texttemplate.Template)(nil), slimfixture.Stubs{"Name": stubReturning("text")}), "Add text stubs")
+	assert.Equals(t, nil, registry.AddStubs((*htmltemplate.Template)(nil), slimfixture.Stubs{"Name": stubReturning("html")}), "Add html stubs")
+	stub, ok := registry.StubFor(reflect.TypeOf(&texttemplate.Template{}), "Name")
+	assert.IsTrue(t, ok, "Stub found for text template")
+	result, _ := stub(nil, nil)
+	assert.Equals(t, "text", result[0], "Text template uses its own stub")
+	stub, ok = registry.StubFor(reflect.TypeOf(&htmltemplate.Template{}), "Name")
+	assert.IsTrue(t, ok, "Stub found for html template")
+	result, _ = stub(nil, nil)
+	assert.Equals(t, "html", result[0], "Html template uses its own stub")
+}
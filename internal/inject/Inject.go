@@ -12,18 +12,28 @@
 package inject
 
 import (
+	"log/slog"
+
 	"github.com/essenius/slim4go/internal/context"
 	"github.com/essenius/slim4go/internal/fixture"
 	"github.com/essenius/slim4go/internal/interfaces"
-	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimdiagnostics"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 	"github.com/essenius/slim4go/internal/slimprocessor"
+	"github.com/essenius/slim4go/internal/slimrepl"
 	"github.com/essenius/slim4go/internal/slimserver"
 	"github.com/essenius/slim4go/internal/standardlibrary"
+	"github.com/essenius/slim4go/internal/wikirunner"
 )
 
-// ActorStack injects an ActorStack object
+var actorStackInstance *standardlibrary.ActorStack
+
+// ActorStack injects an ActorStack object (single instance)
 func ActorStack() *standardlibrary.ActorStack {
-	return standardlibrary.NewActorStack()
+	if actorStackInstance == nil {
+		actorStackInstance = standardlibrary.NewActorStack()
+	}
+	return actorStackInstance
 }
 
 var contextInstance *context.Context
@@ -36,14 +46,33 @@ func Context() *context.Context {
 	return contextInstance
 }
 
+// Logger provides the logger for diagnostic messages, as configured in the context.
+func Logger() *slog.Logger {
+	return Context().Logger
+}
+
+// Diagnostics injects a Diagnostics instance, which serves the state of the session.
+func Diagnostics() *slimdiagnostics.Diagnostics {
+	return slimdiagnostics.NewDiagnostics(SlimInterpreter(), ObjectHandler(), SymbolTable(), ActorStack())
+}
+
+var metricsInstance *slimmetrics.Metrics
+
+// Metrics provides the instruction metrics (single instance).
+func Metrics() *slimmetrics.Metrics {
+	if metricsInstance == nil {
+		metricsInstance = slimmetrics.NewMetrics()
+	}
+	return metricsInstance
+}
+
 var messengerInstance interfaces.SlimMessenger
 
 // Messenger provides a Messenger instance that can send messages to the Slim client.
 func Messenger() interfaces.SlimMessenger {
 	if messengerInstance == nil {
 		context := Context()
-		slimlog.Trace.Printf("Timeout is %v", context.ConnectionTimeout)
-		messengerInstance = slimserver.NewSlimMessenger(context.Port, context.ConnectionTimeout)
+		messengerInstance = slimserver.NewSlimMessenger(context.Port, context.ConnectionTimeout, Logger())
 	}
 	return messengerInstance
 }
@@ -60,7 +89,8 @@ func ObjectHandler() *slimprocessor.ObjectHandler {
 		parser := Parser()
 		objectHandlerInstance = slimprocessor.NewObjectHandler(parser)
 		parser.SetObjectSerializer(objectHandlerInstance)
-		objectHandlerInstance.Add("libraryStandard", StandardLibrary())
+		objectHandlerInstance.SetAccessPolicy(Registry())
+		objectHandlerInstance.SetStubSource(Registry())
 	}
 	return objectHandlerInstance
 }
@@ -71,41 +101,79 @@ var parserInstance *slimprocessor.Parser
 func Parser() *slimprocessor.Parser {
 	if parserInstance == nil {
 		parserInstance = slimprocessor.NewParser(SymbolTable())
+		parserInstance.SetAccessPolicy(Registry())
 	}
 	return parserInstance
 }
 
 var registryInstance *fixture.Registry
 
-// Registry returns a Registry (single instance).
+// Registry returns a Registry (single instance). The standard library is registered first, so it has the lowest precedence.
+// The namespaces and the policy for non-fixtures come from the context.
 func Registry() *fixture.Registry {
 	if registryInstance == nil {
 		registryInstance = fixture.NewRegistry()
+		registryInstance.AddLibrary(StandardLibrary())
+		for _, namespace := range Context().Namespaces {
+			registryInstance.AddNamespace(namespace)
+		}
+		if Context().DenyNonFixtureMembers {
+			registryInstance.DenyNonFixtureMembers()
+		}
 	}
 	return registryInstance
 }
 
-// SlimInterpreter injects a Slim Interpreter
+// Repl injects a Repl, which runs interactive commands via the Slim interpreter.
+func Repl() *slimrepl.Repl {
+	repl := slimrepl.NewRepl(SlimInterpreter())
+	repl.SetParser(Parser())
+	return repl
+}
+
+var slimInterpreterInstance *slimprocessor.SlimInterpreter
+
+// SlimInterpreter injects a Slim Interpreter (single instance, as it keeps track of the instruction being executed)
 func SlimInterpreter() *slimprocessor.SlimInterpreter {
-	return slimprocessor.NewSlimInterpreter(StatementProcessor(), Context().InstructionTimeout)
+	if slimInterpreterInstance == nil {
+		slimInterpreterInstance = slimprocessor.NewSlimInterpreter(StatementProcessor(), Context().InstructionTimeout)
+		slimInterpreterInstance.SetStackTraces(Context().StackTraces)
+		slimInterpreterInstance.SetLogger(Logger())
+		slimInterpreterInstance.SetParallel(Context().Parallel)
+		if Context().MetricsFile != "" || Context().MetricsAddress != "" {
+			slimInterpreterInstance.SetMetrics(Metrics())
+		}
+	}
+	return slimInterpreterInstance
 }
 
 // SlimServer provides the Slim server instance.
 func SlimServer() *slimserver.SlimServer {
 	if slimServerInstance == nil {
 		slimServerInstance = slimserver.NewSlimServer(Registry(), Messenger(), SlimInterpreter())
+		slimServerInstance.SetLogger(Logger())
 	}
 	return slimServerInstance
 }
 
 // StandardLibrary injects a StandardLibrary.
 func StandardLibrary() *standardlibrary.StandardLibrary {
-	return standardlibrary.New(ActorStack(), ObjectHandler())
+	standardLibrary := standardlibrary.New(ActorStack(), ObjectHandler(), Context().InstructionTimeout)
+	standardLibrary.SetDateFormat(Context().DateFormat)
+	return standardLibrary
 }
 
 // StatementProcessor injects a StatementProcessor.
 func StatementProcessor() *slimprocessor.SlimStatementProcessor {
-	return slimprocessor.NewStatementProcessor(Registry(), ObjectHandler(), Parser(), SymbolTable())
+	processor := slimprocessor.NewStatementProcessor(Registry(), ObjectHandler(), Parser(), SymbolTable())
+	processor.SetStackTraces(Context().StackTraces)
+	processor.SetLogger(Logger())
+	return processor
+}
+
+// WikiRunner injects a WikiRunner, which runs wiki pages via the Slim interpreter.
+func WikiRunner() *wikirunner.WikiRunner {
+	return wikirunner.NewWikiRunner(SlimInterpreter())
 }
 
 var symbolTableInstance *slimprocessor.SymbolTable
@@ -0,0 +1,38 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package interfaces
+
+import (
+	"reflect"
+
+	"github.com/essenius/slim4go/slimfixture"
+)
+
+// Access is the way a member is used.
+type Access int
+
+// The ways a member can be used.
+const (
+	MethodCall Access = iota
+	FieldGet
+	FieldSet
+)
+
+// AccessPolicy decides whether a member of an instance can be used.
+type AccessPolicy interface {
+	CheckAccess(instanceType reflect.Type, memberName string, access Access) error
+}
+
+// StubSource provides the stubs that call fixture methods without reflection.
+type StubSource interface {
+	StubFor(instanceType reflect.Type, methodName string) (slimfixture.Stub, bool)
+}
@@ -22,6 +22,7 @@ type ObjectHandler interface {
 	Collector
 	ObjectSerializer
 	AddObjectByConstructor(instanceName string, constructor reflect.Value, args []string) error
+	HasMemberOn(instance interface{}, memberName string, argCount int) bool
 	InvokeMemberOn(instance interface{}, method string, args *slimentity.SlimList) (slimentity.SlimEntity, error)
 	InstancesWithPrefix(prefix string) []interface{}
 }
@@ -11,11 +11,27 @@
 
 package interfaces
 
+import (
+	"github.com/essenius/slim4go/internal/catalog"
+	"github.com/essenius/slim4go/slimfixture"
+)
+
 // Registry is the interface for the fixture registry.
 type Registry interface {
+	AccessPolicy
+	StubSource
+	AddAlias(alias string, fixtureName string) error
 	AddFixture(constructor interface{}) error
 	AddFixturesFrom(fixtureFactory interface{}) error
+	AddLibrary(library interface{}) error
 	AddNamespace(namespace string)
-	FixtureNamed(name string) interface{}
+	AddStubs(fixture interface{}, stubs slimfixture.Stubs) error
+	AllowMembers(fixtureName string, patterns ...string) error
+	Catalog() *catalog.Catalog
+	ClosestFixtures(fixtureName string) []string
+	DenyNonFixtureMembers()
+	FixtureNamed(name string) (interface{}, error)
 	Length() int
+	Libraries() []interface{}
+	SetReadOnlyFields(fixtureName string, fieldNames ...string) error
 }
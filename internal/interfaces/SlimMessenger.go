@@ -16,6 +16,8 @@ import "io"
 // SlimMessenger communicates with the SLIM client (i.e. FitNesse)
 type SlimMessenger interface {
 	io.Reader
+	// Write is used to stream responses
+	io.Writer
 	Listen() error
 	SendMessage(message string) error
 }
@@ -20,6 +20,8 @@ type StatementProcessor interface {
 	DoCall(instanceName, methodName string, args *slimentity.SlimList) slimentity.SlimEntity
 	DoImport(value string) slimentity.SlimEntity
 	DoMake(instanceName, fixtureName string, args *slimentity.SlimList) slimentity.SlimEntity
+	HasMember(instanceName, methodName string, argCount int) bool
+	Instance(instanceName string) interface{}
 	SerializeObjectsIn(slimentity.SlimEntity) slimentity.SlimEntity
 	SetSymbol(symbol string, value interface{})
 }
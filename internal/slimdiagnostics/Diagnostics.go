@@ -0,0 +1,177 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimdiagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Diagnostics allows inspecting a live session (e.g. one that hangs) via HTTP, without attaching a debugger.
+// Besides the pprof endpoints (of which /debug/pprof/goroutine?debug=2 shows where goroutines are stuck), it shows
+// the instruction being executed, the instances, the symbols and the actor stack. It is only served on the loopback interface.
+// The pprof handlers are mounted on the private mux of the diagnostics. Importing net/http/pprof also registers them on
+// http.DefaultServeMux, but slim4go never serves that.
+
+// Definitions and constructors
+
+type activeInstructionSource interface {
+	ActiveInstruction() (*slimentity.SlimList, time.Time)
+}
+
+type instanceSource interface {
+	InstanceNames() []string
+	Get(instanceName string) interface{}
+}
+
+type symbolSource interface {
+	Values() map[string]interface{}
+}
+
+type actorSource interface {
+	Items() []interface{}
+}
+
+// Diagnostics serves the state of a session.
+type Diagnostics struct {
+	interpreter activeInstructionSource
+	objects     instanceSource
+	symbols     symbolSource
+	actors      actorSource
+}
+
+// NewDiagnostics creates a Diagnostics instance using the sources of the session state.
+func NewDiagnostics(interpreter activeInstructionSource, objects instanceSource, symbols symbolSource, actors actorSource) *Diagnostics {
+	diagnostics := new(Diagnostics)
+	diagnostics.interpreter = interpreter
+	diagnostics.objects = objects
+	diagnostics.symbols = symbols
+	diagnostics.actors = actors
+	return diagnostics
+}
+
+// ActiveInstruction describes the instruction being executed.
+type ActiveInstruction struct {
+	Active         bool       `json:"active"`
+	Instruction    string     `json:"instruction,omitempty"`
+	Started        *time.Time `json:"started,omitempty"`
+	RunningSeconds float64    `json:"runningSeconds,omitempty"`
+}
+
+// Value describes a value, e.g. of an instance or a symbol.
+type Value struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// Helpers
+
+// describe returns the type of a value, and the value itself only if it is text. Other values (e.g. fixtures) can be
+// changed by the instruction being executed, so reading them while serving a request would be a data race.
+func describe(name string, value interface{}) Value {
+	description := Value{Name: name, Type: fmt.Sprintf("%T", value)}
+	if text, ok := value.(string); ok {
+		description.Value = text
+	}
+	return description
+}
+
+func writeJSON(writer http.ResponseWriter, content interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(content)
+}
+
+// Methods
+
+// ActiveInstruction returns the instruction being executed, and how long it has been running.
+func (diagnostics *Diagnostics) ActiveInstruction() ActiveInstruction {
+	instruction, started := diagnostics.interpreter.ActiveInstruction()
+	if instruction == nil {
+		return ActiveInstruction{}
+	}
+	return ActiveInstruction{
+		Active:         true,
+		Instruction:    instruction.ToString(),
+		Started:        &started,
+		RunningSeconds: time.Since(started).Seconds(),
+	}
+}
+
+// Actors returns the actor stack, the top first.
+func (diagnostics *Diagnostics) Actors() []Value {
+	actors := []Value{}
+	for _, actor := range diagnostics.actors.Items() {
+		actors = append(actors, describe("", actor))
+	}
+	return actors
+}
+
+// Handler returns the HTTP handler serving the diagnostics.
+func (diagnostics *Diagnostics) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != "/" {
+			http.NotFound(writer, request)
+			return
+		}
+		fmt.Fprint(writer, "slim4go diagnostics\n\n"+
+			"/instruction  the instruction being executed\n"+
+			"/objects      the instances\n"+
+			"/symbols      the symbols\n"+
+			"/actors       the actor stack\n"+
+			"/debug/pprof/ profiles, e.g. /debug/pprof/goroutine?debug=2 for the stacks of all goroutines\n")
+	})
+	mux.HandleFunc("/instruction", func(writer http.ResponseWriter, request *http.Request) {
+		writeJSON(writer, diagnostics.ActiveInstruction())
+	})
+	mux.HandleFunc("/objects", func(writer http.ResponseWriter, request *http.Request) {
+		writeJSON(writer, diagnostics.Objects())
+	})
+	mux.HandleFunc("/symbols", func(writer http.ResponseWriter, request *http.Request) {
+		writeJSON(writer, diagnostics.Symbols())
+	})
+	mux.HandleFunc("/actors", func(writer http.ResponseWriter, request *http.Request) {
+		writeJSON(writer, diagnostics.Actors())
+	})
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// Objects returns the instances in order of creation.
+func (diagnostics *Diagnostics) Objects() []Value {
+	objects := []Value{}
+	for _, instanceName := range diagnostics.objects.InstanceNames() {
+		objects = append(objects, describe(instanceName, diagnostics.objects.Get(instanceName)))
+	}
+	return objects
+}
+
+// Symbols returns the symbols and their values.
+func (diagnostics *Diagnostics) Symbols() map[string]Value {
+	symbols := make(map[string]Value)
+	for symbolName, value := range diagnostics.symbols.Values() {
+		symbols[symbolName] = describe("", value)
+	}
+	return symbols
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimdiagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+type testInterpreter struct {
+	instruction *slimentity.SlimList
+	started     time.Time
+}
+
+func (interpreter *testInterpreter) ActiveInstruction() (*slimentity.SlimList, time.Time) {
+	return interpreter.instruction, interpreter.started
+}
+
+type testObjects map[string]interface{}
+
+func (objects testObjects) InstanceNames() []string {
+	return []string{"conv", "library1"}
+}
+
+func (objects testObjects) Get(instanceName string) interface{} {
+	return objects[instanceName]
+}
+
+type testSymbols map[string]interface{}
+
+func (symbols testSymbols) Values() map[string]interface{} {
+	return symbols
+}
+
+type testActors []interface{}
+
+func (actors testActors) Items() []interface{} {
+	return actors
+}
+
+type converter struct {
+	Unit string
+}
+
+func newTestServer() (*httptest.Server, *testInterpreter) {
+	interpreter := new(testInterpreter)
+	objects := testObjects{"conv": &converter{Unit: "C"}, "library1": 42}
+	symbols := testSymbols{"temp": "20", "list": []string{"a", "b"}}
+	actors := testActors{&converter{Unit: "F"}}
+	diagnostics := NewDiagnostics(interpreter, objects, symbols, actors)
+	return httptest.NewServer(diagnostics.Handler()), interpreter
+}
+
+func get(t *testing.T, server *httptest.Server, path string) (int, string) {
+	response, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(body)
+}
+
+func TestDiagnosticsInstruction(t *testing.T) {
+	server, interpreter := newTestServer()
+	defer server.Close()
+	_, body := get(t, server, "/instruction")
+	assert.Equals(t, "{\n  \"active\": false\n}\n", body, "No active instruction")
+
+	interpreter.instruction = slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call_1", "call", "conv", "wait"})
+	interpreter.started = time.Now().Add(-2 * time.Second)
+	_, body = get(t, server, "/instruction")
+	var active ActiveInstruction
+	assert.Equals(t, nil, json.Unmarshal([]byte(body), &active), "Valid JSON")
+	assert.IsTrue(t, active.Active, "Active")
+	assert.Equals(t, "[call_1, call, conv, wait]", active.Instruction, "Instruction")
+	assert.IsTrue(t, active.RunningSeconds >= 2, "Running for at least 2 seconds")
+}
+
+func TestDiagnosticsState(t *testing.T) {
+	server, _ := newTestServer()
+	defer server.Close()
+	_, body := get(t, server, "/objects")
+	var objects []Value
+	assert.Equals(t, nil, json.Unmarshal([]byte(body), &objects), "Valid JSON for objects")
+	assert.Equals(t, 2, len(objects), "Two objects")
+	assert.Equals(t, Value{Name: "conv", Type: "*slimdiagnostics.converter"}, objects[0], "Fixture instance without its state")
+	assert.Equals(t, Value{Name: "library1", Type: "int"}, objects[1], "Other instance")
+
+	_, body = get(t, server, "/symbols")
+	var symbols map[string]Value
+	assert.Equals(t, nil, json.Unmarshal([]byte(body), &symbols), "Valid JSON for symbols")
+	assert.Equals(t, Value{Type: "string", Value: "20"}, symbols["temp"], "Text symbol")
+	assert.Equals(t, Value{Type: "[]string"}, symbols["list"], "Object symbol without its value")
+
+	_, body = get(t, server, "/actors")
+	var actors []Value
+	assert.Equals(t, nil, json.Unmarshal([]byte(body), &actors), "Valid JSON for actors")
+	assert.Equals(t, 1, len(actors), "One actor")
+	assert.Equals(t, Value{Type: "*slimdiagnostics.converter"}, actors[0], "Actor")
+}
+
+func TestDiagnosticsIndexAndProfiles(t *testing.T) {
+	server, _ := newTestServer()
+	defer server.Close()
+	status, body := get(t, server, "/")
+	assert.Equals(t, http.StatusOK, status, "Index found")
+	assert.IsTrue(t, strings.Contains(body, "/debug/pprof/goroutine?debug=2"), "Index mentions goroutine dump")
+	status, _ = get(t, server, "/unknown")
+	assert.Equals(t, http.StatusNotFound, status, "Unknown path not found")
+	status, body = get(t, server, "/debug/pprof/goroutine?debug=2")
+	assert.Equals(t, http.StatusOK, status, "Goroutine dump found")
+	assert.IsTrue(t, strings.Contains(body, "goroutine "), "Goroutine dump has goroutines")
+	status, body = get(t, server, "/debug/pprof/")
+	assert.Equals(t, http.StatusOK, status, "Profile index found")
+	assert.IsTrue(t, strings.Contains(body, "heap?debug=1"), "Profile index lists heap")
+	status, body = get(t, server, "/debug/pprof/heap")
+	assert.Equals(t, http.StatusOK, status, "Heap profile found")
+	assert.IsTrue(t, len(body) > 0, "Heap profile has content")
+	status, _ = get(t, server, "/debug/pprof/unknown")
+	assert.Equals(t, http.StatusNotFound, status, "Unknown profile not found")
+	status, body = get(t, server, "/debug/pprof/cmdline")
+	assert.Equals(t, http.StatusOK, status, "Command line found")
+	assert.IsTrue(t, strings.Contains(body, "slimdiagnostics"), "Command line contains the test binary")
+	status, _ = get(t, server, "/debug/pprof/symbol")
+	assert.Equals(t, http.StatusOK, status, "Symbol lookup found")
+}
+
+// Run with -race: serving the state must not read fixtures that the instruction being executed changes.
+func TestDiagnosticsStateWhileExecuting(t *testing.T) {
+	interpreter := new(testInterpreter)
+	fixture := &converter{Unit: "C"}
+	diagnostics := NewDiagnostics(interpreter, testObjects{"conv": fixture}, testSymbols{"conv": fixture}, testActors{fixture})
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			fixture.Unit = fmt.Sprint(i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		diagnostics.Objects()
+		diagnostics.Symbols()
+		diagnostics.Actors()
+	}
+	<-done
+}
@@ -14,6 +14,7 @@ package slimentity
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/essenius/slim4go/internal/slimprotocol"
 )
@@ -87,6 +88,14 @@ func ToString(entity SlimEntity) string {
 	return entity.(string)
 }
 
+// describe returns a description of an element for error messages.
+func describe(element SlimEntity) string {
+	if list, ok := element.(*SlimList); ok {
+		return "list " + list.ToString()
+	}
+	return fmt.Sprintf("'%v'", element)
+}
+
 // Methods
 
 //Append adds an item to the list.
@@ -112,6 +121,49 @@ func (list *SlimList) Equals(other *SlimList) bool {
 	return true
 }
 
+// Get returns the element at the index, or an error if the index is out of range.
+func (list *SlimList) Get(index int) (SlimEntity, error) {
+	if index < 0 || index >= list.Length() {
+		return nil, fmt.Errorf("Index %v out of range for list of length %v", index, list.Length())
+	}
+	return (*list)[index], nil
+}
+
+// GetList returns the element at the index if it is a list, or an error otherwise.
+func (list *SlimList) GetList(index int) (*SlimList, error) {
+	element, err := list.Get(index)
+	if err != nil {
+		return nil, err
+	}
+	subList, ok := element.(*SlimList)
+	if !ok {
+		return nil, fmt.Errorf("Expected a list at index %v but got %v", index, describe(element))
+	}
+	return subList, nil
+}
+
+// GetString returns the element at the index if it is a string, or an error otherwise.
+func (list *SlimList) GetString(index int) (string, error) {
+	element, err := list.Get(index)
+	if err != nil {
+		return "", err
+	}
+	text, ok := element.(string)
+	if !ok {
+		return "", fmt.Errorf("Expected a string at index %v but got %v", index, describe(element))
+	}
+	return text, nil
+}
+
+// GetTail returns a list containing everything from the index to the end (which is empty if the index equals the length),
+// or an error if the index is out of range.
+func (list *SlimList) GetTail(index int) (*SlimList, error) {
+	if index < 0 || index > list.Length() {
+		return nil, fmt.Errorf("Tail index %v out of range for list of length %v", index, list.Length())
+	}
+	return list.TailAt(index), nil
+}
+
 // Length returns the number of items in the list.
 func (list *SlimList) Length() int {
 	if list == nil {
@@ -133,21 +185,28 @@ func (list *SlimList) TailAt(index int) *SlimList {
 
 // ToString returns a string representation of the list.
 func (list *SlimList) ToString() string {
-	result := "["
+	var builder strings.Builder
+	list.writeString(&builder)
+	return builder.String()
+}
 
+// writeString writes the string representation of the list, so nested lists don't need their own strings.
+func (list *SlimList) writeString(builder *strings.Builder) {
+	builder.WriteByte('[')
 	for i, entry := range *list {
-		if IsSlimList(entry) {
-			result += entry.(*SlimList).ToString()
-		} else {
-			result += entry.(string)
+		if i > 0 {
+			builder.WriteString(", ")
 		}
-
-		if i != len(*list)-1 {
-			result += ", "
+		switch value := entry.(type) {
+		case *SlimList:
+			value.writeString(builder)
+		case string:
+			builder.WriteString(value)
+		default:
+			builder.WriteString(fmt.Sprintf("%v", value))
 		}
 	}
-	result += "]"
-	return result
+	builder.WriteByte(']')
 }
 
 // TransformCallResult converts the result of a call to a string representation, or an object pointer.
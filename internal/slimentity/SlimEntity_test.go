@@ -77,6 +77,40 @@ func TestSlimListBaseTests(t *testing.T) {
 	assert.Equals(t, "[1 2]", fmt.Sprintf("%v", ToSlice(list6)), "to slice")
 }
 
+func TestSlimListSafeAccessors(t *testing.T) {
+	list := NewSlimListContaining(SlimList{"a", NewSlimListContaining(SlimList{"b", "c"}), nil})
+	element, err := list.Get(0)
+	assert.Equals(t, "a", element, "Get")
+	assert.Equals(t, nil, err, "Get without error")
+	_, err = list.Get(3)
+	assert.Equals(t, "Index 3 out of range for list of length 3", err.Error(), "Get beyond the end")
+	_, err = list.Get(-1)
+	assert.Equals(t, "Index -1 out of range for list of length 3", err.Error(), "Get before the start")
+	text, err := list.GetString(0)
+	assert.Equals(t, "a", text, "GetString")
+	assert.Equals(t, nil, err, "GetString without error")
+	_, err = list.GetString(1)
+	assert.Equals(t, "Expected a string at index 1 but got list [b, c]", err.Error(), "GetString on a list")
+	_, err = list.GetString(2)
+	assert.Equals(t, "Expected a string at index 2 but got '<nil>'", err.Error(), "GetString on nil")
+	_, err = list.GetString(5)
+	assert.Equals(t, "Index 5 out of range for list of length 3", err.Error(), "GetString beyond the end")
+	subList, err := list.GetList(1)
+	assert.Equals(t, "[b, c]", subList.ToString(), "GetList")
+	assert.Equals(t, nil, err, "GetList without error")
+	_, err = list.GetList(0)
+	assert.Equals(t, "Expected a list at index 0 but got 'a'", err.Error(), "GetList on a string")
+	_, err = list.GetList(3)
+	assert.Equals(t, "Index 3 out of range for list of length 3", err.Error(), "GetList beyond the end")
+	tail, err := list.GetTail(3)
+	assert.Equals(t, 0, tail.Length(), "GetTail at the end")
+	assert.Equals(t, nil, err, "GetTail at the end without error")
+	tail, _ = list.GetTail(1)
+	assert.Equals(t, "[[b, c], <nil>]", tail.ToString(), "GetTail and ToString with nil")
+	_, err = list.GetTail(4)
+	assert.Equals(t, "Tail index 4 out of range for list of length 3", err.Error(), "GetTail beyond the end")
+}
+
 func TestSlimEntityIsObject(t *testing.T) {
 	assert.IsTrue(t, !IsObject(""), "empty string is no object")
 	assert.IsTrue(t, IsObject(t), "t (pointer to struct T) is an object")
@@ -13,138 +13,270 @@ package slimentity
 
 import (
 	"bufio"
-	"errors"
+	"bytes"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
-
-	"github.com/essenius/slim4go/internal/apperrors"
 )
 
+// Slim messages are length prefixed: 000003:bye. A list is a message with content like [000002:000002:Hi:000003:Bye:],
+// i.e. the number of entries followed by the entries, each of which is a length prefixed message terminated by a colon.
+// Lengths are in bytes (UTF-8), normally 6 digits but can be more.
+
+// Definitions and constructors
+
 const (
 	terminator     = ':'
 	listStarter    = '['
 	listTerminator = ']'
+	// maxLengthDigits limits the size of a length (and of the number of entries in a list) to something sensible.
+	maxLengthDigits = 10
 )
 
-type slimReader struct {
-	*bufio.Reader
+// Decoder reads Slim messages from a stream. It keeps its buffer between messages, so nothing that was read ahead gets lost.
+type Decoder struct {
+	reader *bufio.Reader
 }
 
-func newSlimReader(reader io.Reader) *slimReader {
-	aSlimReader := new(slimReader)
-	aSlimReader.Reader = bufio.NewReader(reader)
-	return aSlimReader
+// NewDecoder creates a new Decoder reading from reader.
+func NewDecoder(reader io.Reader) *Decoder {
+	decoder := new(Decoder)
+	decoder.reader = bufio.NewReader(reader)
+	return decoder
 }
 
-func (reader *slimReader) skipByte(expected byte) {
-	character, err := reader.ReadByte()
+// encoder writes entities in the Slim format. It measures the lists once before writing: lists are measured and written
+// in the same order, so the lengths can be kept in a slice.
+type encoder struct {
+	writer      stringWriter
+	listLengths []int
+	nextList    int
+	scratch     []byte
+}
 
-	if err != nil {
-		panic("SkipByte: No input available")
-	}
-	if character != expected {
-		panic(fmt.Sprintf("skipByte: Expected '%v' but found '%v'", string(expected), string(character)))
+// stringWriter is implemented by both bufio.Writer and strings.Builder.
+type stringWriter interface {
+	io.ByteWriter
+	io.StringWriter
+	io.Writer
+}
+
+const lengthDigits = 6
+
+func newEncoder(writer stringWriter) *encoder {
+	encoder := new(encoder)
+	encoder.writer = writer
+	encoder.scratch = make([]byte, 0, maxLengthDigits+1)
+	return encoder
+}
+
+// Helpers
+
+// parseLength parses a length (or entry count) followed by a terminator at the start of data,
+// and returns it with the rest of the data. The boolean is false if there is no valid length.
+func parseLength(data []byte) (int, []byte, bool) {
+	for i := 0; i < len(data) && i <= maxLengthDigits; i++ {
+		if data[i] == terminator {
+			if i == 0 {
+				return 0, nil, false
+			}
+			length, err := strconv.Atoi(string(data[:i]))
+			return length, data[i+1:], err == nil
+		}
+		if data[i] < '0' || data[i] > '9' {
+			return 0, nil, false
+		}
 	}
+	return 0, nil, false
 }
 
-func (reader *slimReader) readLength() int {
-	// lengths are normally 6 characters, but can be more. So we generalize and search for the terminator
-	lengthString, err1 := reader.ReadString(terminator)
-	if err1 != nil {
-		panic(fmt.Sprintf("readLength: Could not find next delimiter '%c' (%v)", terminator, err1.Error()))
+// parseEntry parses a length prefixed entry of a list, including the terminator that follows it.
+func parseEntry(data []byte) (SlimEntity, []byte, error) {
+	length, rest, ok := parseLength(data)
+	if !ok {
+		return nil, nil, fmt.Errorf("Expected a length but found '%v'", abbreviate(data))
 	}
-	lengthString = lengthString[0 : len(lengthString)-1]
-	length, err2 := strconv.Atoi(lengthString)
-	if err2 != nil {
-		panic(fmt.Sprintf("readLength: Could not interpret length '%v'", lengthString))
+	if len(rest) < length+1 {
+		return nil, nil, fmt.Errorf("Expected %v bytes and a '%c' but found '%v'", length, terminator, abbreviate(rest))
 	}
-	return length
+	if rest[length] != terminator {
+		return nil, nil, fmt.Errorf("Expected '%c' after %v bytes but found '%c'", terminator, length, rest[length])
+	}
+	entity, err := parseEntity(rest[:length])
+	return entity, rest[length+1:], err
 }
 
-func (reader *slimReader) readExactBytes(numberOfBytes int) []byte {
-	// we're using bytes on purpose here. The line sizes are in bytes.
-	// Note that this means it isn't necessarily the number of characters - SLIM uses UTF-8 in text.
-
-	buffer := make([]byte, numberOfBytes)
-	if n, err := io.ReadAtLeast(reader, buffer, numberOfBytes); err != nil {
-		panic(fmt.Errorf("readExactBytes: Expected %v bytes from Slim client, but got %v", numberOfBytes, n))
+// parseEntity parses the content of a message, which is a list if it starts with a list header and a string otherwise.
+func parseEntity(content []byte) (SlimEntity, error) {
+	if len(content) == 0 || content[0] != listStarter {
+		return string(content), nil
+	}
+	count, rest, isList := parseLength(content[1:])
+	if !isList {
+		return string(content), nil
+	}
+	list := NewSlimList()
+	for entry := 0; entry < count; entry++ {
+		listEntry, remaining, err := parseEntry(rest)
+		if err != nil {
+			return nil, fmt.Errorf("List entry %v: %v", entry+1, err)
+		}
+		list.Append(listEntry)
+		rest = remaining
 	}
-	return buffer
+	if len(rest) != 1 || rest[0] != listTerminator {
+		return nil, fmt.Errorf("Expected '%c' after %v list entries but found '%v'", listTerminator, count, abbreviate(rest))
+	}
+	return list, nil
 }
 
-func (reader *slimReader) isStartOfList() (bool, int) {
-	const minCharsForList = 9 // smallest possible list is [000000:]
-	// we need this check since Peek hangs if it tries to go beyond the buffer
-	if reader.Buffered() < minCharsForList {
-		return false, 0
+// abbreviate keeps error messages readable if the data is big.
+func abbreviate(data []byte) string {
+	const maxLength = 20
+	if len(data) > maxLength {
+		return string(data[:maxLength]) + "..."
 	}
-	for peekLength := minCharsForList - 1; ; peekLength++ {
-		potentialListStart, _ := reader.Peek(peekLength) // can't go wrong due to above check
-		if potentialListStart[0] != listStarter {
-			return false, 0
+	return string(data)
+}
+
+func (decoder *Decoder) readLength() (int, error) {
+	var digits []byte
+	for {
+		character, err := decoder.reader.ReadByte()
+		if err == io.EOF && len(digits) > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
 		}
-		listLength, err2 := strconv.Atoi(string(potentialListStart[1 : peekLength-1]))
-		if err2 != nil {
-			return false, 0
+		if character == terminator && len(digits) > 0 {
+			break
 		}
-		if potentialListStart[peekLength-1] == terminator {
-			_ = reader.readExactBytes(peekLength)
-			return true, listLength
+		if character < '0' || character > '9' || len(digits) == maxLengthDigits {
+			return 0, fmt.Errorf("Could not interpret length '%v'", string(append(digits, character)))
 		}
+		digits = append(digits, character)
 	}
+	return strconv.Atoi(string(digits))
+}
 
+// entityText returns the content of an entity that isn't a list.
+func entityText(entity SlimEntity) string {
+	if text, ok := entity.(string); ok {
+		return text
+	}
+	return convertNull(fmt.Sprintf("%v", entity))
 }
 
-// ReadRequest is the entry point to read a request message from FitNesse.
-func ReadRequest(reader io.Reader) (out interface{}, err error) {
-	var aSlimReader = newSlimReader(reader)
-	return aSlimReader.readRequest()
+func digitCount(number int) int {
+	count := 1
+	for ; number >= 10; number /= 10 {
+		count++
+	}
+	return count
 }
 
-func (reader *slimReader) readRequest() (out SlimEntity, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			out = nil
-			err = errors.New(apperrors.ErrorToString(r))
-		}
-	}()
-
-	lengthInBytes := reader.readLength()
-	if isStart, numberOfItems := reader.isStartOfList(); isStart {
-		list := NewSlimList()
-		for line := 0; line < numberOfItems; line++ {
-			listEntry, err := reader.readRequest()
-			if err != nil {
-				return nil, err
-			}
-			reader.skipByte(terminator)
-			list.Append(listEntry)
-		}
-		reader.skipByte(listTerminator)
-		return list, nil
+// lengthSize returns the number of bytes of a length (or entry count) including its terminator.
+func lengthSize(length int) int {
+	if count := digitCount(length); count > lengthDigits {
+		return count + 1
+	}
+	return lengthDigits + 1
+}
+
+// measure returns the number of bytes of the content of an entity (i.e. without its length), and keeps the list lengths.
+func (encoder *encoder) measure(entity SlimEntity) int {
+	list, ok := entity.(*SlimList)
+	if !ok {
+		return len(entityText(entity))
+	}
+	index := len(encoder.listLengths)
+	encoder.listLengths = append(encoder.listLengths, 0)
+	length := 1 + lengthSize(list.Length()) + 1
+	for _, entry := range *list {
+		entryLength := encoder.measure(entry)
+		length += lengthSize(entryLength) + entryLength + 1
 	}
-	listEntry := reader.readExactBytes(lengthInBytes)
-	return string(listEntry), nil
+	encoder.listLengths[index] = length
+	return length
+}
+
+// marshalledLength measures the entity, and returns its number of bytes including the length.
+func (encoder *encoder) marshalledLength(entity SlimEntity) int {
+	length := encoder.measure(entity)
+	return lengthSize(length) + length
+}
+
+// writeLength writes a length (or entry count) with at least 6 digits, followed by a terminator.
+func (encoder *encoder) writeLength(length int) {
+	encoder.scratch = encoder.scratch[:0]
+	for padding := lengthDigits - digitCount(length); padding > 0; padding-- {
+		encoder.scratch = append(encoder.scratch, '0')
+	}
+	encoder.scratch = strconv.AppendInt(encoder.scratch, int64(length), 10)
+	encoder.scratch = append(encoder.scratch, terminator)
+	encoder.writer.Write(encoder.scratch)
+}
+
+// writeEntity writes the length and the content of a measured entity. Write errors are reported when the writer is flushed.
+func (encoder *encoder) writeEntity(entity SlimEntity) {
+	list, ok := entity.(*SlimList)
+	if !ok {
+		text := entityText(entity)
+		encoder.writeLength(len(text))
+		encoder.writer.WriteString(text)
+		return
+	}
+	encoder.writeLength(encoder.listLengths[encoder.nextList])
+	encoder.nextList++
+	encoder.writer.WriteByte(listStarter)
+	encoder.writeLength(list.Length())
+	for _, entry := range *list {
+		encoder.writeEntity(entry)
+		encoder.writer.WriteByte(terminator)
+	}
+	encoder.writer.WriteByte(listTerminator)
+}
+
+// Methods
+
+// Decode reads the next message. It returns io.EOF if the stream ended before the message started.
+func (decoder *Decoder) Decode() (SlimEntity, error) {
+	length, err := decoder.readLength()
+	if err != nil {
+		return nil, err
+	}
+	// We don't allocate the length up front, since it comes from outside. The buffer grows with the content we get.
+	var content bytes.Buffer
+	if received, err := io.CopyN(&content, decoder.reader, int64(length)); err != nil {
+		return nil, fmt.Errorf("Expected %v bytes but got %v", length, received)
+	}
+	return parseEntity(content.Bytes())
+}
+
+// ReadRequest reads a single message (e.g. a request from FitNesse). Use a Decoder to read a stream of messages.
+func ReadRequest(reader io.Reader) (SlimEntity, error) {
+	return NewDecoder(reader).Decode()
+}
+
+// Encode writes a SlimEntity in its Slim serialized representation. Lists are measured once, and the output is streamed,
+// so big responses (e.g. query tables with many rows) don't need to be built in memory.
+func Encode(writer io.Writer, entity SlimEntity) error {
+	buffer := bufio.NewWriter(writer)
+	encoder := newEncoder(buffer)
+	encoder.measure(entity)
+	encoder.writeEntity(entity)
+	return buffer.Flush()
 }
 
 // Marshal converts a SlimEntity to its Slim serialized representation
 func Marshal(entity SlimEntity) string {
-	if !IsSlimList(entity) {
-		entry := convertNull(fmt.Sprintf("%v", entity))
-		return fmt.Sprintf("%06d%c%s", len(entry), terminator, entry)
-	}
-	var stringBuilder strings.Builder
-	list := entity.(*SlimList)
-	stringBuilder.WriteString(fmt.Sprintf("%c%06d%c", listStarter, list.Length(), terminator))
-	for _, listEntry := range *list {
-		stringBuilder.WriteString(Marshal(listEntry))
-		stringBuilder.WriteRune(terminator)
-	}
-	stringBuilder.WriteRune(listTerminator)
-	result := stringBuilder.String()
-	return Marshal(result)
+	var builder strings.Builder
+	encoder := newEncoder(&builder)
+	builder.Grow(encoder.marshalledLength(entity))
+	encoder.writeEntity(entity)
+	return builder.String()
 }
 
 func convertNull(message interface{}) string {
@@ -12,9 +12,12 @@
 package slimentity
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/essenius/slim4go/internal/assert"
 )
@@ -55,12 +58,18 @@ func TestSlimMarshallerReadRequestFail(t *testing.T) {
 		errorMessage string
 		description  string
 	}{
-		{"", "readLength: Could not find next delimiter ':' (EOF)", "Empty message"},
-		{"00a:", "readLength: Could not interpret length '00a'", "Wrong length spec"},
-		{"0000017:[000001:", "readExactBytes: Expected 17 bytes from Slim client, but got 8", "Incomplete message"},
-		{"000026:[000001:000009:Hi there.:q", "skipByte: Expected ']' but found 'q'", "Wrong list delimiter"},
-		{"000026:[000001:000009:Hi there.:", "SkipByte: No input available", "missing final delimiter"},
-		{"", "readLength: Could not find next delimiter ':' (EOF)", "Empty message"},
+		{"", "EOF", "Empty message"},
+		{"00012", "unexpected EOF", "Missing length terminator"},
+		{":", "Could not interpret length ':'", "Missing length"},
+		{"00a:", "Could not interpret length '00a'", "Wrong length spec"},
+		{"12345678901:", "Could not interpret length '12345678901'", "Length too long"},
+		{"0000017:[000001:", "Expected 17 bytes but got 8", "Incomplete message"},
+		{"000026:[000001:000009:Hi there.:q", "Expected ']' after 1 list entries but found 'q'", "Wrong list delimiter"},
+		{"000026:[000001:000009:Hi there.:", "Expected 26 bytes but got 25", "missing final delimiter"},
+		{"000017:[000002:000000::]", "List entry 2: Expected a length but found ']'", "Missing entry"},
+		{"000017:[000001:000003::]", "List entry 1: Expected 3 bytes and a ':' but found ':]'", "Entry too short"},
+		{"000018:[000001:000001:a;]", "List entry 1: Expected ':' after 1 bytes but found ';'", "Wrong entry terminator"},
+		{"000035:[000001:000018:[000001:000001:a;]:]", "List entry 1: List entry 1: Expected ':' after 1 bytes but found ';'", "Error in nested list"},
 	}
 	for _, testcase := range testcases {
 		stringReader := strings.NewReader(testcase.request)
@@ -97,6 +106,118 @@ func TestSlimMarshallerReadRequestRecursive(t *testing.T) {
 	assert.Equals(t, "Hi_JRÜ€©", subList.ElementAt(3), "Fourth element")
 }
 
+func TestSlimMarshallerDecoderStream(t *testing.T) {
+	// a byte at a time, as if every byte arrives in a separate TCP packet
+	const stream = "000037:[000002:000009:Hi there.:000003:Bye:]000003:bye000020:[000001:000003:[1]:]"
+	decoder := NewDecoder(iotest.OneByteReader(strings.NewReader(stream)))
+	first, err1 := decoder.Decode()
+	assert.Equals(t, nil, err1, "no error on first message")
+	assert.Equals(t, "[Hi there., Bye]", first.(*SlimList).ToString(), "first message is a list")
+	second, err2 := decoder.Decode()
+	assert.Equals(t, nil, err2, "no error on second message")
+	assert.Equals(t, "bye", second, "second message is not lost")
+	third, err3 := decoder.Decode()
+	assert.Equals(t, nil, err3, "no error on third message")
+	assert.Equals(t, "[[1]]", third.(*SlimList).ToString(), "entry starting with [ without list header is a string")
+	_, err4 := decoder.Decode()
+	assert.Equals(t, io.EOF, err4, "end of stream")
+}
+
+func FuzzSlimMarshallerDecode(f *testing.F) {
+	for _, seed := range []string{"000000:", "000003:bye", "000026:[000001:000009:Hi there.:]", "000029:[000001:000012:Hi JRÜ€©:]",
+		"000022:[0000001:00000002:Hi:]", "000027:[000001:000010:[[a, b, c]:]", "000035:[000001:000018:[000001:000001:a;]:]",
+		"000106:[000001:000089:[000004:000017:decisionTable_0_0:000004:make:000015:decisionTable_0:000012:Hi_JRÜ€©:]:]"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		entity, err := NewDecoder(bytes.NewReader(data)).Decode()
+		if err != nil {
+			return
+		}
+		marshalled := Marshal(entity)
+		again, err := ReadRequest(strings.NewReader(marshalled))
+		if err != nil {
+			t.Fatalf("Could not read back %q (from %q): %v", marshalled, data, err)
+		}
+		if remarshalled := Marshal(again); remarshalled != marshalled {
+			t.Fatalf("Round trip of %q gave %q", marshalled, remarshalled)
+		}
+	})
+}
+
+func TestSlimMarshallerEncode(t *testing.T) {
+	list := NewSlimListContaining(SlimList{"Hi there.", NewSlimListContaining(SlimList{"a", ""}), nil, 12})
+	var buffer bytes.Buffer
+	assert.Equals(t, nil, Encode(&buffer, list), "no error")
+	expected := "000083:[000004:000009:Hi there.:000026:[000002:000001:a:000000::]:000005:<nil>:000002:12:]"
+	assert.Equals(t, expected, buffer.String(), "Encoded")
+	assert.Equals(t, expected, Marshal(list), "Marshal gives the same result")
+	long := strings.Repeat("x", 1000000)
+	assert.Equals(t, "1000000:"+long, Marshal(long), "Lengths over 6 digits")
+	many := NewSlimList()
+	for i := 0; i < 1000000; i++ {
+		many.Append("")
+	}
+	assert.Equals(t, "8000010:[1000000:"+strings.Repeat("000000::", 1000000)+"]", Marshal(many), "Counts over 6 digits")
+	err := Encode(failingWriter{}, list)
+	assert.Equals(t, "write failed", err.Error(), "Write error")
+}
+
+type failingWriter struct{}
+
+func (writer failingWriter) Write(data []byte) (int, error) {
+	return 0, fmt.Errorf("write failed")
+}
+
+// wideList creates a list like a query table result: rows of cells with a column name and a value.
+func wideList(rows int, columns int) *SlimList {
+	list := NewSlimList()
+	for row := 0; row < rows; row++ {
+		cells := NewSlimList()
+		for column := 0; column < columns; column++ {
+			cells.Append(NewSlimListContaining(SlimList{fmt.Sprintf("column%v", column), fmt.Sprintf("value %v.%v", row, column)}))
+		}
+		list.Append(cells)
+	}
+	return list
+}
+
+func deepList(depth int) *SlimList {
+	list := NewSlimListContaining(SlimList{"leaf"})
+	for level := 0; level < depth; level++ {
+		list = NewSlimListContaining(SlimList{fmt.Sprintf("level %v", level), list})
+	}
+	return list
+}
+
+func BenchmarkSlimMarshallerMarshalWide(b *testing.B) {
+	list := wideList(5000, 5)
+	for i := 0; i < b.N; i++ {
+		Marshal(list)
+	}
+}
+
+func BenchmarkSlimMarshallerEncodeWide(b *testing.B) {
+	list := wideList(5000, 5)
+	for i := 0; i < b.N; i++ {
+		Encode(io.Discard, list)
+	}
+}
+
+func BenchmarkSlimMarshallerMarshalDeep(b *testing.B) {
+	list := deepList(500)
+	for i := 0; i < b.N; i++ {
+		Marshal(list)
+	}
+}
+
+func BenchmarkSlimMarshallerToStringWide(b *testing.B) {
+	list := wideList(5000, 5)
+	for i := 0; i < b.N; i++ {
+		list.ToString()
+	}
+}
+
 func TestSlimMarshallerConvertNull(t *testing.T) {
 	assert.Equals(t, "null", convertNull(nil), "nil")
 	assert.Equals(t, "a", convertNull("a"), "non-null")
@@ -12,39 +12,25 @@
 package slimlog
 
 import (
-	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 )
 
+// Console output is shown by FitNesse; diagnostic messages go to the (structured) logger that is injected where needed.
 var (
-	logFileName = flag.String("log", "slim4go.log", "Slim4Go log file name")
-	// Info logs informational messages.
-	Info *log.Logger
-	// Error logs errors.
-	Error *log.Logger
-	// Trace logs debugging info.
-	Trace *log.Logger
+	// Info writes informational messages to the console.
+	Info = log.New(os.Stdout, "", 0)
+	// Error writes errors to the console.
+	Error = log.New(os.Stderr, "", 0)
 )
 
-// making sure Trace works in tests.
-var _ = func() error {
-	if Trace == nil {
-		Trace = log.New(os.Stderr, "Trace:", log.Ldate|log.Ltime|log.Lshortfile)
-	}
-	return nil
-}()
-
-// Initialize sets up the loggers.
+// Initialize sets up the console output. When Slim uses pipes, standard output carries the protocol,
+// so we use standard error with the prefixes that FitNesse recognizes.
 func Initialize(slimUsesPipe bool) {
-	logFile, logErr := os.OpenFile(*logFileName, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
-	if logErr != nil {
-		fmt.Println("Could not open", *logFile, ". Slim4Go start Failed")
-		os.Exit(1)
-	}
-	Trace = log.New(logFile, "", log.Ldate|log.Ltime|log.Lshortfile)
-
 	if slimUsesPipe {
 		Info = log.New(os.Stderr, "SOUT :", 0)
 		Error = log.New(os.Stderr, "SERR :", 0)
@@ -52,5 +38,74 @@ func Initialize(slimUsesPipe bool) {
 		Info = log.New(os.Stdout, "", 0)
 		Error = log.New(os.Stderr, "", 0)
 	}
-	Trace.Println("Setup trace for production")
+}
+
+// Options specifies where and how diagnostic messages are logged.
+type Options struct {
+	// Destination is a file name, or one of stdout, stderr and none
+	Destination string
+	// Format is text or json
+	Format string
+	// Level is the minimum level of the messages to log
+	Level slog.Level
+}
+
+// Discard returns a logger that doesn't log anything. It is the default for components that don't get a logger injected.
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// New creates a logger as specified by the options. If the destination is a file, messages are appended to it.
+func New(options Options) (*slog.Logger, error) {
+	writer, err := open(options.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if writer == nil {
+		// Still check the format, so a configuration error shows up even if nothing is logged.
+		if _, err := NewLogger(io.Discard, options.Format, options.Level); err != nil {
+			return nil, err
+		}
+		return Discard(), nil
+	}
+	return NewLogger(writer, options.Format, options.Level)
+}
+
+// NewLogger creates a logger writing to the writer, in text or json format.
+func NewLogger(writer io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(writer, handlerOptions)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(writer, handlerOptions)), nil
+	default:
+		return nil, fmt.Errorf("Unknown log format '%v'. Expected text or json", format)
+	}
+}
+
+// ParseLevel converts the name of a level (debug, info, warn or error) into a level.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("Unknown log level '%v'. Expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// open returns the writer for the destination, or nil if nothing needs to be logged.
+func open(destination string) (io.Writer, error) {
+	switch strings.ToLower(destination) {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	logFile, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open log file: %v", err)
+	}
+	return logFile, nil
 }
@@ -0,0 +1,79 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestSlimLogParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	assert.Equals(t, nil, err, "No error for debug")
+	assert.Equals(t, slog.LevelDebug, level, "Debug")
+	level, _ = ParseLevel("WARN")
+	assert.Equals(t, slog.LevelWarn, level, "Warn (case insensitive)")
+	_, err = ParseLevel("verbose")
+	assert.Equals(t, "Unknown log level 'verbose'. Expected debug, info, warn or error", err.Error(), "Unknown level")
+}
+
+func TestSlimLogNewLogger(t *testing.T) {
+	var buffer bytes.Buffer
+	logger, err := NewLogger(&buffer, "json", slog.LevelInfo)
+	assert.Equals(t, nil, err, "No error for json")
+	logger.Debug("Hidden")
+	logger.Info("Shown", "id", "call_1")
+	var entry map[string]interface{}
+	assert.Equals(t, nil, json.Unmarshal(buffer.Bytes(), &entry), "One JSON entry (debug is filtered out)")
+	assert.Equals(t, "Shown", entry["msg"], "Message")
+	assert.Equals(t, "call_1", entry["id"], "Field")
+
+	buffer.Reset()
+	logger, _ = NewLogger(&buffer, "text", slog.LevelDebug)
+	logger.Debug("Shown", "id", "call_2")
+	assert.IsTrue(t, strings.Contains(buffer.String(), "level=DEBUG msg=Shown id=call_2"), "Text format")
+
+	_, err = NewLogger(&buffer, "xml", slog.LevelInfo)
+	assert.Equals(t, "Unknown log format 'xml'. Expected text or json", err.Error(), "Unknown format")
+}
+
+func TestSlimLogNew(t *testing.T) {
+	logger, err := New(Options{Destination: "none"})
+	assert.Equals(t, nil, err, "No error for none")
+	assert.IsTrue(t, !logger.Enabled(context.Background(), slog.LevelError), "Nothing is logged with destination none")
+
+	fileName := filepath.Join(t.TempDir(), "test.log")
+	logger, err = New(Options{Destination: fileName, Format: "text", Level: slog.LevelInfo})
+	assert.Equals(t, nil, err, "No error for file")
+	logger.Info("To file")
+	content, _ := os.ReadFile(fileName)
+	assert.IsTrue(t, strings.Contains(string(content), "msg=\"To file\""), "Message written to file")
+
+	_, err = New(Options{Destination: filepath.Join(t.TempDir(), "missing", "test.log")})
+	assert.IsTrue(t, strings.HasPrefix(err.Error(), "Could not open log file: "), "Error for wrong folder")
+}
+
+func TestSlimLogInitialize(t *testing.T) {
+	Initialize(true)
+	assert.Equals(t, "SOUT :", Info.Prefix(), "Info prefix with pipes")
+	assert.Equals(t, "SERR :", Error.Prefix(), "Error prefix with pipes")
+	Initialize(false)
+	assert.Equals(t, "", Info.Prefix(), "No info prefix with sockets")
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimmetrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics are collected per fixture and method, so it is clear which fixture methods make a suite slow.
+// Calls are attributed to the fixture that the instance was made from. Calls on instances that were not made
+// in the session (e.g. libraries) are attributed to the instance name.
+
+// Definitions and constructors
+
+// Outcome is the result type of an instruction.
+type Outcome int
+
+const (
+	// Success means that the instruction returned a value.
+	Success Outcome = iota
+	// Exception means that the instruction returned an exception.
+	Exception
+	// Timeout means that the instruction didn't finish in time.
+	Timeout
+)
+
+// Constructor is the method name used for making instances.
+const Constructor = "(constructor)"
+
+// Measurement contains the metrics of a fixture method.
+type Measurement struct {
+	Fixture    string        `json:"fixture"`
+	Method     string        `json:"method"`
+	Count      int           `json:"count"`
+	Exceptions int           `json:"exceptions"`
+	Timeouts   int           `json:"timeouts"`
+	Total      time.Duration `json:"-"`
+	Max        time.Duration `json:"-"`
+}
+
+type measurementKey struct {
+	fixture string
+	method  string
+}
+
+// Metrics collects the metrics of the executed instructions. It can be used concurrently.
+type Metrics struct {
+	mutex        sync.Mutex
+	measurements map[measurementKey]*Measurement
+	instances    map[string]string
+}
+
+// NewMetrics creates an empty set of metrics.
+func NewMetrics() *Metrics {
+	metrics := new(Metrics)
+	metrics.measurements = make(map[measurementKey]*Measurement)
+	metrics.instances = make(map[string]string)
+	return metrics
+}
+
+// Methods
+
+// Call records the execution of a method on an instance.
+func (metrics *Metrics) Call(instanceName string, methodName string, duration time.Duration, outcome Outcome) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	fixtureName, ok := metrics.instances[instanceName]
+	if !ok {
+		fixtureName = instanceName
+	}
+	metrics.record(fixtureName, methodName, duration, outcome)
+}
+
+// Make records making an instance of a fixture. If that succeeded, later calls on the instance are attributed to the fixture.
+func (metrics *Metrics) Make(instanceName string, fixtureName string, duration time.Duration, outcome Outcome) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	if outcome == Success {
+		metrics.instances[instanceName] = fixtureName
+	}
+	metrics.record(fixtureName, Constructor, duration, outcome)
+}
+
+// Mean returns the average duration of the instructions.
+func (measurement *Measurement) Mean() time.Duration {
+	if measurement.Count == 0 {
+		return 0
+	}
+	return measurement.Total / time.Duration(measurement.Count)
+}
+
+func (metrics *Metrics) record(fixtureName string, methodName string, duration time.Duration, outcome Outcome) {
+	key := measurementKey{fixtureName, methodName}
+	measurement, ok := metrics.measurements[key]
+	if !ok {
+		measurement = &Measurement{Fixture: fixtureName, Method: methodName}
+		metrics.measurements[key] = measurement
+	}
+	measurement.Count++
+	measurement.Total += duration
+	if duration > measurement.Max {
+		measurement.Max = duration
+	}
+	switch outcome {
+	case Exception:
+		measurement.Exceptions++
+	case Timeout:
+		measurement.Timeouts++
+	}
+}
+
+// Summary returns (copies of) the measurements, the slowest (in total) first.
+func (metrics *Metrics) Summary() []Measurement {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	summary := make([]Measurement, 0, len(metrics.measurements))
+	for _, measurement := range metrics.measurements {
+		summary = append(summary, *measurement)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Total != summary[j].Total {
+			return summary[i].Total > summary[j].Total
+		}
+		if summary[i].Fixture != summary[j].Fixture {
+			return summary[i].Fixture < summary[j].Fixture
+		}
+		return summary[i].Method < summary[j].Method
+	})
+	return summary
+}
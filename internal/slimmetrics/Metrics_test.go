@@ -0,0 +1,67 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimmetrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func sampleMetrics() *Metrics {
+	metrics := NewMetrics()
+	metrics.Make("conv", "TemperatureConverter", 2*time.Millisecond, Success)
+	metrics.Call("conv", "ConvertTo", 10*time.Millisecond, Success)
+	metrics.Call("conv", "ConvertTo", 30*time.Millisecond, Exception)
+	metrics.Call("conv", "Wait", 50*time.Millisecond, Timeout)
+	metrics.Call("library1", "Echo", time.Millisecond, Success)
+	metrics.Make("broken", "Broken", time.Millisecond, Exception)
+	return metrics
+}
+
+func TestMetricsSummary(t *testing.T) {
+	summary := sampleMetrics().Summary()
+	assert.Equals(t, 5, len(summary), "Five fixture methods")
+	assert.Equals(t, "Wait", summary[0].Method, "Slowest first")
+	convertTo := summary[1]
+	assert.Equals(t, "TemperatureConverter", convertTo.Fixture, "Call attributed to the fixture of the instance")
+	assert.Equals(t, "ConvertTo", convertTo.Method, "Method")
+	assert.Equals(t, 2, convertTo.Count, "Count")
+	assert.Equals(t, 1, convertTo.Exceptions, "Exceptions")
+	assert.Equals(t, 0, convertTo.Timeouts, "No timeouts")
+	assert.Equals(t, 40*time.Millisecond, convertTo.Total, "Total")
+	assert.Equals(t, 20*time.Millisecond, convertTo.Mean(), "Mean")
+	assert.Equals(t, 30*time.Millisecond, convertTo.Max, "Max")
+	assert.Equals(t, 1, summary[0].Timeouts, "Timeout")
+	assert.Equals(t, Constructor, summary[2].Method, "Constructor")
+	assert.Equals(t, "Broken", summary[3].Fixture, "Failed make is measured, ordered by fixture if durations are equal")
+	assert.Equals(t, "library1", summary[4].Fixture, "Unknown instance is used as fixture")
+	assert.Equals(t, time.Duration(0), (&Measurement{}).Mean(), "Mean without instructions")
+}
+
+func TestMetricsConcurrentUse(t *testing.T) {
+	metrics := NewMetrics()
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := 0; j < 100; j++ {
+				metrics.Call("instance", "method", time.Microsecond, Success)
+			}
+		}()
+	}
+	waitGroup.Wait()
+	assert.Equals(t, 1000, metrics.Summary()[0].Count, "All calls counted")
+}
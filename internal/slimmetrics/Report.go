@@ -0,0 +1,140 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimmetrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The summary can be written as JSON or CSV (e.g. at the end of a session), and in the Prometheus text format.
+
+// Definitions
+
+// reportEntry is a measurement as reported in JSON, with the durations in seconds.
+type reportEntry struct {
+	Measurement
+	TotalSeconds float64 `json:"totalSeconds"`
+	MeanSeconds  float64 `json:"meanSeconds"`
+	MaxSeconds   float64 `json:"maxSeconds"`
+}
+
+// Helpers
+
+// FormatOf returns the summary format for a file name: csv for .csv files, and json otherwise.
+func FormatOf(fileName string) string {
+	if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func seconds(measurement Measurement) []string {
+	return []string{
+		strconv.FormatFloat(measurement.Total.Seconds(), 'f', -1, 64),
+		strconv.FormatFloat(measurement.Mean().Seconds(), 'f', -1, 64),
+		strconv.FormatFloat(measurement.Max.Seconds(), 'f', -1, 64),
+	}
+}
+
+// Methods
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus text format.
+func (metrics *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		metrics.WritePrometheus(writer)
+	})
+}
+
+// Write writes the summary in the specified format (json or csv).
+func (metrics *Metrics) Write(writer io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return metrics.WriteJSON(writer)
+	case "csv":
+		return metrics.WriteCSV(writer)
+	default:
+		return fmt.Errorf("Unknown metrics format '%v'. Expected json or csv", format)
+	}
+}
+
+// WriteCSV writes the summary as CSV, with a header line. Durations are in seconds.
+func (metrics *Metrics) WriteCSV(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"fixture", "method", "count", "exceptions", "timeouts", "totalSeconds", "meanSeconds", "maxSeconds"})
+	for _, measurement := range metrics.Summary() {
+		counts := []string{strconv.Itoa(measurement.Count), strconv.Itoa(measurement.Exceptions), strconv.Itoa(measurement.Timeouts)}
+		record := append(append([]string{measurement.Fixture, measurement.Method}, counts...), seconds(measurement)...)
+		csvWriter.Write(record)
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteJSON writes the summary as a JSON array. Durations are in seconds.
+func (metrics *Metrics) WriteJSON(writer io.Writer) error {
+	entries := []reportEntry{}
+	for _, measurement := range metrics.Summary() {
+		entries = append(entries, reportEntry{
+			Measurement:  measurement,
+			TotalSeconds: measurement.Total.Seconds(),
+			MeanSeconds:  measurement.Mean().Seconds(),
+			MaxSeconds:   measurement.Max.Seconds(),
+		})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (metrics *Metrics) WritePrometheus(writer io.Writer) error {
+	summary := metrics.Summary()
+	families := []struct {
+		name  string
+		kind  string
+		help  string
+		value func(measurement Measurement) string
+	}{
+		{"slim4go_instructions_total", "counter", "Number of executed instructions.",
+			func(measurement Measurement) string { return strconv.Itoa(measurement.Count) }},
+		{"slim4go_instruction_exceptions_total", "counter", "Number of instructions that returned an exception.",
+			func(measurement Measurement) string { return strconv.Itoa(measurement.Exceptions) }},
+		{"slim4go_instruction_timeouts_total", "counter", "Number of instructions that timed out.",
+			func(measurement Measurement) string { return strconv.Itoa(measurement.Timeouts) }},
+		{"slim4go_instruction_duration_seconds_total", "counter", "Total duration of the instructions.",
+			func(measurement Measurement) string { return seconds(measurement)[0] }},
+		{"slim4go_instruction_duration_seconds_max", "gauge", "Maximum duration of an instruction.",
+			func(measurement Measurement) string { return seconds(measurement)[2] }},
+	}
+	var builder strings.Builder
+	for _, family := range families {
+		fmt.Fprintf(&builder, "# HELP %v %v\n# TYPE %v %v\n", family.name, family.help, family.name, family.kind)
+		for _, measurement := range summary {
+			fmt.Fprintf(&builder, "%v{fixture=\"%v\",method=\"%v\"} %v\n",
+				family.name, escapeLabel(measurement.Fixture), escapeLabel(measurement.Method), family.value(measurement))
+		}
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimmetrics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestReportFormatOf(t *testing.T) {
+	assert.Equals(t, "csv", FormatOf("metrics.CSV"), "CSV")
+	assert.Equals(t, "json", FormatOf("metrics.json"), "JSON")
+	assert.Equals(t, "json", FormatOf("metrics"), "JSON by default")
+}
+
+func TestReportWriteCSV(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Make("conv", "TemperatureConverter", 2*time.Millisecond, Success)
+	metrics.Call("conv", "ConvertTo", 10*time.Millisecond, Success)
+	metrics.Call("conv", "ConvertTo", 30*time.Millisecond, Exception)
+	var output strings.Builder
+	assert.Equals(t, nil, metrics.Write(&output, "csv"), "Write CSV without error")
+	expected := "fixture,method,count,exceptions,timeouts,totalSeconds,meanSeconds,maxSeconds\n" +
+		"TemperatureConverter,ConvertTo,2,1,0,0.04,0.02,0.03\n" +
+		"TemperatureConverter,(constructor),1,0,0,0.002,0.002,0.002\n"
+	assert.Equals(t, expected, output.String(), "CSV")
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	var output strings.Builder
+	assert.Equals(t, nil, sampleMetrics().Write(&output, "JSON"), "Write JSON without error")
+	var entries []map[string]interface{}
+	assert.Equals(t, nil, json.Unmarshal([]byte(output.String()), &entries), "Valid JSON")
+	assert.Equals(t, 5, len(entries), "Five entries")
+	assert.Equals(t, "TemperatureConverter", entries[1]["fixture"], "Fixture")
+	assert.Equals(t, "ConvertTo", entries[1]["method"], "Method")
+	assert.Equals(t, 2.0, entries[1]["count"], "Count")
+	assert.Equals(t, 1.0, entries[1]["exceptions"], "Exceptions")
+	assert.Equals(t, 0.04, entries[1]["totalSeconds"], "Total")
+	assert.Equals(t, 0.02, entries[1]["meanSeconds"], "Mean")
+	assert.Equals(t, 0.03, entries[1]["maxSeconds"], "Max")
+
+	output.Reset()
+	NewMetrics().WriteJSON(&output)
+	assert.Equals(t, "[]\n", output.String(), "No measurements")
+	assert.Equals(t, "Unknown metrics format 'xml'. Expected json or csv", sampleMetrics().Write(&output, "xml").Error(), "Unknown format")
+}
+
+func TestReportPrometheusHandler(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Make("quote", `Say "hi"`, time.Millisecond, Success)
+	metrics.Call("quote", "Speak", 500*time.Millisecond, Timeout)
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+	response, err := http.Get(server.URL + "/metrics")
+	assert.Equals(t, nil, err, "Get without error")
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	assert.Equals(t, http.StatusOK, response.StatusCode, "Status")
+	assert.IsTrue(t, strings.HasPrefix(response.Header.Get("Content-Type"), "text/plain"), "Content type")
+	text := string(body)
+	assert.IsTrue(t, strings.HasPrefix(text, "# HELP slim4go_instructions_total Number of executed instructions.\n"+
+		"# TYPE slim4go_instructions_total counter\n"+
+		`slim4go_instructions_total{fixture="Say \"hi\"",method="Speak"} 1`+"\n"), "Counter with escaped label")
+	assert.IsTrue(t, strings.Contains(text, `slim4go_instruction_timeouts_total{fixture="Say \"hi\"",method="Speak"} 1`+"\n"), "Timeouts")
+	assert.IsTrue(t, strings.Contains(text, "# TYPE slim4go_instruction_duration_seconds_max gauge\n"), "Gauge")
+	assert.IsTrue(t, strings.Contains(text, `slim4go_instruction_duration_seconds_total{fixture="Say \"hi\"",method="Speak"} 0.5`+"\n"), "Duration")
+}
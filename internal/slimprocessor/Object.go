@@ -21,6 +21,7 @@ import (
 	"github.com/essenius/slim4go/internal/interfaces"
 	"github.com/essenius/slim4go/internal/slimentity"
 	"github.com/essenius/slim4go/internal/slimprotocol"
+	"github.com/essenius/slim4go/slimfixture"
 )
 
 // Definitions and constructors for object. An object is an instantiated fixture.
@@ -29,6 +30,10 @@ import (
 type object struct {
 	instanceValue reflect.Value
 	parser        interfaces.Parser
+	// policy restricts the members that can be used. If nil, all members can be used (e.g. for conversions).
+	policy interfaces.AccessPolicy
+	// stubs call methods without reflection. If nil, all methods are called via reflection.
+	stubs interfaces.StubSource
 }
 
 // NewObject creates a new object instance.
@@ -83,20 +88,94 @@ func (anObject *object) instance() interface{} {
 	return anObject.instanceValue.Interface()
 }
 
-// InvokeMember invokes a function or sets/gets a field.
+// HasMember returns whether InvokeMember can find a method or field with the given number of arguments.
+func (anObject *object) HasMember(memberName string, argCount int) bool {
+	names := memberNamesFor(strings.Title(memberName), argCount)
+	for _, name := range names {
+		if method := anObject.instanceValue.MethodByName(name); method.IsValid() {
+			return true
+		}
+	}
+	if argCount > 1 {
+		return false
+	}
+	structValue := anObject.instanceValue
+	for structValue.Kind() == reflect.Ptr && !structValue.IsNil() {
+		structValue = structValue.Elem()
+	}
+	if structValue.Kind() != reflect.Struct {
+		return false
+	}
+	for _, name := range names {
+		if structValue.FieldByName(name).IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// callStub calls a method via its stub, after replacing the symbols in the arguments.
+func (anObject *object) callStub(stub slimfixture.Stub, args *slimentity.SlimList) (returnEntity slimentity.SlimEntity, err error) {
+	arguments := []string{}
+	for _, arg := range slimentity.ToSlice(args) {
+		resolvedArg, err := anObject.parser.Parse(arg, reflect.TypeOf(""))
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, resolvedArg.(string))
+	}
+	defer func() {
+		if panicData := recover(); panicData != nil {
+			returnEntity = nil
+			err = apperrors.NewPanicError(panicData, "slimprocessor.(*object).callStub")
+		}
+	}()
+	results, err := stub(anObject.instance(), arguments)
+	if err != nil {
+		return nil, err
+	}
+	returnValues := []reflect.Value{}
+	for _, result := range results {
+		returnValues = append(returnValues, reflect.ValueOf(result))
+	}
+	return slimentity.TransformCallResult(returnValues), nil
+}
+
+// checkAccess returns an AccessDeniedError if the policy doesn't allow using the member.
+func (anObject *object) checkAccess(memberName string, access interfaces.Access) error {
+	if anObject.policy == nil {
+		return nil
+	}
+	return anObject.policy.CheckAccess(anObject.instanceValue.Type(), memberName, access)
+}
+
+// InvokeMember invokes a function or sets/gets a field, if the policy allows that.
 func (anObject *object) InvokeMember(memberName string, args *slimentity.SlimList) (slimentity.SlimEntity, error) {
 	// We can only use exported methods or fields, which start with a capital.
 	// Since in the Java convention that FitNesse uses, methods are in camelCase, we need to capitalize the first letter.
 	names := memberNamesFor(strings.Title(memberName), args.Length())
 	for _, name := range names {
+		if stub, ok := anObject.stubFor(name); ok {
+			if err := anObject.checkAccess(name, interfaces.MethodCall); err != nil {
+				return nil, err
+			}
+			return anObject.callStub(stub, args)
+		}
 		method := anObject.instanceValue.MethodByName(name)
 		if method.IsValid() {
+			if err := anObject.checkAccess(name, interfaces.MethodCall); err != nil {
+				return nil, err
+			}
 			return anObject.parser.CallFunction(method, slimentity.ToSlice(args))
 		}
 	}
-	if result, err := anObject.tryField(names, args); err == nil {
+	result, err := anObject.tryField(names, args)
+	if err == nil {
 		return result, nil
 	}
+	if _, ok := err.(*apperrors.AccessDeniedError); ok {
+		return nil, err
+	}
 	return "", &apperrors.NotFoundError{Entity: "member", Description: memberName}
 }
 
@@ -109,6 +188,13 @@ func (anObject *object) Serialize() string {
 	return anObject.instanceValue.Type().String()
 }
 
+func (anObject *object) stubFor(methodName string) (slimfixture.Stub, bool) {
+	if anObject.stubs == nil {
+		return nil, false
+	}
+	return anObject.stubs.StubFor(anObject.instanceValue.Type(), methodName)
+}
+
 func (anObject *object) setField(field reflect.Value, value slimentity.SlimEntity, name string) (slimentity.SlimEntity, error) {
 	if field.CanSet() {
 		fieldType := field.Type()
@@ -124,6 +210,7 @@ func (anObject *object) setField(field reflect.Value, value slimentity.SlimEntit
 func (anObject *object) tryField(fieldNames []string, args *slimentity.SlimList) (slimentity.SlimEntity, error) {
 	if anObject.instanceValue.Kind() == reflect.Ptr {
 		elemObject := newObject(anObject.instanceValue.Elem(), anObject.parser)
+		elemObject.policy = anObject.policy
 		return elemObject.tryField(fieldNames, args)
 	}
 	if anObject.instanceValue.Kind() != reflect.Struct {
@@ -134,8 +221,14 @@ func (anObject *object) tryField(fieldNames []string, args *slimentity.SlimList)
 		if field.IsValid() {
 			switch args.Length() {
 			case 0:
+				if err := anObject.checkAccess(name, interfaces.FieldGet); err != nil {
+					return nil, err
+				}
 				return getField(field, name)
 			case 1:
+				if err := anObject.checkAccess(name, interfaces.FieldSet); err != nil {
+					return nil, err
+				}
 				return anObject.setField(field, args.ElementAt(0), name)
 			}
 		}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/essenius/slim4go/internal/apperrors"
 	"github.com/essenius/slim4go/internal/interfaces"
@@ -24,15 +25,25 @@ import (
 type objectMap map[string]*object
 
 // ObjectHandler contains the instantiated fixtures (i.e. objects) and provides functions to handle them.
+// It keeps track of the order in which instances were created, so that libraries can be searched in a predictable order.
+// It is safe for concurrent use (e.g. by a fixture that timed out and is still running). Constructors and members
+// of instances are invoked without holding the lock. Replacing an instance (via Add, Set or a make with an existing name)
+// doesn't affect calls that are in flight: they finish on the instance they started with. Calls after the replacement
+// use the new instance.
 type ObjectHandler struct {
-	objectMap *objectMap
-	parser    interfaces.Parser
+	mutex         sync.RWMutex
+	objectMap     *objectMap
+	creationOrder []string
+	parser        interfaces.Parser
+	policy        interfaces.AccessPolicy
+	stubs         interfaces.StubSource
 }
 
 // NewObjectHandler creates a new ObjectCollection.
 func NewObjectHandler(parser interfaces.Parser) *ObjectHandler {
 	handler := new(ObjectHandler)
 	handler.objectMap = newObjectMap()
+	handler.creationOrder = []string{}
 	handler.parser = parser
 	return handler
 }
@@ -53,7 +64,7 @@ func (handler *ObjectHandler) Add(instanceName string, value interface{}) {
 func (handler *ObjectHandler) AddObjectByConstructor(instanceName string, constructor reflect.Value, args []string) error {
 	anObject, err := handler.constructObject(constructor, args)
 	if err == nil {
-		(*handler.objectMap)[instanceName] = anObject
+		handler.store(instanceName, anObject)
 		return nil
 	}
 	return err
@@ -70,7 +81,8 @@ func (handler *ObjectHandler) Deserialize(objectType reflect.Type, input string)
 		parseType = objectType
 	}
 	instance := reflect.New(parseType).Interface()
-	_, err := handler.InvokeMemberOn(instance, "Parse", slimentity.NewSlimListContaining([]slimentity.SlimEntity{input}))
+	// Parse is used for a conversion, so the access policy doesn't apply
+	_, err := handler.newObject(reflect.ValueOf(instance)).InvokeMember("Parse", slimentity.NewSlimListContaining([]slimentity.SlimEntity{input}))
 	if _, ok := err.(*apperrors.NotFoundError); ok {
 		return nil, toErrorf("No method Parse found for type '%v'", reflect.TypeOf(instance))
 	}
@@ -94,14 +106,23 @@ func (handler *ObjectHandler) Get(instanceName string) interface{} {
 	return object.instance()
 }
 
+// HasMemberOn returns whether a member (method or field) can be invoked on an instance with the given number of arguments.
+func (handler *ObjectHandler) HasMemberOn(instance interface{}, memberName string, argCount int) bool {
+	return handler.newObject(reflect.ValueOf(instance)).HasMember(memberName, argCount)
+}
+
 // Length returns the number of items in the collection.
 func (handler *ObjectHandler) Length() int {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
 	return len(*handler.objectMap)
 }
 
-// InvokeMemberOn finds an instance, and invokes a member on it with the given parameters.
+// InvokeMemberOn finds an instance, and invokes a member on it with the given parameters, if the access policy allows that.
 func (handler *ObjectHandler) InvokeMemberOn(instance interface{}, memberName string, args *slimentity.SlimList) (slimentity.SlimEntity, error) {
 	anObject := handler.newObject(reflect.ValueOf(instance))
+	anObject.policy = handler.policy
+	anObject.stubs = handler.stubs
 	result, err := anObject.InvokeMember(memberName, args)
 	if err != nil {
 		return nil, err
@@ -109,38 +130,58 @@ func (handler *ObjectHandler) InvokeMemberOn(instance interface{}, memberName st
 	return result, nil
 }
 
-// InstancesWithPrefix returns all instances of which the name starts with the prefix.
+// InstanceNames returns the names of all instances, in order of creation.
+func (handler *ObjectHandler) InstanceNames() []string {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
+	return append([]string{}, handler.creationOrder...)
+}
+
+// InstancesWithPrefix returns all instances of which the name starts with the prefix, in order of creation.
 func (handler *ObjectHandler) InstancesWithPrefix(prefix string) []interface{} {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
 	result := make([]interface{}, 0)
-	for instanceName, instance := range *handler.objectMap {
+	for _, instanceName := range handler.creationOrder {
 		if strings.HasPrefix(instanceName, prefix) {
-			result = append(result, instance.instance())
+			result = append(result, (*handler.objectMap)[instanceName].instance())
 		}
 	}
 	return result
 }
 
+// SetAccessPolicy specifies the policy that restricts the members InvokeMemberOn can use.
+func (handler *ObjectHandler) SetAccessPolicy(policy interfaces.AccessPolicy) {
+	handler.policy = policy
+}
+
+// SetStubSource specifies where InvokeMemberOn finds the stubs that call methods without reflection.
+func (handler *ObjectHandler) SetStubSource(stubs interfaces.StubSource) {
+	handler.stubs = stubs
+}
+
 // Serialize returns a serialized representation of an instantiated object (i.e. call its ToString method).
 func (handler *ObjectHandler) Serialize(instance interface{}) string {
 	anObject := handler.newObject(reflect.ValueOf(instance))
 	return anObject.Serialize()
 }
 
-// Set sets an existing enty in the collection to a new value.
+// Set replaces the instance of an existing entry in the collection.
 func (handler *ObjectHandler) Set(instanceName string, instance interface{}) error {
-	anObject := handler.objectNamed(instanceName)
-	if anObject == nil {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	if _, ok := (*handler.objectMap)[instanceName]; !ok {
 		return fmt.Errorf("instance not found")
 	}
-	anObject.instanceValue = reflect.ValueOf(instance)
+	// Replace rather than update the object, so objects that were already retrieved don't change underneath their users.
+	(*handler.objectMap)[instanceName] = handler.newObject(reflect.ValueOf(instance))
 	return nil
 }
 
 // Other methods
 
 func (handler *ObjectHandler) addObject(instanceName string, instance interface{}) {
-	anObject := handler.newObject(reflect.ValueOf(instance))
-	(*handler.objectMap)[instanceName] = anObject
+	handler.store(instanceName, handler.newObject(reflect.ValueOf(instance)))
 }
 
 func (handler *ObjectHandler) constructObject(constructor reflect.Value, args []string) (*object, error) {
@@ -156,6 +197,22 @@ func (handler *ObjectHandler) newObject(value reflect.Value) *object {
 }
 
 func (handler *ObjectHandler) objectNamed(instanceName string) *object {
+	handler.mutex.RLock()
+	defer handler.mutex.RUnlock()
 	anObject, _ := (*handler.objectMap)[instanceName]
 	return anObject
 }
+
+// store adds or replaces an object. A replaced object counts as most recently created.
+func (handler *ObjectHandler) store(instanceName string, anObject *object) {
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	for i, name := range handler.creationOrder {
+		if name == instanceName {
+			handler.creationOrder = append(handler.creationOrder[:i], handler.creationOrder[i+1:]...)
+			break
+		}
+	}
+	handler.creationOrder = append(handler.creationOrder, instanceName)
+	(*handler.objectMap)[instanceName] = anObject
+}
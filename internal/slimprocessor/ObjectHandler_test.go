@@ -12,7 +12,9 @@
 package slimprocessor
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/essenius/slim4go/internal/assert"
@@ -30,6 +32,27 @@ func TestObjectHandlerWithPrefix(t *testing.T) {
 	assert.Equals(t, 2, len(libraries), "Length OK")
 	assert.Equals(t, 3, libraries[0], "entry 1 exists")
 	assert.Equals(t, 5, libraries[1], "entry 2 exists")
+	objects.Add("library1", 6)
+	libraries = objects.InstancesWithPrefix("library")
+	assert.Equals(t, 2, len(libraries), "Length OK after replacing entry")
+	assert.Equals(t, 5, libraries[0], "entry 2 is now the oldest")
+	assert.Equals(t, 6, libraries[1], "replaced entry is the most recent")
+	names := objects.InstanceNames()
+	assert.Equals(t, 5, len(names), "All instance names")
+	assert.Equals(t, "test1", names[0], "Oldest instance first")
+	assert.Equals(t, "library1", names[4], "Replaced instance last")
+}
+
+func TestObjectHandlerHasMemberOn(t *testing.T) {
+	objects := NewObjectHandler(nil)
+	messenger := NewMessenger()
+	assert.IsTrue(t, objects.HasMemberOn(messenger, "message", 0), "method")
+	assert.IsTrue(t, objects.HasMemberOn(messenger, "message", 1), "setter")
+	assert.IsTrue(t, objects.HasMemberOn(messenger, "messageField", 0), "field get")
+	assert.IsTrue(t, objects.HasMemberOn(messenger, "setMessageField", 1), "field set")
+	assert.IsTrue(t, !objects.HasMemberOn(messenger, "messageField", 2), "field with two arguments")
+	assert.IsTrue(t, !objects.HasMemberOn(messenger, "nonexisting", 0), "nonexisting member")
+	assert.IsTrue(t, !objects.HasMemberOn(5, "message", 0), "member on non-struct")
 }
 
 func TestObjectHandler(t *testing.T) {
@@ -39,6 +62,42 @@ func TestObjectHandler(t *testing.T) {
 	assert.Equals(t, "instance not found", err.Error(), "Error message OK")
 }
 
+func TestObjectHandlerConcurrentUse(t *testing.T) {
+	objects := NewObjectHandler(nil)
+	objects.Add("library1", 0)
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := 0; j < 100; j++ {
+				objects.Add(fmt.Sprintf("instance%v", i), j)
+				objects.Set("library1", j)
+				objects.Get("library1")
+				objects.InstancesWithPrefix("library")
+				objects.InstanceNames()
+				objects.Length()
+			}
+		}()
+	}
+	waitGroup.Wait()
+	assert.Equals(t, 11, objects.Length(), "All instances added")
+	assert.Equals(t, 99, objects.Get("instance9"), "Last value stored")
+}
+
+func TestObjectHandlerReplaceInFlight(t *testing.T) {
+	objects := NewObjectHandler(nil)
+	original := NewMessenger()
+	objects.Add("messenger", original)
+	inFlight := objects.Get("messenger").(*Messenger)
+	replacement := NewMessenger()
+	assert.Equals(t, nil, objects.Set("messenger", replacement), "Set without error")
+	inFlight.SetMessage("late")
+	assert.Equals(t, "late", original.Message(), "A call in flight finishes on the original instance")
+	assert.Equals(t, replacement, objects.Get("messenger"), "Calls after the replacement get the new instance")
+	assert.Equals(t, "", replacement.Message(), "Replacement not affected by the call in flight")
+}
+
 func TestObjectHandlerSerialize(t *testing.T) {
 	parser := NewParser(NewSymbolTable())
 	objectHandler := NewObjectHandler(parser)
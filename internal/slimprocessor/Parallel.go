@@ -0,0 +1,163 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimprocessor
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Instructions on different instances don't share state, unless they use symbols or libraries. So the make and call
+// instructions on an instance form a chain that is executed in order, while chains of different instances are executed
+// in parallel. All other instructions (imports, assignments, calls that use symbols, calls on libraries and on the
+// script table actor) are barriers: they start when all instructions before them are done, and the instructions after
+// them start when they are done. A call on an instance that doesn't have the method falls back to the libraries, so
+// that is a barrier too. Whether the instance has the method can only be checked once it exists, so a call on an
+// instance made in the same run of parallel instructions ends that run. Instances can share an object, e.g. after
+// make a $object and make b $object, so the chains of instances with the same object are joined. The results are always
+// returned in the order of the instructions.
+
+// Helpers
+
+// chainOf returns the instance an instruction works on if it can be executed in parallel with instructions on other
+// instances, or an empty string if it is a barrier.
+func chainOf(instruction slimentity.SlimEntity) string {
+	instructionList, ok := instruction.(*slimentity.SlimList)
+	if !ok {
+		return ""
+	}
+	values, ok := stringsAt(instructionList, 1, 2)
+	if !ok || (values[0] != "make" && values[0] != "call") {
+		return ""
+	}
+	instanceName := values[1]
+	if instanceName == "" || instanceName == "scriptTableActor" || strings.HasPrefix(instanceName, "library") || usesSymbols(instructionList) {
+		return ""
+	}
+	return instanceName
+}
+
+// identityOf returns the address of the data of an instance, if it refers to data that other instances could share.
+func identityOf(instance interface{}) (uintptr, bool) {
+	value := reflect.ValueOf(instance)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return value.Pointer(), value.Pointer() != 0
+	}
+	return 0, false
+}
+
+// usesSymbols returns whether an entity refers to a symbol anywhere, including in nested lists.
+func usesSymbols(entity slimentity.SlimEntity) bool {
+	switch value := entity.(type) {
+	case string:
+		return strings.Contains(value, "$")
+	case *slimentity.SlimList:
+		for _, item := range *value {
+			if usesSymbols(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Methods
+
+// chainIn returns the instance an instruction works on if it can be executed in parallel with the instructions before
+// it in the current run, or an empty string if it is a barrier or must wait for that run. Instances made in the run are
+// added to made.
+func (slimInterpreter *SlimInterpreter) chainIn(instruction slimentity.SlimEntity, made map[string]bool) string {
+	instanceName := chainOf(instruction)
+	if instanceName == "" {
+		return ""
+	}
+	instructionList := instruction.(*slimentity.SlimList)
+	if command, _ := instructionList.GetString(1); command == "make" {
+		made[instanceName] = true
+		return instanceName
+	}
+	methodName, err := instructionList.GetString(3)
+	if err != nil || made[instanceName] || !slimInterpreter.processor.HasMember(instanceName, methodName, instructionList.Length()-4) {
+		return ""
+	}
+	return instanceName
+}
+
+// chainKey returns the name of the chain for an instance: the first instance name in the run that has the same object.
+// The instances are the ones from before the run, as instances made in the run aren't used in it.
+func (slimInterpreter *SlimInterpreter) chainKey(instanceName string, owners map[uintptr]string) string {
+	identity, ok := identityOf(slimInterpreter.processor.Instance(instanceName))
+	if !ok {
+		return instanceName
+	}
+	if owner, ok := owners[identity]; ok {
+		return owner
+	}
+	owners[identity] = instanceName
+	return instanceName
+}
+
+// processChains executes the chains in the instructions in parallel, with at most as many goroutines as there are workers.
+// It stores the results at the index of their instruction.
+func (slimInterpreter *SlimInterpreter) processChains(instructions []slimentity.SlimEntity, results []slimentity.SlimEntity) {
+	chains := make(map[string][]int)
+	owners := make(map[uintptr]string)
+	var instanceNames []string
+	for index, instruction := range instructions {
+		instanceName := slimInterpreter.chainKey(chainOf(instruction), owners)
+		if _, ok := chains[instanceName]; !ok {
+			instanceNames = append(instanceNames, instanceName)
+		}
+		chains[instanceName] = append(chains[instanceName], index)
+	}
+	workers := make(chan bool, slimInterpreter.workers)
+	var waitGroup sync.WaitGroup
+	for _, instanceName := range instanceNames {
+		chain := chains[instanceName]
+		workers <- true
+		waitGroup.Add(1)
+		go func() {
+			defer func() {
+				<-workers
+				waitGroup.Done()
+			}()
+			for _, index := range chain {
+				results[index] = slimInterpreter.processInstruction(instructions[index])
+			}
+		}()
+	}
+	waitGroup.Wait()
+}
+
+// processInParallel splits the instructions at the barriers, and executes the chains between them in parallel.
+func (slimInterpreter *SlimInterpreter) processInParallel(instructions *slimentity.SlimList) *slimentity.SlimList {
+	results := make([]slimentity.SlimEntity, len(*instructions))
+	for start := 0; start < len(*instructions); {
+		made := make(map[string]bool)
+		end := start
+		for end < len(*instructions) && slimInterpreter.chainIn((*instructions)[end], made) != "" {
+			end++
+		}
+		if end == start {
+			results[start] = slimInterpreter.processInstruction((*instructions)[start])
+			start++
+			continue
+		}
+		slimInterpreter.processChains((*instructions)[start:end], results[start:end])
+		start = end
+	}
+	return slimentity.NewSlimListContaining(results)
+}
@@ -0,0 +1,181 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimprocessor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+func instructions(instructionList ...[]slimentity.SlimEntity) *slimentity.SlimList {
+	result := slimentity.NewSlimList()
+	for _, instruction := range instructionList {
+		result.Append(slimentity.NewSlimListContaining(instruction))
+	}
+	return result
+}
+
+func TestParallelChainOf(t *testing.T) {
+	nestedSymbol := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"a", "$b"})
+	testCases := []struct {
+		instruction slimentity.SlimEntity
+		expected    string
+		description string
+	}{
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"make1", "make", "instance1", "fixture"}), "instance1", "Make"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "instance1", "method", "arg"}), "instance1", "Call"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "instance1", "method", "$arg"}), "", "Call using a symbol"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "instance1", "method", nestedSymbol}), "", "Call using a symbol in a nested list"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"make1", "make", "$instance", "fixture"}), "", "Make on a symbol"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"make1", "make", "library1", "fixture"}), "", "Make of a library"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "scriptTableActor", "method"}), "", "Call on the script table actor"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "callAndAssign", "symbol", "instance1", "method"}), "", "CallAndAssign"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"import1", "import", "path"}), "", "Import"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"assign1", "assign", "symbol", "value"}), "", "Assign"},
+		{slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call"}), "", "Malformed"},
+		{"call1", "", "Not a list"},
+	}
+	for _, testCase := range testCases {
+		assert.Equals(t, testCase.expected, chainOf(testCase.instruction), testCase.description)
+	}
+}
+
+func TestParallelProcess(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(newMeetingProcessor(4), time.Duration(7)*time.Second)
+	slimInterpreter.SetParallel(4)
+	request := instructions(
+		[]slimentity.SlimEntity{"import1", "import", "test"},
+		[]slimentity.SlimEntity{"make1", "make", "instance1", "fixture"},
+		[]slimentity.SlimEntity{"make2", "make", "instance2", "fixture"},
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "meet"},
+		[]slimentity.SlimEntity{"call2", "call", "instance2", "meet"},
+		[]slimentity.SlimEntity{"call3", "call", "instance3", "meet"},
+		[]slimentity.SlimEntity{"call4", "call", "instance4", "meet"},
+		[]slimentity.SlimEntity{"call5", "call", "instance1", "method", "$symbol"},
+		[]slimentity.SlimEntity{"call6", "call", "instance2", "method"},
+	)
+	request.Append("bogus")
+	result := slimInterpreter.Process(request)
+	assert.Equals(t, "[[import1, Import test], [make1, Make instance1 fixture([])], [make2, Make instance2 fixture([])], "+
+		"[call1, Call instance1 meet: met], [call2, Call instance2 meet: met], [call3, Call instance3 meet: met], [call4, Call instance4 meet: met], "+
+		"[call5, Call instance1 method([$symbol])], [call6, Call instance2 method([])], __EXCEPTION__:message:<<MALFORMED_INSTRUCTION bogus>>]",
+		result.ToString(), "Calls on different instances met, and results are in the order of the instructions")
+	instruction, _ := slimInterpreter.ActiveInstruction()
+	assert.Equals(t, (*slimentity.SlimList)(nil), instruction, "No active instruction when done")
+}
+
+func TestParallelWorkersAndChains(t *testing.T) {
+	maxRunning := func(workers int, request *slimentity.SlimList) int {
+		processor := new(MockStatementProcessor)
+		slimInterpreter := NewSlimInterpreter(processor, time.Duration(7)*time.Second)
+		slimInterpreter.SetParallel(workers)
+		slimInterpreter.Process(request)
+		return processor.MaxRunning()
+	}
+	assert.IsTrue(t, maxRunning(2, instructions(
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "wait"},
+		[]slimentity.SlimEntity{"call2", "call", "instance2", "wait"},
+		[]slimentity.SlimEntity{"call3", "call", "instance3", "wait"},
+		[]slimentity.SlimEntity{"call4", "call", "instance4", "wait"},
+	)) <= 2, "No more than two instructions at the same time")
+
+	slimInterpreter := NewSlimInterpreter(newMeetingProcessor(2), time.Duration(7)*time.Second)
+	slimInterpreter.SetParallel(2)
+	assert.Equals(t, "[[call1, Call instance1 meet: met], [call2, Call instance2 meet: met]]", slimInterpreter.Process(instructions(
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "meet"},
+		[]slimentity.SlimEntity{"call2", "call", "instance2", "meet"},
+	)).ToString(), "Two instructions at the same time")
+
+	assert.Equals(t, 1, maxRunning(4, instructions(
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "wait"},
+		[]slimentity.SlimEntity{"call2", "call", "instance1", "wait"},
+	)), "Instructions on the same instance run in order")
+
+	assert.Equals(t, 1, maxRunning(4, instructions(
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "wait"},
+		[]slimentity.SlimEntity{"call2", "call", "library1", "wait"},
+		[]slimentity.SlimEntity{"call3", "call", "instance2", "wait"},
+	)), "Instructions don't run in parallel with a barrier")
+
+	assert.Equals(t, 1, maxRunning(4, instructions(
+		[]slimentity.SlimEntity{"call1", "call", "instance1", "libraryWait"},
+		[]slimentity.SlimEntity{"call2", "call", "instance2", "libraryWait"},
+	)), "Calls falling back to a library are barriers")
+
+	slimInterpreter.SetParallel(0)
+	assert.Equals(t, 1, slimInterpreter.workers, "At least one worker")
+}
+
+func TestParallelChainIn(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	slimInterpreter := NewSlimInterpreter(processor, time.Duration(7)*time.Second)
+	assert.Equals(t, "OK", processor.DoMake("messenger1", "Messenger", slimentity.NewSlimList()), "Make messenger1")
+	made := make(map[string]bool)
+	call := func(instanceName, methodName string) slimentity.SlimEntity {
+		return slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", instanceName, methodName})
+	}
+	assert.Equals(t, "messenger1", slimInterpreter.chainIn(call("messenger1", "Message"), made), "Call on existing instance with the method")
+	assert.Equals(t, "", slimInterpreter.chainIn(call("messenger1", "popFixture"), made), "Call falling back to a library")
+	assert.Equals(t, "", slimInterpreter.chainIn(call("nonexisting", "Message"), made), "Call on nonexisting instance")
+	assert.Equals(t, "", slimInterpreter.chainIn(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "messenger1"}), made), "Call without method")
+	make1 := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"make1", "make", "messenger1", "Messenger"})
+	assert.Equals(t, "messenger1", slimInterpreter.chainIn(make1, made), "Make")
+	assert.Equals(t, "", slimInterpreter.chainIn(call("messenger1", "Message"), made), "Call on instance made in the same run")
+}
+
+// Run with -race: calls on instances that fall back to the standard library must not run at the same time.
+func TestParallelLibraryFallback(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	slimInterpreter := NewSlimInterpreter(processor, time.Duration(7)*time.Second)
+	slimInterpreter.SetParallel(8)
+	request := slimentity.NewSlimList()
+	for i := 0; i < 8; i++ {
+		request.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{fmt.Sprintf("make%v", i), "make", fmt.Sprintf("messenger%v", i), "Messenger"}))
+	}
+	for i := 0; i < 8; i++ {
+		request.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{
+			fmt.Sprintf("call%v", i), "call", fmt.Sprintf("messenger%v", i), "randomInteger", "1", "6"}))
+	}
+	result := slimInterpreter.Process(request)
+	for i := 8; i < 16; i++ {
+		entry := (*result)[i].(*slimentity.SlimList)
+		value, _ := entry.GetString(1)
+		assert.IsTrue(t, value >= "1" && value <= "6", fmt.Sprintf("randomInteger via library on messenger%v", i-8))
+	}
+}
+
+// Run with -race: calls on instances that share an object must not run at the same time.
+func TestParallelSharedObject(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	slimInterpreter := NewSlimInterpreter(processor, time.Duration(7)*time.Second)
+	slimInterpreter.SetParallel(8)
+	assert.Equals(t, "OK", processor.DoMake("messenger1", "Messenger", slimentity.NewSlimList()), "Make messenger1")
+	assert.Equals(t, "OK", processor.DoMake("messenger2", "Messenger", slimentity.NewSlimList()), "Make messenger2")
+	processor.objects.Add("shared1", processor.objects.Get("messenger1"))
+	processor.objects.Add("shared2", processor.objects.Get("messenger1"))
+	owners := make(map[uintptr]string)
+	assert.Equals(t, "shared1", slimInterpreter.chainKey("shared1", owners), "First instance of the object owns the chain")
+	assert.Equals(t, "messenger2", slimInterpreter.chainKey("messenger2", owners), "Other object")
+	assert.Equals(t, "shared1", slimInterpreter.chainKey("messenger1", owners), "Same object joins the chain")
+	assert.Equals(t, "nonexisting", slimInterpreter.chainKey("nonexisting", owners), "Instance that doesn't exist yet")
+	request := slimentity.NewSlimList()
+	for i := 0; i < 8; i++ {
+		request.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{
+			fmt.Sprintf("call%v", i), "call", fmt.Sprintf("shared%v", i%2+1), "setMessage", fmt.Sprintf("message%v", i)}))
+	}
+	slimInterpreter.Process(request)
+	assert.Equals(t, "message7", processor.objects.Get("messenger1").(*Messenger).MessageField, "Calls on the shared object ran in order")
+}
@@ -16,7 +16,6 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -33,6 +32,7 @@ import (
 type Parser struct {
 	objectSerializer interfaces.ObjectSerializer
 	symbols          interfaces.SymbolCollector
+	policy           interfaces.AccessPolicy
 }
 
 // NewParser creates a new Parser.
@@ -47,6 +47,11 @@ func (parser *Parser) SetObjectSerializer(objectSerializer interfaces.ObjectSeri
 	parser.objectSerializer = objectSerializer
 }
 
+// SetAccessPolicy specifies the policy that restricts the members that symbol expressions can use.
+func (parser *Parser) SetAccessPolicy(policy interfaces.AccessPolicy) {
+	parser.policy = policy
+}
+
 // Helper functions
 
 func isPredefinedType(inputType reflect.Type) bool {
@@ -132,7 +137,7 @@ func (parser *Parser) CallFunction(function reflect.Value, args []string) (retur
 	defer func() {
 		if panicData := recover(); panicData != nil {
 			returnEntity = nil
-			err = fmt.Errorf("Panic: %v", apperrors.ErrorToString(panicData))
+			err = apperrors.NewPanicError(panicData, "slimprocessor.(*Parser).CallFunction")
 		}
 	}()
 	returnValue := function.Call(*arguments)
@@ -169,18 +174,27 @@ func (parser *Parser) matchParamType(paramIn []string, method reflect.Value) (*[
 // Parse takes an input string and parses it to the desired type.
 func (parser *Parser) Parse(input string, targetType reflect.Type) (interface{}, error) {
 	if isPredefinedType(targetType) {
-		resolvedInput := parser.ReplaceSymbolsIn(input)
+		resolvedInput, err := parser.interpolate(input, true)
+		if err != nil {
+			return nil, err
+		}
 		return parser.parsePredefined(resolvedInput, targetType)
 	}
 	if symbolValue, ok := parser.symbols.NonTextSymbol(input); ok {
 		if reflect.TypeOf(symbolValue).AssignableTo(targetType) {
 			return symbolValue, nil
 		}
-		return nil, toErrorf("Symbol '%v' of type '%v' not assignable to type '%v'", input, reflect.TypeOf(symbolValue), targetType)
+		// A list symbol (e.g. the result of a call returning a slice) can be parsed into a slice via its text representation.
+		if !slimentity.IsSlimList(symbolValue) || targetType.Kind() != reflect.Slice {
+			return nil, toErrorf("Symbol '%v' of type '%v' not assignable to type '%v'", input, reflect.TypeOf(symbolValue), targetType)
+		}
 	}
 	// target is no predefined type, input is no list, and no Symbol as Object.
 	// Check if it needs to be put in an interface - then we need to infer the type.
-	resolvedInput := parser.ReplaceSymbolsIn(input)
+	resolvedInput, err := parser.interpolate(input, true)
+	if err != nil {
+		return nil, err
+	}
 	resolvedInputType := reflect.TypeOf(resolvedInput)
 	if resolvedInputType.Kind() == reflect.String && targetType.Kind() == reflect.Interface {
 		return parser.parseToInferredType(resolvedInput), nil
@@ -339,10 +353,11 @@ func (parser *Parser) parseToInferredType(input string) interface{} {
 	return input
 }
 
-// ReplaceSymbolsIn replaces all occurrences of symbols in a string to their value.
+// ReplaceSymbolsIn replaces all occurrences of symbols and symbol expressions in a string to their value.
+// Expressions that cannot be evaluated are left as-is.
 func (parser *Parser) ReplaceSymbolsIn(source string) string {
-	regex := regexp.MustCompile(`\$` + symbolPattern)
-	return regex.ReplaceAllStringFunc(source, parser.replaceSymbolValue)
+	result, _ := parser.interpolate(source, false)
+	return result
 }
 
 // ReplaceSymbols does ReplaceSymbolsIn recursively for a SlimList.
@@ -12,11 +12,17 @@
 package slimprocessor
 
 import (
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/essenius/slim4go/internal/apperrors"
 	"github.com/essenius/slim4go/internal/interfaces"
 
 	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 	"github.com/essenius/slim4go/internal/slimprotocol"
 )
 
@@ -24,8 +30,14 @@ import (
 
 // SlimInterpreter processes incomming SlimLists and dispatches commands to its statement processor.
 type SlimInterpreter struct {
-	processor interfaces.StatementProcessor
-	timeout   time.Duration
+	processor   interfaces.StatementProcessor
+	timeout     time.Duration
+	stackTraces bool
+	logger      *slog.Logger
+	metrics     *slimmetrics.Metrics
+	workers     int
+	activeMutex sync.Mutex
+	active      map[*slimentity.SlimList]time.Time
 }
 
 // NewSlimInterpreter creates a new Slim interpreter.
@@ -33,66 +45,178 @@ func NewSlimInterpreter(processor interfaces.StatementProcessor, timeout time.Du
 	slimInterpreter := new(SlimInterpreter)
 	slimInterpreter.processor = processor
 	slimInterpreter.timeout = timeout
+	slimInterpreter.logger = slimlog.Discard()
+	slimInterpreter.workers = 1
+	slimInterpreter.active = make(map[*slimentity.SlimList]time.Time)
 	return slimInterpreter
 }
 
+// ActiveInstruction returns the instruction that is being executed and when it started, or nil if none is.
+// If several instructions are executed in parallel, it returns the one that has been running longest.
+// It can be called from another goroutine, e.g. to find out where a session hangs.
+func (slimInterpreter *SlimInterpreter) ActiveInstruction() (*slimentity.SlimList, time.Time) {
+	slimInterpreter.activeMutex.Lock()
+	defer slimInterpreter.activeMutex.Unlock()
+	var oldest *slimentity.SlimList
+	var oldestSince time.Time
+	for instruction, since := range slimInterpreter.active {
+		if oldest == nil || since.Before(oldestSince) {
+			oldest = instruction
+			oldestSince = since
+		}
+	}
+	return oldest, oldestSince
+}
+
+// SetLogger specifies the logger for the instructions.
+func (slimInterpreter *SlimInterpreter) SetLogger(logger *slog.Logger) {
+	slimInterpreter.logger = logger
+}
+
+// SetMetrics makes the interpreter collect the counts and durations of the instructions.
+func (slimInterpreter *SlimInterpreter) SetMetrics(metrics *slimmetrics.Metrics) {
+	slimInterpreter.metrics = metrics
+}
+
+// SetParallel specifies how many instructions may be executed in parallel. With one worker (the default),
+// all instructions are executed in order. See processInParallel for which instructions can run in parallel.
+func (slimInterpreter *SlimInterpreter) SetParallel(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	slimInterpreter.workers = workers
+}
+
+// SetStackTraces specifies whether exceptions caused by panics include a stack trace.
+func (slimInterpreter *SlimInterpreter) SetStackTraces(enabled bool) {
+	slimInterpreter.stackTraces = enabled
+}
+
 // Helper methods
 
-func addResult(list *slimentity.SlimList, entry ...slimentity.SlimEntity) {
-	sublist := slimentity.NewSlimListContaining(entry)
-	list.Append(sublist)
+func resultOf(entry ...slimentity.SlimEntity) *slimentity.SlimList {
+	return slimentity.NewSlimListContaining(entry)
 }
 
 func malformedInstruction(instruction interface{}) string {
-	if slimentity.IsSlimList(instruction) {
-		return slimprotocol.MalformedInstruction(instruction.(*slimentity.SlimList).ToString())
+	if list, ok := instruction.(*slimentity.SlimList); ok {
+		return slimprotocol.MalformedInstruction(list.ToString())
+	}
+	return slimprotocol.MalformedInstruction(fmt.Sprintf("%v", instruction))
+}
+
+// instructionFields contains the names of the fields after the id and the command, per command.
+var instructionFields = map[string][]string{
+	"assign":        {"symbol"},
+	"call":          {"instance", "method"},
+	"callAndAssign": {"symbol", "instance", "method"},
+	"import":        {"path"},
+	"make":          {"instance", "fixture"},
+}
+
+// instructionAttributes returns the log attributes of an instruction: the id, the command, and what the command applies to.
+func instructionAttributes(instruction *slimentity.SlimList) []any {
+	id, _ := instruction.GetString(0)
+	command, _ := instruction.GetString(1)
+	attributes := []any{"id", id, "command", command}
+	for i, name := range instructionFields[command] {
+		if value, err := instruction.GetString(i + 2); err == nil {
+			attributes = append(attributes, name, value)
+		}
+	}
+	return attributes
+}
+
+// stringsAt returns the strings at the indexes of the instruction, and whether all of them are present.
+func stringsAt(instruction *slimentity.SlimList, indexes ...int) ([]string, bool) {
+	values := make([]string, len(indexes))
+	for i, index := range indexes {
+		value, err := instruction.GetString(index)
+		if err != nil {
+			return nil, false
+		}
+		values[i] = value
 	}
-	return slimprotocol.MalformedInstruction(instruction.(string))
+	return values, true
 }
 
 // Methods
 
-func (slimInterpreter *SlimInterpreter) dispatch(instruction *slimentity.SlimList) slimentity.SlimEntity {
-	command := instruction.StringAt(1)
+// setActive keeps track of the instructions that are being executed. A zero time means that the instruction finished.
+func (slimInterpreter *SlimInterpreter) setActive(instruction *slimentity.SlimList, since time.Time) {
+	slimInterpreter.activeMutex.Lock()
+	defer slimInterpreter.activeMutex.Unlock()
+	if since.IsZero() {
+		delete(slimInterpreter.active, instruction)
+		return
+	}
+	slimInterpreter.active[instruction] = since
+}
+
+// dispatch executes an instruction. Fixtures may panic, and so may the processing of their arguments and results.
+// We don't want that to bring down the server, so a panic results in an exception for the instruction.
+// For a callAndAssign, it also returns the assignment of the symbol, which the caller makes if the result is still wanted.
+func (slimInterpreter *SlimInterpreter) dispatch(instruction *slimentity.SlimList) (result slimentity.SlimEntity, assignment func()) {
+	defer func() {
+		if panicData := recover(); panicData != nil {
+			result = exception(apperrors.NewPanicError(panicData, "slimprocessor.(*SlimInterpreter).dispatch"), slimInterpreter.stackTraces)
+			assignment = nil
+		}
+	}()
+	command, err := instruction.GetString(1)
+	if err != nil {
+		return malformedInstruction(instruction), nil
+	}
 	switch command {
 	case "assign":
-		return slimInterpreter.doAssign(instruction)
+		return slimInterpreter.doAssign(instruction), nil
 	case "call":
 		return slimInterpreter.doCall(instruction, noAssign)
 	case "callAndAssign":
 		return slimInterpreter.doCall(instruction, assign)
 	case "import":
-		return slimInterpreter.DoImport(instruction)
+		return slimInterpreter.DoImport(instruction), nil
 	case "make":
-		return slimInterpreter.DoMake(instruction)
+		return slimInterpreter.DoMake(instruction), nil
 	default:
-		return malformedInstruction(instruction)
+		return malformedInstruction(instruction), nil
 	}
 }
 
+type dispatchResult struct {
+	result     slimentity.SlimEntity
+	assignment func()
+}
+
+// dispatchWithTimeout returns a timeout exception if the instruction takes too long. Go can't stop the goroutine executing
+// the instruction, so that keeps running in the background and may still change the state of its fixture. Its result is
+// discarded: a callAndAssign that timed out doesn't assign its symbol, as FitNesse already got the exception. The assignment
+// is made here rather than in the goroutine, so it can't happen after the timeout.
 func (slimInterpreter *SlimInterpreter) dispatchWithTimeout(instruction *slimentity.SlimList) slimentity.SlimEntity {
-	resultChannel := make(chan slimentity.SlimEntity, 1)
+	resultChannel := make(chan dispatchResult, 1)
 	instructionTimer := time.NewTimer(slimInterpreter.timeout)
 	go func() {
-		returnValue := slimInterpreter.dispatch(instruction)
-		resultChannel <- returnValue
+		result, assignment := slimInterpreter.dispatch(instruction)
+		resultChannel <- dispatchResult{result, assignment}
 	}()
 	select {
-	case result := <-resultChannel:
+	case returnValue := <-resultChannel:
 		instructionTimer.Stop()
-		return result
+		if returnValue.assignment != nil {
+			returnValue.assignment()
+		}
+		return returnValue.result
 	case <-instructionTimer.C:
 		return slimprotocol.TimedOut(slimInterpreter.timeout)
 	}
 }
 
 func (slimInterpreter *SlimInterpreter) doAssign(instruction *slimentity.SlimList) string {
-	if instruction.Length() < 3 {
+	values, ok := stringsAt(instruction, 2, 3)
+	if !ok {
 		return malformedInstruction(instruction)
 	}
-	symbolName := instruction.StringAt(2)
-	value := instruction.StringAt(3)
-	slimInterpreter.processor.SetSymbol(symbolName, value)
+	slimInterpreter.processor.SetSymbol(values[0], values[1])
 	return slimprotocol.OK()
 }
 
@@ -101,67 +225,118 @@ const (
 	assign   = 5
 )
 
-func (slimInterpreter *SlimInterpreter) doCall(instruction *slimentity.SlimList, minLength int) slimentity.SlimEntity {
-	if instruction.Length() < minLength {
-		return malformedInstruction(instruction)
-	}
+// doCall executes a call. For a callAndAssign, the symbol assignment is returned rather than made.
+func (slimInterpreter *SlimInterpreter) doCall(instruction *slimentity.SlimList, minLength int) (slimentity.SlimEntity, func()) {
 	startIndex := 2
 	var symbolName string
 	if minLength == assign {
-		symbolName = instruction.StringAt(startIndex)
+		symbol, ok := stringsAt(instruction, startIndex)
+		if !ok {
+			return malformedInstruction(instruction), nil
+		}
+		symbolName = symbol[0]
 		startIndex++
 	}
-	instanceName := instruction.StringAt(startIndex)
-	methodName := instruction.StringAt(startIndex + 1)
-	args := instruction.TailAt(startIndex + 2)
-	result := slimInterpreter.processor.DoCall(instanceName, methodName, args)
+	values, ok := stringsAt(instruction, startIndex, startIndex+1)
+	args, err := instruction.GetTail(startIndex + 2)
+	if !ok || err != nil {
+		return malformedInstruction(instruction), nil
+	}
+	result := slimInterpreter.processor.DoCall(values[0], values[1], args)
+	var assignment func()
 	if minLength == assign {
-		slimInterpreter.processor.SetSymbol(symbolName, result)
+		assignment = func() { slimInterpreter.processor.SetSymbol(symbolName, result) }
 	}
-	return slimInterpreter.processor.SerializeObjectsIn(result)
+	return slimInterpreter.processor.SerializeObjectsIn(result), assignment
 }
 
 // DoImport executes an Import instruction.
 func (slimInterpreter *SlimInterpreter) DoImport(instruction *slimentity.SlimList) slimentity.SlimEntity {
-	if instruction.Length() < 3 {
+	pathName, err := instruction.GetString(2)
+	if err != nil {
 		return malformedInstruction(instruction)
 	}
-	pathName := instruction.StringAt(2)
 	return slimInterpreter.processor.DoImport(pathName)
 }
 
 // DoMake executes a Make instruction.
 func (slimInterpreter *SlimInterpreter) DoMake(instruction *slimentity.SlimList) slimentity.SlimEntity {
-	if instruction.Length() < 4 {
+	values, ok := stringsAt(instruction, 2, 3)
+	args, err := instruction.GetTail(4)
+	if !ok || err != nil {
 		return malformedInstruction(instruction)
 	}
-	instanceName := instruction.StringAt(2)
-	fixtureName := instruction.StringAt(3)
-	args := instruction.TailAt(4)
-	return slimInterpreter.processor.DoMake(instanceName, fixtureName, args)
+	return slimInterpreter.processor.DoMake(values[0], values[1], args)
+}
+
+// logInstruction logs an executed instruction with its duration. Exceptions are logged as warnings, including the exception.
+func (slimInterpreter *SlimInterpreter) logInstruction(instruction *slimentity.SlimList, result slimentity.SlimEntity, duration time.Duration) {
+	attributes := append(instructionAttributes(instruction), "duration", duration)
+	if response, ok := result.(string); ok && slimprotocol.IsException(response) {
+		slimInterpreter.logger.Warn("Instruction failed", append(attributes, "exception", response)...)
+		return
+	}
+	slimInterpreter.logger.Debug("Instruction", attributes...)
+}
+
+// measure adds an executed make or call instruction to the metrics, if they are collected.
+func (slimInterpreter *SlimInterpreter) measure(instruction *slimentity.SlimList, result slimentity.SlimEntity, duration time.Duration) {
+	if slimInterpreter.metrics == nil {
+		return
+	}
+	outcome := slimmetrics.Success
+	if response, ok := result.(string); ok && slimprotocol.IsException(response) {
+		outcome = slimmetrics.Exception
+		if response == slimprotocol.TimedOut(slimInterpreter.timeout) {
+			outcome = slimmetrics.Timeout
+		}
+	}
+	command, _ := instruction.GetString(1)
+	field := func(name string) string {
+		for i, fieldName := range instructionFields[command] {
+			if fieldName == name {
+				value, _ := instruction.GetString(i + 2)
+				return value
+			}
+		}
+		return ""
+	}
+	switch command {
+	case "make":
+		slimInterpreter.metrics.Make(field("instance"), field("fixture"), duration, outcome)
+	case "call", "callAndAssign":
+		slimInterpreter.metrics.Call(field("instance"), field("method"), duration, outcome)
+	}
 }
 
 // Process takes an incoming set of instructions, dispatches to statement processor, and retrieves the result.
 func (slimInterpreter *SlimInterpreter) Process(instructions *slimentity.SlimList) *slimentity.SlimList {
+	if slimInterpreter.workers > 1 {
+		return slimInterpreter.processInParallel(instructions)
+	}
 	results := slimentity.NewSlimList()
 	for _, instruction := range *instructions {
-		if slimentity.IsSlimList(instruction) {
-			instructionList := instruction.(*slimentity.SlimList)
-			if instructionList.Length() == 0 {
-				addResult(results, malformedInstruction(instructionList))
-			} else {
-				id := instructionList.StringAt(0)
-
-				if instructionList.Length() == 1 {
-					addResult(results, id, malformedInstruction(instructionList))
-				} else {
-					result := slimInterpreter.dispatchWithTimeout(instructionList)
-					addResult(results, id, result)
-				}
-			}
-		} else {
-			results.Append(malformedInstruction(instruction))
-		}
+		results.Append(slimInterpreter.processInstruction(instruction))
 	}
 	return results
 }
+
+// processInstruction executes an instruction and returns its entry in the results.
+func (slimInterpreter *SlimInterpreter) processInstruction(instruction slimentity.SlimEntity) slimentity.SlimEntity {
+	instructionList, ok := instruction.(*slimentity.SlimList)
+	if !ok {
+		return malformedInstruction(instruction)
+	}
+	id, err := instructionList.GetString(0)
+	if err != nil {
+		return resultOf(malformedInstruction(instructionList))
+	}
+	start := time.Now()
+	slimInterpreter.setActive(instructionList, start)
+	result := slimInterpreter.dispatchWithTimeout(instructionList)
+	slimInterpreter.setActive(instructionList, time.Time{})
+	duration := time.Since(start)
+	slimInterpreter.logInstruction(instructionList, result, duration)
+	slimInterpreter.measure(instructionList, result, duration)
+	return resultOf(id, result)
+}
@@ -12,18 +12,41 @@
 package slimprocessor
 
 import (
+	"bytes"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/fixture"
 	"github.com/essenius/slim4go/internal/interfaces"
 	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 )
 
 type MockStatementProcessor struct {
 	SetSymbolCalls       int
 	RegisterFixtureCalls int
+	// the number of wait calls running, and the maximum of that
+	mutex      sync.Mutex
+	running    int
+	maxRunning int
+	// meet calls wait until there are meetings of them
+	meetings int
+	arrived  int
+	met      chan bool
+}
+
+// newMeetingProcessor returns a mock for which meet calls wait until the number of meetings is reached, or a second passed.
+func newMeetingProcessor(meetings int) *MockStatementProcessor {
+	mock := new(MockStatementProcessor)
+	mock.meetings = meetings
+	mock.met = make(chan bool)
+	return mock
 }
 
 func (mock *MockStatementProcessor) FixtureRegistry() interfaces.Registry {
@@ -31,6 +54,12 @@ func (mock *MockStatementProcessor) FixtureRegistry() interfaces.Registry {
 }
 
 func (mock *MockStatementProcessor) DoCall(instanceName, methodName string, args *slimentity.SlimList) slimentity.SlimEntity {
+	switch methodName {
+	case "wait", "libraryWait":
+		mock.wait()
+	case "meet":
+		return fmt.Sprintf("Call %v meet: %v", instanceName, mock.meet())
+	}
 	return fmt.Sprintf("Call %v %v(%v)", instanceName, methodName, args.ToString())
 }
 
@@ -38,6 +67,9 @@ func (mock *MockStatementProcessor) DoImport(path string) slimentity.SlimEntity
 	if path == "wait" {
 		time.Sleep(time.Duration(100) * time.Millisecond)
 	}
+	if path == "panic" {
+		panic("import failed")
+	}
 	return fmt.Sprintf("Import %v", path)
 }
 
@@ -45,6 +77,48 @@ func (mock *MockStatementProcessor) DoMake(instanceName, fixtureName string, arg
 	return fmt.Sprintf("Make %v %v(%v)", instanceName, fixtureName, args.ToString())
 }
 
+func (mock *MockStatementProcessor) HasMember(instanceName, methodName string, argCount int) bool {
+	return !strings.HasPrefix(methodName, "library")
+}
+
+func (mock *MockStatementProcessor) Instance(instanceName string) interface{} {
+	return nil
+}
+
+func (mock *MockStatementProcessor) MaxRunning() int {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	return mock.maxRunning
+}
+
+func (mock *MockStatementProcessor) meet() string {
+	mock.mutex.Lock()
+	mock.arrived++
+	if mock.arrived == mock.meetings {
+		close(mock.met)
+	}
+	mock.mutex.Unlock()
+	select {
+	case <-mock.met:
+		return "met"
+	case <-time.After(time.Second):
+		return "alone"
+	}
+}
+
+func (mock *MockStatementProcessor) wait() {
+	mock.mutex.Lock()
+	mock.running++
+	if mock.running > mock.maxRunning {
+		mock.maxRunning = mock.running
+	}
+	mock.mutex.Unlock()
+	time.Sleep(time.Duration(100) * time.Millisecond)
+	mock.mutex.Lock()
+	mock.running--
+	mock.mutex.Unlock()
+}
+
 func (mock *MockStatementProcessor) Objects() interfaces.Collector {
 	return nil
 }
@@ -93,6 +167,133 @@ func TestSlimInterpreterTimeout(t *testing.T) {
 	assert.Equals(t, `[[import1, __EXCEPTION__:message:<<TIMED_OUT 0>>]]`, slimInterpreter.Process(importList).ToString(), "Import with timeout")
 }
 
+type Sleeper struct {
+	Label string
+}
+
+func NewSleeper(label string) *Sleeper {
+	sleeper := new(Sleeper)
+	sleeper.Label = label
+	return sleeper
+}
+
+func (sleeper *Sleeper) Sleep(milliseconds int) string {
+	time.Sleep(time.Duration(milliseconds) * time.Millisecond)
+	return sleeper.Label
+}
+
+func TestSlimInterpreterTimedOutFixture(t *testing.T) {
+	symbols := NewSymbolTable()
+	parser := NewParser(symbols)
+	objects := NewObjectHandler(parser)
+	parser.SetObjectSerializer(objects)
+	processor := NewStatementProcessor(fixture.NewRegistry(), objects, parser, symbols)
+	processor.registry.AddFixture(NewSleeper)
+	processor.registry.AddNamespace("slimprocessor")
+	slimInterpreter := NewSlimInterpreter(processor, time.Duration(20)*time.Millisecond)
+	instructions := slimentity.NewSlimList()
+	for _, instruction := range [][]slimentity.SlimEntity{
+		{"make1", "make", "sleeper1", "Sleeper", "first"},
+		{"callAndAssign1", "callAndAssign", "label", "sleeper1", "sleep", "100"},
+		{"make2", "make", "sleeper1", "Sleeper", "second"},
+		{"call1", "call", "sleeper1", "sleep", "0"},
+	} {
+		instructions.Append(slimentity.NewSlimListContaining(instruction))
+	}
+	assert.Equals(t, "[[make1, OK], [callAndAssign1, __EXCEPTION__:message:<<TIMED_OUT 0>>], [make2, OK], [call1, second]]",
+		slimInterpreter.Process(instructions).ToString(), "Instance replaced while the timed out call is in flight")
+	// keep using the object handler and the symbol table while the timed out call finishes, so the race detector can catch unsafe access
+	start := time.Now()
+	for time.Since(start) < 150*time.Millisecond {
+		symbols.Set("other", objects.Get("sleeper1"))
+		objects.InstanceNames()
+	}
+	assert.Equals(t, nil, symbols.Get("$label"), "Timed out call finished without assigning the symbol")
+	assert.Equals(t, "second", objects.Get("sleeper1").(*Sleeper).Label, "Replacement kept")
+}
+
+func TestSlimInterpreterPanic(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(7)*time.Second)
+	panicList := MakeInstructionList("import1", "import", "panic")
+	panicList.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"import2", "import", "test"}))
+	assert.Equals(t, `[[import1, __EXCEPTION__:message:<<Panic: import failed>>], [import2, Import test]]`,
+		slimInterpreter.Process(panicList).ToString(), "Panic is reported and next instruction is executed")
+	slimInterpreter.SetStackTraces(true)
+	result := slimInterpreter.Process(MakeInstructionList("import3", "import", "panic"))
+	response, err := result.GetList(0)
+	assert.Equals(t, nil, err, "No error getting the response")
+	exception, _ := response.GetString(1)
+	assert.IsTrue(t, strings.HasPrefix(exception, "__EXCEPTION__:message:<<Panic: import failed>>\n"), "Exception followed by stack trace")
+	assert.IsTrue(t, strings.Contains(exception, "(*MockStatementProcessor).DoImport"), "Stack trace contains the panicking function")
+	assert.IsTrue(t, !strings.Contains(exception, "(*SlimInterpreter).dispatch\n"), "Stack trace stops before the recovering function")
+}
+
+func TestSlimInterpreterLogging(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(7)*time.Second)
+	var buffer bytes.Buffer
+	logger, _ := slimlog.NewLogger(&buffer, "text", slog.LevelDebug)
+	slimInterpreter.SetLogger(logger)
+	instructions := MakeInstructionList("callAndAssign1", "callAndAssign", "symbol1", "instance1", "method1", "arg1")
+	instructions.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"import1", "import", "panic"}))
+	slimInterpreter.Process(instructions)
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Equals(t, 2, len(lines), "Two instructions logged")
+	assert.IsTrue(t, strings.Contains(lines[0],
+		"level=DEBUG msg=Instruction id=callAndAssign1 command=callAndAssign symbol=symbol1 instance=instance1 method=method1 duration="),
+		"Call logged with its fields")
+	assert.IsTrue(t, strings.Contains(lines[1], "level=WARN msg=\"Instruction failed\" id=import1 command=import path=panic duration="),
+		"Exception logged as warning")
+	assert.IsTrue(t, strings.Contains(lines[1], "exception=\"__EXCEPTION__:message:<<Panic: import failed>>\""), "Exception included")
+}
+
+func TestSlimInterpreterMetrics(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(7)*time.Second)
+	metrics := slimmetrics.NewMetrics()
+	slimInterpreter.SetMetrics(metrics)
+	instructions := MakeInstructionList("make1", "make", "instance1", "fixture1")
+	instructions.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call1", "call", "instance1", "method1"}))
+	instructions.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"call2", "callAndAssign", "symbol1", "instance1", "method1"}))
+	instructions.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"import1", "import", "panic"}))
+	slimInterpreter.Process(instructions)
+	summary := metrics.Summary()
+	assert.Equals(t, 2, len(summary), "Make and call measured, import not")
+	for _, measurement := range summary {
+		assert.Equals(t, "fixture1", measurement.Fixture, "Fixture of "+measurement.Method)
+	}
+	counts := map[string]int{summary[0].Method: summary[0].Count, summary[1].Method: summary[1].Count}
+	assert.Equals(t, 2, counts["method1"], "Call and callAndAssign counted")
+	assert.Equals(t, 1, counts[slimmetrics.Constructor], "Make counted")
+
+	timeoutInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(10)*time.Millisecond)
+	timeoutMetrics := slimmetrics.NewMetrics()
+	timeoutInterpreter.SetMetrics(timeoutMetrics)
+	timeoutInterpreter.Process(MakeInstructionList("call1", "call", "instance1", "wait"))
+	timedOut := timeoutMetrics.Summary()[0]
+	assert.Equals(t, "instance1", timedOut.Fixture, "Instance used as fixture if it wasn't made")
+	assert.Equals(t, 1, timedOut.Timeouts, "Timeout counted")
+	assert.Equals(t, 0, timedOut.Exceptions, "Timeout not counted as exception")
+}
+
+func TestSlimInterpreterActiveInstruction(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(7)*time.Second)
+	instruction, _ := slimInterpreter.ActiveInstruction()
+	assert.Equals(t, (*slimentity.SlimList)(nil), instruction, "No active instruction at start")
+	done := make(chan bool)
+	go func() {
+		slimInterpreter.Process(MakeInstructionList("call1", "call", "instance1", "wait"))
+		done <- true
+	}()
+	start := time.Now()
+	for instruction == nil && time.Since(start) < time.Second {
+		time.Sleep(time.Millisecond)
+		instruction, _ = slimInterpreter.ActiveInstruction()
+	}
+	assert.Equals(t, "[call1, call, instance1, wait]", instruction.ToString(), "Active instruction while waiting")
+	<-done
+	instruction, _ = slimInterpreter.ActiveInstruction()
+	assert.Equals(t, (*slimentity.SlimList)(nil), instruction, "No active instruction when done")
+}
+
 func TestSlimInterpreterMalformedInstructions(t *testing.T) {
 	MockStatementProcessor := new(MockStatementProcessor)
 	slimInterpreter := NewSlimInterpreter(MockStatementProcessor, time.Duration(7)*time.Second)
@@ -114,3 +315,50 @@ func TestSlimInterpreterMalformedInstructions(t *testing.T) {
 	assert.Equals(t, `[[bogus, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [bogus]>>]]`, slimInterpreter.Process(noCommandList).ToString(), "no command")
 }
 
+func TestSlimInterpreterNestedListsInInstructions(t *testing.T) {
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(7)*time.Second)
+	nested := slimentity.NewSlimListContaining(slimentity.SlimList{"x"})
+	testCases := []struct {
+		instruction *slimentity.SlimList
+		expected    string
+		description string
+	}{
+		{MakeInstructionList(nested, "import", "test"), `[[__EXCEPTION__:message:<<MALFORMED_INSTRUCTION [[x], import, test]>>]]`, "List as id"},
+		{MakeInstructionList("id", nested, "test"), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, [x], test]>>]]`, "List as command"},
+		{MakeInstructionList("id", "import", nested), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, import, [x]]>>]]`, "List as path"},
+		{MakeInstructionList("id", "make", nested, "fixture"), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, make, [x], fixture]>>]]`, "List as instance"},
+		{MakeInstructionList("id", "make", "instance", "fixture", nested), `[[id, Make instance fixture([[x]])]]`, "List as argument"},
+		{MakeInstructionList("id", "call", "instance", nested), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, call, instance, [x]]>>]]`, "List as method"},
+		{MakeInstructionList("id", "callAndAssign", nested, "instance", "method"), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, callAndAssign, [x], instance, method]>>]]`, "List as symbol"},
+		{MakeInstructionList("id", "assign", "symbol"), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, assign, symbol]>>]]`, "Assign without value"},
+		{MakeInstructionList("id", "assign", "symbol", nested), `[[id, __EXCEPTION__:message:<<MALFORMED_INSTRUCTION [id, assign, symbol, [x]]>>]]`, "List as value"},
+		{slimentity.NewSlimListContaining(slimentity.SlimList{"id"}), `[__EXCEPTION__:message:<<MALFORMED_INSTRUCTION id>>]`, "String as instruction"},
+	}
+	for _, testCase := range testCases {
+		assert.Equals(t, testCase.expected, slimInterpreter.Process(testCase.instruction).ToString(), testCase.description)
+	}
+}
+
+func FuzzSlimInterpreterProcess(f *testing.F) {
+	for _, seed := range []*slimentity.SlimList{
+		MakeInstructionList("import1", "import", "test"),
+		MakeInstructionList("make1", "make", "instance1", "fixture", "arg1", slimentity.NewSlimListContaining(slimentity.SlimList{"a", "b"})),
+		MakeInstructionList("call1", "call", "instance1", "method1", "arg1"),
+		MakeInstructionList("callAndAssign1", "callAndAssign", "symbol1", "instance1", "method2"),
+		MakeInstructionList("assign1", "assign", "symbol2", "value2"),
+		MakeInstructionList("id", "call", slimentity.NewSlimListContaining(slimentity.SlimList{"x"}), "method"),
+	} {
+		f.Add(slimentity.Marshal(seed))
+	}
+	slimInterpreter := NewSlimInterpreter(new(MockStatementProcessor), time.Duration(10)*time.Second)
+	f.Fuzz(func(t *testing.T, request string) {
+		instructions, err := slimentity.ReadRequest(strings.NewReader(request))
+		if err != nil || !slimentity.IsSlimList(instructions) {
+			return
+		}
+		results := slimInterpreter.Process(instructions.(*slimentity.SlimList))
+		if results.Length() != instructions.(*slimentity.SlimList).Length() {
+			t.Fatalf("Expected a result per instruction for %q but got %v", request, results.ToString())
+		}
+	})
+}
@@ -12,12 +12,16 @@
 package slimprocessor
 
 import (
+	"fmt"
+	"log/slog"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/essenius/slim4go/internal/apperrors"
 	"github.com/essenius/slim4go/internal/interfaces"
 	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimlog"
 	"github.com/essenius/slim4go/internal/slimprotocol"
 )
 
@@ -25,10 +29,14 @@ import (
 
 // SlimStatementProcessor is the implementation of the StatementProcessor interface.
 type SlimStatementProcessor struct {
-	registry interfaces.Registry
-	objects  interfaces.ObjectHandler
-	parser   interfaces.Parser
-	symbols  interfaces.SymbolCollector
+	registry          interfaces.Registry
+	objects           interfaces.ObjectHandler
+	parser            interfaces.Parser
+	symbols           interfaces.SymbolCollector
+	conflictMutex     sync.Mutex
+	reportedConflicts map[string]bool
+	stackTraces       bool
+	logger            *slog.Logger
 }
 
 // NewStatementProcessor returns a new SlimStatementProcesspr.
@@ -38,9 +46,39 @@ func NewStatementProcessor(registry interfaces.Registry, objects interfaces.Obje
 	processor.objects = objects
 	processor.parser = parser
 	processor.symbols = symbols
+	processor.reportedConflicts = make(map[string]bool)
+	processor.logger = slimlog.Discard()
 	return processor
 }
 
+// SetLogger specifies the logger for diagnostic messages, e.g. about conflicting library methods.
+func (processor *SlimStatementProcessor) SetLogger(logger *slog.Logger) {
+	processor.logger = logger
+}
+
+// SetStackTraces specifies whether exceptions caused by panics include a stack trace.
+func (processor *SlimStatementProcessor) SetStackTraces(enabled bool) {
+	processor.stackTraces = enabled
+}
+
+// Helpers
+
+// exception converts an error into an exception, with a stack trace if it was caused by a panic and stack traces are enabled.
+func exception(err error, stackTraces bool) string {
+	if panicError, ok := err.(*apperrors.PanicError); ok && stackTraces {
+		return slimprotocol.ExceptionWithStack(panicError.Error(), panicError.Stack)
+	}
+	return slimprotocol.Exception(err.Error())
+}
+
+func reversed(list []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		result = append(result, list[i])
+	}
+	return result
+}
+
 // Interface methods
 
 // DoCall calls a method (or property) on an instance.
@@ -57,17 +95,22 @@ func (processor *SlimStatementProcessor) DoCall(instanceName, methodName string,
 	if err1 == nil {
 		return result
 	}
+	if deniedErr, ok := err1.(*apperrors.AccessDeniedError); ok {
+		return slimprotocol.AccessDenied(deniedErr.Member, deniedErr.Fixture, deniedErr.Reason)
+	}
 	if _, ok := err1.(*apperrors.NotFoundError); !ok {
-		return slimprotocol.Exception(err1.Error())
+		return exception(err1, processor.stackTraces)
 	}
 	// no object found or no method found on the object instance. Try via the libraries
-	libraries := processor.objects.InstancesWithPrefix("library")
-	var err2 error
-	for _, library := range libraries {
-		result, err2 = processor.objects.InvokeMemberOn(library, methodName, args)
-		if err2 == nil {
-			return result
+	if library := processor.libraryWith(methodName, args.Length()); library != nil {
+		result, err2 := processor.objects.InvokeMemberOn(library, methodName, args)
+		if deniedErr, ok := err2.(*apperrors.AccessDeniedError); ok {
+			return slimprotocol.AccessDenied(deniedErr.Member, deniedErr.Fixture, deniedErr.Reason)
 		}
+		if err2 != nil {
+			return exception(err2, processor.stackTraces)
+		}
+		return result
 	}
 	if notFoundErr, ok := err1.(*apperrors.NotFoundError); ok {
 		// If the instance was not found, best to return that message.
@@ -78,6 +121,17 @@ func (processor *SlimStatementProcessor) DoCall(instanceName, methodName string,
 	return slimprotocol.NoMethodInFixture(methodName, reflect.TypeOf(instance).String(), args.Length())
 }
 
+// HasMember returns whether an instance exists and has the member, i.e. whether a call doesn't fall back to the libraries.
+func (processor *SlimStatementProcessor) HasMember(instanceName, methodName string, argCount int) bool {
+	instance := processor.objects.Get(instanceName)
+	return instance != nil && processor.objects.HasMemberOn(instance, methodName, argCount)
+}
+
+// Instance returns the instance with the name, or nil if there is none.
+func (processor *SlimStatementProcessor) Instance(instanceName string) interface{} {
+	return processor.objects.Get(instanceName)
+}
+
 // DoImport executes an Slim Import command
 func (processor *SlimStatementProcessor) DoImport(value string) slimentity.SlimEntity {
 	processor.registry.AddNamespace(value)
@@ -91,9 +145,12 @@ func (processor *SlimStatementProcessor) DoMake(instanceName, fixtureName string
 		return slimprotocol.OK()
 	}
 	resolvedFixtureName := processor.parser.ReplaceSymbolsIn(fixtureName)
-	constructor := processor.registry.FixtureNamed(resolvedFixtureName)
-	if constructor == nil {
-		return slimprotocol.NoFixture(resolvedFixtureName)
+	constructor, err := processor.registry.FixtureNamed(resolvedFixtureName)
+	if ambiguousErr, ok := err.(*apperrors.AmbiguousFixtureError); ok {
+		return slimprotocol.AmbiguousFixture(resolvedFixtureName, ambiguousErr.Candidates)
+	}
+	if err != nil {
+		return slimprotocol.NoFixture(resolvedFixtureName, processor.registry.ClosestFixtures(resolvedFixtureName)...)
 	}
 	constructorValue := reflect.ValueOf(constructor)
 	if err := processor.objects.AddObjectByConstructor(instanceName, constructorValue, slimentity.ToSlice(args)); err != nil {
@@ -102,6 +159,13 @@ func (processor *SlimStatementProcessor) DoMake(instanceName, fixtureName string
 	return slimprotocol.OK()
 }
 
+// Libraries returns the libraries in order of precedence: the ones made via library tables (most recent first),
+// followed by the ones registered at startup (most recent first).
+func (processor *SlimStatementProcessor) Libraries() []interface{} {
+	libraries := reversed(processor.objects.InstancesWithPrefix("library"))
+	return append(libraries, processor.registry.Libraries()...)
+}
+
 // SetSymbol sets a value in the symbol table.
 func (processor *SlimStatementProcessor) SetSymbol(symbol string, value interface{}) {
 	processor.symbols.Set(symbol, value)
@@ -123,3 +187,36 @@ func (processor *SlimStatementProcessor) SerializeObjectsIn(input slimentity.Sli
 	}
 	return input
 }
+
+// Other methods
+
+// firstReportOf returns whether a library conflict has not been reported yet, and marks it as reported.
+func (processor *SlimStatementProcessor) firstReportOf(conflict string) bool {
+	processor.conflictMutex.Lock()
+	defer processor.conflictMutex.Unlock()
+	if processor.reportedConflicts[conflict] {
+		return false
+	}
+	processor.reportedConflicts[conflict] = true
+	return true
+}
+
+// libraryWith returns the library with the highest precedence that has the method, or nil if there is none.
+// If other libraries also have the method, that gets logged (once per method), as it may not be what the test author expects.
+func (processor *SlimStatementProcessor) libraryWith(methodName string, argCount int) interface{} {
+	var found interface{}
+	for _, library := range processor.Libraries() {
+		if !processor.objects.HasMemberOn(library, methodName, argCount) {
+			continue
+		}
+		if found == nil {
+			found = library
+			continue
+		}
+		if conflict := fmt.Sprintf("%v[%v]", methodName, argCount); processor.firstReportOf(conflict) {
+			processor.logger.Info("Method is provided by multiple libraries. Using the first",
+				"method", conflict, "first", reflect.TypeOf(found).String(), "second", reflect.TypeOf(library).String())
+		}
+	}
+	return found
+}
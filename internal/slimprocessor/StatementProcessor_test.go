@@ -12,13 +12,17 @@
 package slimprocessor
 
 import (
+	htmltemplate "html/template"
 	"reflect"
+	"strings"
 	"testing"
+	texttemplate "text/template"
 
 	"github.com/essenius/slim4go/internal/assert"
 	"github.com/essenius/slim4go/internal/fixture"
 	"github.com/essenius/slim4go/internal/slimentity"
 	"github.com/essenius/slim4go/internal/standardlibrary"
+	"github.com/essenius/slim4go/slimfixture"
 )
 
 const instanceName = "scriptTableActor"
@@ -50,7 +54,7 @@ func initProcessorAndLibrary(t *testing.T) (*SlimStatementProcessor, *standardli
 	parser.SetObjectSerializer(objectHandler)
 	processor := NewStatementProcessor(fixture.NewRegistry(), objectHandler, parser, symbols)
 
-	objectHandler.Add("libraryStandard", standardlibrary.New(standardlibrary.NewActorStack(), objectHandler))
+	objectHandler.Add("libraryStandard", standardlibrary.New(standardlibrary.NewActorStack(), objectHandler, 0))
 	assert.Equals(t, 1, objectHandler.Length(), "Length of object collection = 1 (libraryStandard)")
 	library := objectHandler.Get("libraryStandard").(*standardlibrary.StandardLibrary)
 	assert.IsTrue(t, library != nil, "library found")
@@ -113,6 +117,11 @@ func TestStatementProcessorMakeMessenger(t *testing.T) {
 	assert.Equals(t, "Bye bye", processor.DoCall("instance1", "Message", slimentity.NewSlimList()), "Call Get after setting with symbols")
 	assert.Equals(t, "__EXCEPTION__:message:<<Panic: Bye bye>>",
 		processor.DoCall("instance1", "Panic", slimentity.NewSlimList()), "Panic is caught and reported")
+	processor.SetStackTraces(true)
+	panicWithStack := processor.DoCall("instance1", "Panic", slimentity.NewSlimList()).(string)
+	assert.IsTrue(t, strings.HasPrefix(panicWithStack, "__EXCEPTION__:message:<<Panic: Bye bye>>\n"), "Panic with stack trace")
+	assert.IsTrue(t, strings.Contains(panicWithStack, "(*Messenger).Panic"), "Stack trace contains the panicking method")
+	processor.SetStackTraces(false)
 	assert.Equals(t, "__EXCEPTION__:message:<<Expected 1 parameter(s) but got 0>>",
 		processor.DoCall("instance1", "SetMessage", slimentity.NewSlimList()), "Call Set with empty parameter set")
 	assert.Equals(t, "SetMessage", processor.DoCall("instance1", "CloneSymbol",
@@ -143,6 +152,30 @@ func TestStatementProcessorMakeOrder(t *testing.T) {
 		"Use a constructor with wrong number of parameters")
 }
 
+func TestStatementProcessorMakeAmbiguous(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	processor.registry.AddFixture(texttemplate.New)
+	processor.registry.AddFixture(htmltemplate.New)
+	nameArg := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"name"})
+	assert.Equals(t, "__EXCEPTION__:message:<<AMBIGUOUS_CLASS template.Template: matches html/template.Template, text/template.Template. "+
+		"Use the full name, an alias or a more specific import>>", processor.DoMake("instance1", "template.Template", nameArg), "Ambiguous name")
+	assert.Equals(t, "OK", processor.DoMake("instance1", "text/template.Template", nameArg), "Full name")
+	assert.Equals(t, "*template.Template", reflect.TypeOf(processor.objects.Get("instance1")).String(), "Instance made")
+	assert.Equals(t, "OK", processor.DoImport("html/template"), "Import full path")
+	assert.Equals(t, "OK", processor.DoMake("instance2", "Template", nameArg), "Name in imported package")
+	assert.Equals(t, nil, processor.registry.AddAlias("TextTemplate", "text/template.Template"), "Add alias")
+	assert.Equals(t, "OK", processor.DoMake("instance3", "TextTemplate", nameArg), "Alias")
+}
+
+func TestStatementProcessorMakeGraceful(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	noArgs := slimentity.NewSlimList()
+	assert.Equals(t, "OK", processor.DoMake("instance1", "messenger", noArgs), "Lower case")
+	assert.Equals(t, "OK", processor.DoMake("instance2", "slimprocessor/messenger fixture", noArgs), "Slash, space and Fixture suffix")
+	assert.Equals(t, "__EXCEPTION__:message:<<NO_CLASS mesenger. Closest matches: slimprocessor.Messenger>>",
+		processor.DoMake("instance3", "mesenger", noArgs), "Closest match reported")
+}
+
 func TestStatementProcessorMakeObjectWithPanic(t *testing.T) {
 	processor, _ := initProcessorAndLibrary(t)
 	processor.registry.AddFixture(NewObjectWithPanic)
@@ -172,3 +205,138 @@ func TestStatementProcessorSerializeObjectsIn(t *testing.T) {
 	assert.Equals(t, "[test2, demo1, demo2, slimprocessor.emptyStruct]",
 		processor.SerializeObjectsIn(list).(*slimentity.SlimList).ToString(), "list with objects")
 }
+
+type echoLibrary struct {
+	prefix string
+}
+
+func (library *echoLibrary) Echo(input string) string {
+	return library.prefix + input
+}
+
+func (library *echoLibrary) Shout(input string) string {
+	return library.prefix + input + "!"
+}
+
+func TestStatementProcessorLibraryPrecedence(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	hello := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"hello"})
+	assert.Equals(t, nil, processor.registry.AddLibrary(&echoLibrary{"registered:"}), "Register library")
+	assert.Equals(t, "hello", processor.DoCall("nonexisting", "echo", hello), "Library table beats registered library")
+	assert.Equals(t, "registered:hello!", processor.DoCall("nonexisting", "shout", hello), "Registered library is used")
+	processor.objects.Add("libraryFirst", &echoLibrary{"first:"})
+	processor.objects.Add("librarySecond", &echoLibrary{"second:"})
+	assert.Equals(t, 4, len(processor.Libraries()), "Four libraries")
+	assert.Equals(t, "second:hello", processor.DoCall("nonexisting", "echo", hello), "Most recent library is used")
+	processor.objects.Add("libraryFirst", &echoLibrary{"renewed:"})
+	assert.Equals(t, "renewed:hello!", processor.DoCall(instanceName, "shout", hello), "Remade library is most recent")
+	assert.Equals(t, "__EXCEPTION__:message:<<Expected 1 parameter(s) but got 2>>",
+		processor.DoCall(instanceName, "shout", slimentity.NewSlimListContaining([]slimentity.SlimEntity{"a", "b"})),
+		"Library errors are reported")
+}
+
+func TestStatementProcessorStandardLibraryKeywords(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	call := func(methodName string, args ...slimentity.SlimEntity) slimentity.SlimEntity {
+		return processor.DoCall(instanceName, methodName, slimentity.NewSlimListContaining(args))
+	}
+	processor.SetSymbol("list", slimentity.NewSlimListContaining([]slimentity.SlimEntity{"a", "b", "c"}))
+	assert.Equals(t, "6.5", call("add", "1", "2", "3.5"), "Variadic add")
+	assert.Equals(t, "2", call("listLength", "[a, b]"), "ListLength with list literal")
+	assert.Equals(t, "c", call("listElement", "$list", "2"), "ListElement with list symbol")
+	assert.Equals(t, "value", call("mapValue", "<table><tr><td>key</td><td>value</td></tr></table>", "key"), "MapValue with hash table")
+	assert.Equals(t, "HELLO WORLD", call("toUpper", "Hello World"), "ToUpper")
+	assert.Equals(t, "true", call("matches", "abc", "^a"), "Matches")
+}
+
+func TestStatementProcessorStubs(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	processor.objects.(*ObjectHandler).SetStubSource(processor.registry)
+	stubs := slimfixture.Stubs{
+		"Message": func(instance interface{}, args []string) ([]interface{}, error) {
+			return []interface{}{"stub:" + instance.(*Messenger).Message()}, nil
+		},
+		"SetMessage": func(instance interface{}, args []string) ([]interface{}, error) {
+			if err := slimfixture.CheckArgs(args, 1); err != nil {
+				return nil, err
+			}
+			instance.(*Messenger).SetMessage(args[0])
+			return nil, nil
+		},
+		"Panic": func(instance interface{}, args []string) ([]interface{}, error) {
+			panic("stub panic")
+		},
+	}
+	assert.Equals(t, "Could not add stubs: fixture 'github.com/essenius/slim4go/internal/slimprocessor.Order' is not registered",
+		processor.registry.AddStubs((*Order)(nil), stubs).Error(), "Stubs for unregistered fixture")
+	assert.Equals(t, nil, processor.registry.AddStubs((*Messenger)(nil), stubs), "Add stubs")
+	noArgs := slimentity.NewSlimList()
+	assert.Equals(t, "stub:Hello world", processor.DoCall(instanceName, "message", noArgs), "Stub is used")
+	processor.SetSymbol("greeting", "hi")
+	assert.Equals(t, "/__VOID__/", processor.DoCall(instanceName, "setMessage",
+		slimentity.NewSlimListContaining([]slimentity.SlimEntity{"$greeting there"})), "Stub without result")
+	assert.Equals(t, "stub:hi there", processor.DoCall(instanceName, "getMessage", noArgs), "Symbols are replaced, alternative name finds stub")
+	assert.Equals(t, "__EXCEPTION__:message:<<Expected 1 parameter(s) but got 0>>",
+		processor.DoCall(instanceName, "setMessage", slimentity.NewSlimListContaining([]slimentity.SlimEntity{})), "Stub errors are reported")
+	assert.Equals(t, "__EXCEPTION__:message:<<Panic: stub panic>>", processor.DoCall(instanceName, "panic", noArgs), "Stub panic is caught")
+	assert.Equals(t, "hi there", processor.DoCall(instanceName, "messageField", noArgs), "Fields don't use stubs")
+
+	processor.objects.(*ObjectHandler).SetAccessPolicy(processor.registry)
+	assert.Equals(t, nil, processor.registry.AllowMembers("slimprocessor.Messenger", "Set*"), "Allow members")
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED Message slimprocessor.Messenger: not an allowed member>>",
+		processor.DoCall(instanceName, "message", noArgs), "Policy applies to stubs")
+}
+
+func TestStatementProcessorAccessPolicy(t *testing.T) {
+	processor, library := initProcessorAndLibrary(t)
+	processor.objects.(*ObjectHandler).SetAccessPolicy(processor.registry)
+	assert.Equals(t, nil, processor.registry.AddLibrary(library), "Register standard library")
+	noArgs := slimentity.NewSlimList()
+	bye := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"bye"})
+	assert.Equals(t, nil, processor.registry.AllowMembers("slimprocessor.Messenger", "Message", "*Field"), "Allow members")
+	assert.Equals(t, "Hello world", processor.DoCall(instanceName, "message", noArgs), "Allowed method")
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED SetMessage slimprocessor.Messenger: not an allowed member>>",
+		processor.DoCall(instanceName, "setMessage", bye), "Method not allowed")
+	assert.Equals(t, nil, processor.registry.SetReadOnlyFields("slimprocessor.Messenger", "MessageField"), "Set read-only field")
+	assert.Equals(t, "Hello world", processor.DoCall(instanceName, "messageField", noArgs), "Read-only field can be read")
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED MessageField slimprocessor.Messenger: read-only field>>",
+		processor.DoCall(instanceName, "setMessageField", bye), "Read-only field can't be set")
+
+	processor.objects.Add("sut", &echoLibrary{"sut:"})
+	assert.Equals(t, "sut:bye", processor.DoCall("sut", "echo", bye), "Non-fixture allowed by default")
+	processor.registry.DenyNonFixtureMembers()
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED Echo slimprocessor.echoLibrary: not a fixture>>",
+		processor.DoCall("sut", "echo", bye), "Non-fixture denied")
+	assert.Equals(t, "bye", processor.DoCall("nonexisting", "echo", bye), "Registered library allowed")
+	assert.Equals(t, "Hello world", processor.DoCall(instanceName, "message", noArgs), "Fixture allowed")
+	aDemoStruct1 := &demoStruct1{"demo"}
+	aDemoStruct1.Parse("demo1")
+	assert.Equals(t, "demo1", processor.SerializeObjectsIn(aDemoStruct1), "Conversions are not restricted")
+}
+
+func TestStatementProcessorRegexGroupReferences(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	args := slimentity.NewSlimListContaining([]slimentity.SlimEntity{"1x 2y", "([0-9])([a-z])", "${2}0${1}"})
+	assert.Equals(t, "x01 y02", processor.DoCall(instanceName, "replaceRegex", args), "Group references are not interpolated")
+}
+
+func TestStatementProcessorAccessPolicyInExpressions(t *testing.T) {
+	processor, _ := initProcessorAndLibrary(t)
+	processor.objects.(*ObjectHandler).SetAccessPolicy(processor.registry)
+	processor.parser.(*Parser).SetAccessPolicy(processor.registry)
+	processor.symbols.Set("messenger", processor.objects.Get(instanceName))
+	processor.symbols.Set("order", &expressionOrder{quantity: 3})
+	argument := func(text string) *slimentity.SlimList {
+		return slimentity.NewSlimListContaining([]slimentity.SlimEntity{text})
+	}
+	assert.Equals(t, nil, processor.registry.AllowMembers("slimprocessor.Messenger", "SetMessage"), "Allow setter")
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED MessageField slimprocessor.Messenger: not an allowed member>>",
+		processor.DoCall(instanceName, "setMessage", argument("${$messenger.messageField + '!'}")), "Field not allowed in expression")
+	assert.Equals(t, nil, processor.registry.AllowMembers("slimprocessor.Messenger", "MessageField"), "Allow field")
+	assert.Equals(t, "/__VOID__/", processor.DoCall(instanceName, "setMessage", argument("${$messenger.messageField + '!'}")), "Allowed field in expression")
+	assert.Equals(t, "Hello world!", processor.objects.Get(instanceName).(*Messenger).MessageField, "Allowed field used")
+	assert.Equals(t, "/__VOID__/", processor.DoCall(instanceName, "setMessage", argument("${$order.quantity}")), "Non-fixture allowed by default")
+	processor.registry.DenyNonFixtureMembers()
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED GetQuantity slimprocessor.expressionOrder: not a fixture>>",
+		processor.DoCall(instanceName, "setMessage", argument("$order.quantity")), "Non-fixture denied in symbol reference")
+}
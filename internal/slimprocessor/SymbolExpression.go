@@ -0,0 +1,631 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimprocessor
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/essenius/slim4go/internal/interfaces"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Definitions and constructors
+
+// A symbol expression is either a symbol reference or an expression between ${ and }.
+// A symbol reference is $name, optionally followed by accessors: .Member (exported field, or getter GetMember or IsMember) or [index].
+// Accessors are only applied to symbols that contain something else than text, so $file.txt keeps working for text symbols.
+// An expression can contain symbol references, numbers, quoted strings, parentheses and the operators + - * / %.
+// Using + on operands that are not both numbers concatenates them. An expression can be followed by :format, e.g. ${$price * 2:%.2f}.
+// Text between ${ and } that isn't a valid expression (e.g. ${HOME}) is no symbol expression and stays as it is.
+// Neither are digits between ${ and }, so group references in regular expression replacements (e.g. ${1}) keep working.
+
+type symbolExpression struct {
+	parser        *Parser
+	input         string
+	pos           int
+	referenceOnly bool
+	syntaxOnly    bool
+}
+
+func newSymbolExpression(parser *Parser, input string) *symbolExpression {
+	expression := new(symbolExpression)
+	expression.parser = parser
+	expression.input = input
+	return expression
+}
+
+var symbolNameRegex = regexp.MustCompile(`^\$` + symbolPattern)
+
+var groupReferenceRegex = regexp.MustCompile(`^[0-9]+$`)
+
+// Helper functions
+
+func isIntegerVerb(verb byte) bool {
+	return strings.IndexByte("bcdoOxX", verb) >= 0
+}
+
+func isFloatVerb(verb byte) bool {
+	return strings.IndexByte("eEfFgG", verb) >= 0
+}
+
+// closingIndex returns the index of the closing character matching the opening one at the start of input, skipping quoted text.
+func closingIndex(input string, opening, closing byte) int {
+	nesting := 0
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == opening:
+			nesting++
+		case char == closing:
+			nesting--
+			if nesting == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitFormat separates the format specification (after the last colon outside quotes) from the expression.
+func splitFormat(input string) (string, string) {
+	var quote byte
+	formatStart := -1
+	for i := 0; i < len(input); i++ {
+		char := input[i]
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ':':
+			formatStart = i
+		}
+	}
+	if formatStart == -1 {
+		return input, ""
+	}
+	return input[:formatStart], input[formatStart+1:]
+}
+
+// getterNames returns the names of the methods that can get a member: GetName and IsName, or the name itself if that is a getter.
+func getterNames(name string) []string {
+	for _, prefix := range []string{"Get", "Is"} {
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" && unicode.IsUpper([]rune(rest)[0]) {
+			return []string{name}
+		}
+	}
+	return []string{"Get" + name, "Is" + name}
+}
+
+// exportedName upper-cases the first letter of a member name, as only exported members can be used.
+func exportedName(name string) string {
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// numberFrom returns the value as int64 or float64 if it represents a number.
+func numberFrom(value interface{}) (interface{}, bool) {
+	if value == nil {
+		return nil, false
+	}
+	inputValue := reflect.ValueOf(value)
+	switch inputValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return inputValue.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(inputValue.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return inputValue.Float(), true
+	case reflect.String:
+		text := strings.TrimSpace(inputValue.String())
+		if result, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return result, true
+		}
+		if result, err := strconv.ParseFloat(text, 64); err == nil {
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(number interface{}) float64 {
+	if integer, ok := number.(int64); ok {
+		return float64(integer)
+	}
+	return number.(float64)
+}
+
+func calculate(operator byte, left, right interface{}) (interface{}, error) {
+	leftInt, leftIsInt := left.(int64)
+	rightInt, rightIsInt := right.(int64)
+	if leftIsInt && rightIsInt {
+		switch operator {
+		case '+':
+			return leftInt + rightInt, nil
+		case '-':
+			return leftInt - rightInt, nil
+		case '*':
+			return leftInt * rightInt, nil
+		case '/', '%':
+			if rightInt == 0 {
+				return nil, toErrorf("Division by zero")
+			}
+			if operator == '%' {
+				return leftInt % rightInt, nil
+			}
+			if leftInt%rightInt == 0 {
+				return leftInt / rightInt, nil
+			}
+		}
+	}
+	leftFloat, rightFloat := toFloat(left), toFloat(right)
+	switch operator {
+	case '+':
+		return leftFloat + rightFloat, nil
+	case '-':
+		return leftFloat - rightFloat, nil
+	case '*':
+		return leftFloat * rightFloat, nil
+	}
+	if rightFloat == 0 {
+		return nil, toErrorf("Division by zero")
+	}
+	if operator == '%' {
+		return math.Mod(leftFloat, rightFloat), nil
+	}
+	return leftFloat / rightFloat, nil
+}
+
+// Parser methods
+
+// interpolate replaces all symbol expressions in source by their values.
+// If strict is set, the first expression that can't be evaluated returns an error. Otherwise, such expressions are kept as-is.
+func (parser *Parser) interpolate(source string, strict bool) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(source); {
+		if source[i] != '$' {
+			result.WriteByte(source[i])
+			i++
+			continue
+		}
+		replacement, length, err := parser.evaluateSymbolExpressionAt(source[i:])
+		if err != nil {
+			if strict {
+				return "", err
+			}
+			replacement = source[i : i+length]
+		}
+		if length == 0 {
+			replacement, length = "$", 1
+		}
+		result.WriteString(replacement)
+		i += length
+	}
+	return result.String(), nil
+}
+
+// evaluateSymbolExpressionAt evaluates the symbol expression at the start of input.
+// It returns the replacement text and the length of the expression (0 if there is none).
+func (parser *Parser) evaluateSymbolExpressionAt(input string) (string, int, error) {
+	if strings.HasPrefix(input, "${") {
+		end := closingIndex(input[1:], '{', '}')
+		if end == -1 {
+			return input[:2], 2, nil
+		}
+		length := end + 2
+		expressionText, format := splitFormat(input[2 : length-1])
+		if groupReferenceRegex.MatchString(input[2:length-1]) || !parser.isExpression(expressionText) {
+			return input[:length], length, nil
+		}
+		value, err := newSymbolExpression(parser, expressionText).evaluate()
+		if err != nil {
+			return "", length, err
+		}
+		result, err := parser.formatValue(value, format)
+		return result, length, err
+	}
+	symbolName := symbolNameRegex.FindString(input)
+	if symbolName == "" {
+		return "", 0, nil
+	}
+	if _, isNonText := parser.symbols.NonTextSymbol(symbolName); !isNonText {
+		return parser.replaceSymbolValue(symbolName), len(symbolName), nil
+	}
+	expression := newSymbolExpression(parser, input)
+	expression.referenceOnly = true
+	value, err := expression.reference()
+	if err != nil {
+		return "", expression.pos, err
+	}
+	return parser.textOf(value), expression.pos, nil
+}
+
+// isExpression checks the syntax of an expression without evaluating it.
+func (parser *Parser) isExpression(input string) bool {
+	expression := newSymbolExpression(parser, input)
+	expression.syntaxOnly = true
+	_, err := expression.evaluate()
+	return err == nil
+}
+
+func (parser *Parser) formatValue(value interface{}, format string) (string, error) {
+	if format == "" {
+		return parser.textOf(value), nil
+	}
+	verb := format[len(format)-1]
+	argument := value
+	if number, ok := numberFrom(value); ok {
+		argument = number
+		if isFloatVerb(verb) {
+			argument = toFloat(number)
+		} else if float, isFloat := number.(float64); isFloat && isIntegerVerb(verb) && float == math.Trunc(float) {
+			argument = int64(float)
+		}
+	} else if slimentity.IsObject(value) {
+		argument = parser.objectSerializer.Serialize(value)
+	}
+	result := fmt.Sprintf(format, argument)
+	if strings.Contains(result, "%!") {
+		return "", toErrorf("Invalid format '%v' for value '%v'", format, parser.textOf(value))
+	}
+	return result, nil
+}
+
+// textOf returns the text representation of a value, serializing objects via their ToString method.
+func (parser *Parser) textOf(value interface{}) string {
+	return parser.serializedText(slimentity.TransformCallResult([]reflect.Value{reflect.ValueOf(value)}))
+}
+
+func (parser *Parser) serializedText(entity slimentity.SlimEntity) string {
+	if slimentity.IsSlimList(entity) {
+		entries := []string{}
+		for _, entry := range *entity.(*slimentity.SlimList) {
+			entries = append(entries, parser.serializedText(entry))
+		}
+		return "[" + strings.Join(entries, ", ") + "]"
+	}
+	if slimentity.IsObject(entity) {
+		return parser.objectSerializer.Serialize(entity)
+	}
+	return entity.(string)
+}
+
+// symbolExpression methods
+
+func (expression *symbolExpression) errorf(template string, param ...interface{}) error {
+	// a reference is embedded in other text, so we only show the part that was evaluated
+	source := expression.input
+	if expression.referenceOnly {
+		source = source[:expression.pos]
+	}
+	return toErrorf("Expression '%v': %v", source, fmt.Sprintf(template, param...))
+}
+
+func (expression *symbolExpression) atEnd() bool {
+	return expression.pos >= len(expression.input)
+}
+
+func (expression *symbolExpression) current() byte {
+	if expression.atEnd() {
+		return 0
+	}
+	return expression.input[expression.pos]
+}
+
+func (expression *symbolExpression) skipSpaces() {
+	for !expression.atEnd() && unicode.IsSpace(rune(expression.current())) {
+		expression.pos++
+	}
+}
+
+// evaluate evaluates the complete input as expression.
+func (expression *symbolExpression) evaluate() (interface{}, error) {
+	value, err := expression.sum()
+	if err != nil {
+		return nil, err
+	}
+	expression.skipSpaces()
+	if !expression.atEnd() {
+		return nil, expression.errorf("unexpected '%v'", expression.input[expression.pos:])
+	}
+	return value, nil
+}
+
+func (expression *symbolExpression) sum() (interface{}, error) {
+	left, err := expression.product()
+	for err == nil {
+		expression.skipSpaces()
+		operator := expression.current()
+		if operator != '+' && operator != '-' {
+			return left, nil
+		}
+		expression.pos++
+		var right interface{}
+		if right, err = expression.product(); err == nil {
+			left, err = expression.apply(operator, left, right)
+		}
+	}
+	return nil, err
+}
+
+func (expression *symbolExpression) product() (interface{}, error) {
+	left, err := expression.unary()
+	for err == nil {
+		expression.skipSpaces()
+		operator := expression.current()
+		if operator != '*' && operator != '/' && operator != '%' {
+			return left, nil
+		}
+		expression.pos++
+		var right interface{}
+		if right, err = expression.unary(); err == nil {
+			left, err = expression.apply(operator, left, right)
+		}
+	}
+	return nil, err
+}
+
+func (expression *symbolExpression) unary() (interface{}, error) {
+	expression.skipSpaces()
+	if expression.current() != '-' {
+		return expression.primary()
+	}
+	expression.pos++
+	value, err := expression.unary()
+	if err != nil {
+		return nil, err
+	}
+	return expression.apply('-', int64(0), value)
+}
+
+func (expression *symbolExpression) primary() (interface{}, error) {
+	expression.skipSpaces()
+	char := expression.current()
+	switch {
+	case expression.atEnd():
+		return nil, expression.errorf("unexpected end")
+	case char == '$':
+		return expression.reference()
+	case char == '"' || char == '\'':
+		return expression.quotedString(char)
+	case char == '(':
+		expression.pos++
+		value, err := expression.sum()
+		if err != nil {
+			return nil, err
+		}
+		expression.skipSpaces()
+		if expression.current() != ')' {
+			return nil, expression.errorf("missing ')'")
+		}
+		expression.pos++
+		return value, nil
+	case char >= '0' && char <= '9' || char == '.':
+		return expression.number()
+	default:
+		return nil, expression.errorf("unexpected '%v'", expression.input[expression.pos:])
+	}
+}
+
+func (expression *symbolExpression) number() (interface{}, error) {
+	start := expression.pos
+	for !expression.atEnd() && (unicode.IsDigit(rune(expression.current())) || expression.current() == '.') {
+		expression.pos++
+	}
+	text := expression.input[start:expression.pos]
+	if number, ok := numberFrom(text); ok {
+		return number, nil
+	}
+	return nil, expression.errorf("'%v' is not a valid number", text)
+}
+
+func (expression *symbolExpression) quotedString(quote byte) (interface{}, error) {
+	end := strings.IndexByte(expression.input[expression.pos+1:], quote)
+	if end == -1 {
+		return nil, expression.errorf("missing closing quote")
+	}
+	value := expression.input[expression.pos+1 : expression.pos+1+end]
+	expression.pos += end + 2
+	return value, nil
+}
+
+// reference evaluates a symbol reference including its accessors.
+func (expression *symbolExpression) reference() (interface{}, error) {
+	symbolName := symbolNameRegex.FindString(expression.input[expression.pos:])
+	if symbolName == "" {
+		return nil, expression.errorf("'$' must be followed by a symbol name")
+	}
+	expression.pos += len(symbolName)
+	var value interface{}
+	if !expression.syntaxOnly {
+		if value = expression.parser.symbols.Get(symbolName); value == nil {
+			return nil, expression.errorf("symbol '%v' is not defined", symbolName)
+		}
+	}
+	var err error
+	for err == nil {
+		switch {
+		case expression.current() == '.' && expression.pos+1 < len(expression.input) && unicode.IsLetter(rune(expression.input[expression.pos+1])):
+			expression.pos++
+			start := expression.pos
+			for !expression.atEnd() && (unicode.IsLetter(rune(expression.current())) || unicode.IsDigit(rune(expression.current())) || expression.current() == '_') {
+				expression.pos++
+			}
+			if !expression.syntaxOnly {
+				value, err = expression.member(value, expression.input[start:expression.pos])
+			}
+		case expression.current() == '[':
+			end := closingIndex(expression.input[expression.pos:], '[', ']')
+			if end == -1 {
+				return nil, expression.errorf("missing ']'")
+			}
+			indexText := expression.input[expression.pos+1 : expression.pos+end]
+			expression.pos += end + 1
+			value, err = expression.index(value, indexText)
+		default:
+			return value, nil
+		}
+	}
+	return nil, err
+}
+
+// checkAccess returns an AccessDeniedError if the policy doesn't allow using the member of the value.
+// The error is returned as is, so the instruction using the expression results in an ACCESS_DENIED exception.
+func (expression *symbolExpression) checkAccess(value interface{}, memberName string, access interfaces.Access) error {
+	if expression.parser.policy == nil {
+		return nil
+	}
+	return expression.parser.policy.CheckAccess(reflect.TypeOf(value), memberName, access)
+}
+
+// member gets the value of an exported field, or the result of a getter (GetName or IsName without parameters), if the policy
+// allows that. Other methods are not called, so expanding a symbol has no side effects.
+func (expression *symbolExpression) member(value interface{}, memberName string) (result interface{}, err error) {
+	instanceValue := reflect.ValueOf(value)
+	name := exportedName(memberName)
+	for _, getterName := range getterNames(name) {
+		if method := instanceValue.MethodByName(getterName); method.IsValid() && method.Type().NumIn() == 0 {
+			if err := expression.checkAccess(value, getterName, interfaces.MethodCall); err != nil {
+				return nil, err
+			}
+			return expression.callGetter(method, memberName)
+		}
+	}
+	for instanceValue.Kind() == reflect.Ptr || instanceValue.Kind() == reflect.Interface {
+		if instanceValue.IsNil() {
+			return nil, expression.errorf("cannot get '%v' from nil", memberName)
+		}
+		instanceValue = instanceValue.Elem()
+	}
+	if instanceValue.Kind() == reflect.Struct {
+		if field := instanceValue.FieldByName(name); field.IsValid() && field.CanInterface() {
+			if err := expression.checkAccess(value, name, interfaces.FieldGet); err != nil {
+				return nil, err
+			}
+			return field.Interface(), nil
+		}
+	}
+	return nil, expression.errorf("no member '%v' in '%v'", memberName, reflect.TypeOf(value))
+}
+
+func (expression *symbolExpression) callGetter(method reflect.Value, memberName string) (result interface{}, err error) {
+	defer func() {
+		if panicData := recover(); panicData != nil {
+			result = nil
+			err = expression.errorf("'%v' panicked: %v", memberName, panicData)
+		}
+	}()
+	returnValues := method.Call(nil)
+	if len(returnValues) == 0 {
+		return nil, expression.errorf("'%v' does not return a value", memberName)
+	}
+	last := returnValues[len(returnValues)-1]
+	if last.Type() == reflect.TypeOf((*error)(nil)).Elem() && !last.IsNil() {
+		return nil, expression.errorf("'%v' returned error: %v", memberName, last.Interface())
+	}
+	return returnValues[0].Interface(), nil
+}
+
+// index gets an element of a slice, array or string by position, or of a map by key.
+// The index can be an expression. An index that is a plain identifier is taken literally as a map key.
+func (expression *symbolExpression) index(value interface{}, indexText string) (interface{}, error) {
+	var key interface{} = strings.TrimSpace(indexText)
+	if !expression.parser.symbols.IsValidSymbolName(key.(string)) {
+		indexExpression := newSymbolExpression(expression.parser, indexText)
+		indexExpression.syntaxOnly = expression.syntaxOnly
+		var err error
+		if key, err = indexExpression.evaluate(); err != nil {
+			return nil, err
+		}
+	}
+	if expression.syntaxOnly {
+		return nil, nil
+	}
+	container := reflect.ValueOf(value)
+	for container.Kind() == reflect.Ptr || container.Kind() == reflect.Interface {
+		if container.IsNil() {
+			return nil, expression.errorf("cannot index nil")
+		}
+		container = container.Elem()
+	}
+	switch container.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String:
+		number, ok := numberFrom(key)
+		position, isInt := number.(int64)
+		if !ok || !isInt {
+			return nil, expression.errorf("index '%v' is not an integer", key)
+		}
+		if container.Kind() == reflect.String {
+			runes := []rune(container.String())
+			if position < 0 || position >= int64(len(runes)) {
+				return nil, expression.errorf("index %v out of range (length %v)", position, len(runes))
+			}
+			return string(runes[position]), nil
+		}
+		if position < 0 || position >= int64(container.Len()) {
+			return nil, expression.errorf("index %v out of range (length %v)", position, container.Len())
+		}
+		return container.Index(int(position)).Interface(), nil
+	case reflect.Map:
+		keyValue := reflect.ValueOf(key)
+		if !keyValue.Type().AssignableTo(container.Type().Key()) {
+			parsedKey, err := expression.parser.Parse(expression.parser.textOf(key), container.Type().Key())
+			if err != nil {
+				return nil, expression.errorf("key '%v' does not match type '%v'", key, container.Type().Key())
+			}
+			keyValue = reflect.ValueOf(parsedKey)
+		}
+		element := container.MapIndex(keyValue)
+		if !element.IsValid() {
+			return nil, expression.errorf("key '%v' not found", key)
+		}
+		return element.Interface(), nil
+	default:
+		return nil, expression.errorf("cannot index '%v'", reflect.TypeOf(value))
+	}
+}
+
+func (expression *symbolExpression) apply(operator byte, left, right interface{}) (interface{}, error) {
+	if expression.syntaxOnly {
+		return left, nil
+	}
+	leftNumber, leftIsNumber := numberFrom(left)
+	rightNumber, rightIsNumber := numberFrom(right)
+	if leftIsNumber && rightIsNumber {
+		result, err := calculate(operator, leftNumber, rightNumber)
+		if err != nil {
+			return nil, expression.errorf("%v", err)
+		}
+		return result, nil
+	}
+	if operator == '+' {
+		return expression.parser.textOf(left) + expression.parser.textOf(right), nil
+	}
+	return nil, expression.errorf("operator '%c' needs numbers but got '%v' and '%v'",
+		operator, expression.parser.textOf(left), expression.parser.textOf(right))
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimprocessor
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+type expressionOrder struct {
+	Items    []string
+	Prices   map[string]float64
+	Customer *demoStruct1
+	quantity int
+}
+
+func (order *expressionOrder) GetTotal() float64 {
+	total := 0.0
+	for _, price := range order.Prices {
+		total += price
+	}
+	return total
+}
+
+func (order *expressionOrder) GetQuantity() int {
+	return order.quantity
+}
+
+func (order *expressionOrder) IsEmpty() bool {
+	return order.quantity == 0
+}
+
+func (order *expressionOrder) Clear() int {
+	order.quantity = 0
+	return order.quantity
+}
+
+func (order *expressionOrder) GetFailing() (int, error) {
+	return 0, fmt.Errorf("no can do")
+}
+
+func (order *expressionOrder) ToString() string {
+	return fmt.Sprintf("order of %v", order.quantity)
+}
+
+func initExpressionParser() *Parser {
+	parser := initParser()
+	parser.symbols.Set("a", "5")
+	parser.symbols.Set("b", "2.5")
+	parser.symbols.Set("text", "hello")
+	parser.symbols.Set("list", slimentity.NewSlimListContaining([]slimentity.SlimEntity{"one", "two", "three"}))
+	parser.symbols.Set("order", &expressionOrder{
+		Items:    []string{"cup", "saucer"},
+		Prices:   map[string]float64{"cup": 1.5, "saucer": 2},
+		Customer: &demoStruct1{"John"},
+		quantity: 3,
+	})
+	return parser
+}
+
+func TestSymbolExpressionArithmetic(t *testing.T) {
+	parser := initExpressionParser()
+	assertInterpolation := func(expected string, input string, description string) {
+		result, err := parser.interpolate(input, true)
+		assert.Equals(t, nil, err, description+" has no error")
+		assert.Equals(t, expected, result, description)
+	}
+	assertInterpolation("6", "${$a + 1}", "Add integer to symbol")
+	assertInterpolation("7.5", "${$a+$b}", "Add float symbol")
+	assertInterpolation("-3", "${-$a + 2}", "Unary minus")
+	assertInterpolation("20", "${($a + 5) * 2}", "Parentheses")
+	assertInterpolation("2", "${$a / 2.5}", "Division with whole result")
+	assertInterpolation("2.5", "${$a / 2}", "Division with fraction")
+	assertInterpolation("1", "${$a % 2}", "Modulo")
+	assertInterpolation("hello world", "${$text + ' world'}", "Concatenation with quoted string")
+	assertInterpolation("hello5", "${$text + $a}", "Concatenation with number symbol")
+	assertInterpolation("value 6 and hello", "value ${$a + 1} and $text", "Expression and plain symbol combined")
+	assertInterpolation("7.50", "${$a + $b:%.2f}", "Float format")
+	assertInterpolation("005", "${$a:%03d}", "Integer format")
+	assertInterpolation("a:b", "${'a:b'}", "Colon in quoted string is no format")
+}
+
+func TestSymbolExpressionAccessors(t *testing.T) {
+	parser := initExpressionParser()
+	assertInterpolation := func(expected string, input string, description string) {
+		result, err := parser.interpolate(input, true)
+		assert.Equals(t, nil, err, description+" has no error")
+		assert.Equals(t, expected, result, description)
+	}
+	assertInterpolation("3.5", "$order.Total", "Getter via Get prefix")
+	assertInterpolation("3.5", "$order.getTotal", "Getter by its own name")
+	assertInterpolation("3", "$order.quantity", "Getter of unexported field")
+	assertInterpolation("false", "$order.empty", "Getter via Is prefix")
+	assertInterpolation("John", "$order.Customer", "Field with object serialized")
+	assertInterpolation("saucer", "$order.Items[1]", "Index on slice field")
+	assertInterpolation("1.5", "$order.Prices[cup]", "Map with literal key")
+	assertInterpolation("2", "$order.Prices['saucer']", "Map with quoted key")
+	assertInterpolation("three", "$list[2]", "Index on list symbol")
+	assertInterpolation("two", "$list[$a - 4]", "Index with expression")
+	assertInterpolation("Total: 7", "Total: ${$order.Total * 2}", "Method in expression")
+	assertInterpolation("order of 3.", "$order.", "Trailing period is no accessor")
+	assertInterpolation("hello.txt", "$text.txt", "No accessors on text symbols")
+	assertInterpolation("e", "${$text[1]}", "Index on text in expression")
+	assertInterpolation("$undefined.Total", "$undefined.Total", "Undefined symbol stays as-is")
+}
+
+func TestSymbolExpressionErrors(t *testing.T) {
+	parser := initExpressionParser()
+	assertError := func(expected string, input string, description string) {
+		_, err := parser.interpolate(input, true)
+		assert.IsTrue(t, err != nil, description+" returns error")
+		assert.Equals(t, expected, err.Error(), description)
+	}
+	assertError("Expression '$a / 0': Division by zero", "${$a / 0}", "Division by zero")
+	assertError("Expression '$text * 2': operator '*' needs numbers but got 'hello' and '2'", "${$text * 2}", "Multiply text")
+	assertError("Expression '$c + 1': symbol '$c' is not defined", "${$c + 1}", "Undefined symbol")
+	assertError("Expression '$order.Nothing': no member 'Nothing' in '*slimprocessor.expressionOrder'", "$order.Nothing and more", "Unknown member")
+	assertError("Expression '$order.Failing': 'Failing' returned error: no can do", "$order.Failing", "Method returning error")
+	assertError("Expression '$order.Clear': no member 'Clear' in '*slimprocessor.expressionOrder'", "$order.Clear", "Method that isn't a getter")
+	assert.Equals(t, "3", parser.ReplaceSymbolsIn("$order.quantity"), "Method that isn't a getter not called")
+	assertError("Expression '$order.Customer.ToString': no member 'ToString' in '*slimprocessor.demoStruct1'", "$order.Customer.ToString", "ToString isn't a getter")
+	assertError("Expression '$list[3]': index 3 out of range (length 3)", "$list[3]", "Index out of range")
+	assertError("Expression '$list[x]': index 'x' is not an integer", "$list[x]", "Non-numerical index")
+	assertError("Expression '$order.Prices[mug]': key 'mug' not found", "$order.Prices[mug]", "Missing key")
+	assertError("Invalid format '%d' for value 'hello'", "${$text:%d}", "Wrong format")
+	assert.Equals(t, "${$a / 0} and 5", parser.ReplaceSymbolsIn("${$a / 0} and $a"), "ReplaceSymbolsIn keeps invalid expressions")
+}
+
+func TestSymbolExpressionNoExpression(t *testing.T) {
+	parser := initExpressionParser()
+	assertLiteral := func(input string, description string) {
+		result, err := parser.interpolate(input, true)
+		assert.Equals(t, nil, err, description+" has no error")
+		assert.Equals(t, input, result, description)
+	}
+	assertLiteral("echo ${HOME}", "Shell variable")
+	assertLiteral("cost ${", "Unmatched opening")
+	assertLiteral("${$a +}", "Missing operand")
+	assertLiteral("${($a}", "Missing parenthesis")
+	assertLiteral("${a + 1}", "Symbol without $")
+	assertLiteral("${1}${12}", "Regular expression group references")
+	assertLiteral("${$c +}", "Undefined symbol in invalid expression")
+	assertLiteral("${$list[x +]}", "Invalid index expression")
+	result, err := parser.interpolate("${$a + 1", true)
+	assert.Equals(t, nil, err, "Missing closing brace has no error")
+	assert.Equals(t, "${5 + 1", result, "Missing closing brace keeps the opening, symbol replaced")
+}
+
+func TestSymbolExpressionInParse(t *testing.T) {
+	parser := initExpressionParser()
+	result, err := parser.Parse("${$a * 2}", reflect.TypeOf(0))
+	assert.Equals(t, nil, err, "No error parsing expression to int")
+	assert.Equals(t, 10, result, "Expression parsed to int")
+	_, err = parser.Parse("${$a / 0}", reflect.TypeOf(0))
+	assert.Equals(t, "Expression '$a / 0': Division by zero", err.Error(), "Expression error surfaces in Parse")
+	result, err = parser.Parse("echo ${HOME}", reflect.TypeOf(""))
+	assert.Equals(t, nil, err, "No error parsing text that isn't an expression")
+	assert.Equals(t, "echo ${HOME}", result, "Text that isn't an expression stays as-is")
+	result, err = parser.Parse("cost ${", reflect.TypeOf(""))
+	assert.Equals(t, nil, err, "No error parsing unmatched opening")
+	assert.Equals(t, "cost ${", result, "Unmatched opening stays as-is")
+}
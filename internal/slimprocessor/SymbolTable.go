@@ -15,17 +15,22 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sync"
 )
 
 // Definitions and constructors
 
-// SymbolTable contains the FitNesse symbols.
-type SymbolTable map[string]interface{}
+// SymbolTable contains the FitNesse symbols. It is safe for concurrent use.
+type SymbolTable struct {
+	mutex  sync.RWMutex
+	values map[string]interface{}
+}
 
 // NewSymbolTable creates a new Symbol table.
 func NewSymbolTable() *SymbolTable {
-	symbols := make(SymbolTable)
-	return &symbols
+	symbols := new(SymbolTable)
+	symbols.values = make(map[string]interface{})
+	return symbols
 }
 
 // Methods
@@ -67,10 +72,23 @@ func (symbols *SymbolTable) Get(symbolName string) interface{} {
 	return symbolValue
 }
 
+// Values returns (a copy of) the symbols and their values.
+func (symbols *SymbolTable) Values() map[string]interface{} {
+	symbols.mutex.RLock()
+	defer symbols.mutex.RUnlock()
+	values := make(map[string]interface{}, len(symbols.values))
+	for name, value := range symbols.values {
+		values[name] = value
+	}
+	return values
+}
+
 // Set sets an entry in the symbol table.
 func (symbols *SymbolTable) Set(symbolName string, value interface{}) error {
 	if symbols.IsValidSymbolName(symbolName) {
-		(*symbols)[symbolName] = value
+		symbols.mutex.Lock()
+		defer symbols.mutex.Unlock()
+		symbols.values[symbolName] = value
 		return nil
 	}
 	return fmt.Errorf("Invalid symbol name: %v", symbolName)
@@ -78,11 +96,15 @@ func (symbols *SymbolTable) Set(symbolName string, value interface{}) error {
 
 // Length gets the number of items in the symbol table. TODO: not used. Optimize interfaces.
 func (symbols *SymbolTable) Length() int {
-	return len(*symbols)
+	symbols.mutex.RLock()
+	defer symbols.mutex.RUnlock()
+	return len(symbols.values)
 }
 
 // ValueOf should be eliminated.
 func (symbols *SymbolTable) ValueOf(symbolName string) (interface{}, bool) {
-	symbolValue, ok := (*symbols)[symbolName[1:]]
+	symbols.mutex.RLock()
+	defer symbols.mutex.RUnlock()
+	symbolValue, ok := symbols.values[symbolName[1:]]
 	return symbolValue, ok
 }
@@ -12,7 +12,9 @@
 package slimprocessor
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/essenius/slim4go/internal/assert"
@@ -35,11 +37,38 @@ func TestSymbolTable(t *testing.T) {
 	aDemoStruct1.Parse("hi from aDemoStruct1")
 	assert.Equals(t, nil, symbols.Set("test3", aDemoStruct1), "Set test3 to object")
 	assert.Equals(t, "Invalid symbol name: $_test3", symbols.Set("$_test3", "_value3").Error(), "invalid name $_test3")
+	values := symbols.Values()
+	assert.Equals(t, 3, len(values), "Three values")
+	assert.Equals(t, "value1", values["test1"], "Text value")
+	assert.Equals(t, aDemoStruct1, values["test3"], "Object value")
+	values["test1"] = "changed"
+	assert.Equals(t, "value1", symbols.Get("$test1"), "Values returns a copy")
+}
+
+func TestSymbolTableConcurrentUse(t *testing.T) {
+	symbols := NewSymbolTable()
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := 0; j < 100; j++ {
+				symbols.Set(fmt.Sprintf("symbol%v", i), j)
+				symbols.Get("$symbol0")
+				symbols.NonTextSymbol("$symbol1")
+				symbols.Values()
+				symbols.Length()
+			}
+		}()
+	}
+	waitGroup.Wait()
+	assert.Equals(t, 10, symbols.Length(), "All symbols set")
+	assert.Equals(t, 99, symbols.Get("$symbol9"), "Last value stored")
 }
 
 func TestSymbolTableNonString(t *testing.T) {
 	symbols := NewSymbolTable()
-	(*symbols)["test1"] = NewMessenger()
+	symbols.values["test1"] = NewMessenger()
 	assert.Equals(t, nil, symbols.Set("test2", "text2"), "Set with text")
 	result, ok := symbols.NonTextSymbol("$test1")
 	assert.IsTrue(t, ok, "Identified non-text symbol")
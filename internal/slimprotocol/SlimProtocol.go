@@ -28,6 +28,16 @@ func AbortTest(reason string) string {
 	return "__EXCEPTION__:ABORT_SLIM_TEST:message:<<" + reason + ">>"
 }
 
+// AccessDenied returns an exception that an access policy doesn't allow using a member of a fixture.
+func AccessDenied(memberName string, fixtureName string, reason string) string {
+	return Exceptionf("ACCESS_DENIED %v %v: %v", memberName, fixtureName, reason)
+}
+
+// AmbiguousFixture returns an exception that a fixture name matches fixtures of more than one package.
+func AmbiguousFixture(fixtureName string, candidates []string) string {
+	return Exceptionf("AMBIGUOUS_CLASS %v: matches %v. Use the full name, an alias or a more specific import", fixtureName, strings.Join(candidates, ", "))
+}
+
 // Bye is the incoming instruction to quit.
 func Bye() string {
 	return "bye"
@@ -52,19 +62,36 @@ func Exception(exception string) string {
 	return AbortSuite(match[2])
 }
 
+// ExceptionWithStack returns the message in the FitNesse exception format, followed by a stack trace (if not empty).
+// FitNesse shows the message, and the complete exception (including the stack trace) in a collapsible section.
+func ExceptionWithStack(exception string, stack string) string {
+	if stack == "" {
+		return Exception(exception)
+	}
+	return Exception(exception) + "\n" + strings.TrimSuffix(stack, "\n")
+}
+
 // Exceptionf returns a message using a formatting
 func Exceptionf(template string, param ...interface{}) string {
 	return Exception(fmt.Sprintf(template, param...))
 }
 
+// IsException returns whether a response is an exception.
+func IsException(response string) bool {
+	return strings.HasPrefix(response, "__EXCEPTION__:")
+}
+
 // MalformedInstruction returns the exception that the insinstruction could not be parsed successfully.
 func MalformedInstruction(instruction string) string {
 	return Exceptionf("MALFORMED_INSTRUCTION %v", instruction)
 }
 
-// NoFixture returns an exception message that the fixture was not found.
-func NoFixture(fixtureName string) string {
-	return Exceptionf("NO_CLASS %v", fixtureName)
+// NoFixture returns an exception message that the fixture was not found, with the closest matches if there are any.
+func NoFixture(fixtureName string, closestMatches ...string) string {
+	if len(closestMatches) == 0 {
+		return Exceptionf("NO_CLASS %v", fixtureName)
+	}
+	return Exceptionf("NO_CLASS %v. Closest matches: %v", fixtureName, strings.Join(closestMatches, ", "))
 }
 
 // NoConstructor returns an exception that no suitable constructor could be found.
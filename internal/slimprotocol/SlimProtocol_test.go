@@ -24,11 +24,36 @@ func TestSlimProtocolException(t *testing.T) {
 	assert.Equals(t, "__EXCEPTION__:ABORT_SLIM_TEST:message:<<Quit>>", Exception("aborttest:Quit"), "Abort Test")
 }
 
+func TestSlimProtocolAccessDenied(t *testing.T) {
+	assert.Equals(t, "__EXCEPTION__:message:<<ACCESS_DENIED Close sut.Database: not a fixture>>",
+		AccessDenied("Close", "sut.Database", "not a fixture"), "Access denied")
+}
+
+func TestSlimProtocolAmbiguousFixture(t *testing.T) {
+	assert.Equals(t, "__EXCEPTION__:message:<<AMBIGUOUS_CLASS fixtures.Order: matches acme.com/fixtures.Order, example.com/fixtures.Order. "+
+		"Use the full name, an alias or a more specific import>>",
+		AmbiguousFixture("fixtures.Order", []string{"acme.com/fixtures.Order", "example.com/fixtures.Order"}), "Ambiguous fixture")
+}
+
+func TestSlimProtocolExceptionWithStack(t *testing.T) {
+	assert.Equals(t, "__EXCEPTION__:message:<<error>>", ExceptionWithStack("error", ""), "No stack")
+	assert.Equals(t, "__EXCEPTION__:message:<<error>>\nmain.f\n\tmain.go:1", ExceptionWithStack("error", "main.f\n\tmain.go:1\n"), "Stack")
+}
+
+func TestSlimProtocolIsException(t *testing.T) {
+	assert.IsTrue(t, IsException(Exception("oops")), "Exception")
+	assert.IsTrue(t, IsException(AbortTest("stop")), "Abort test")
+	assert.IsTrue(t, !IsException("OK"), "OK")
+	assert.IsTrue(t, !IsException(Void()), "Void")
+}
+
 func TestSlimProtocolExceptionf(t *testing.T) {
 	assert.Equals(t, "bye", Bye(), "Bye")
 	assert.Equals(t, "__EXCEPTION__:message:<<COULD_NOT_INVOKE_CONSTRUCTOR myFixture>>", CouldNotInvokeConstructor("myFixture"), "Could not invoke constructor")
 	assert.Equals(t, "__EXCEPTION__:message:<<MALFORMED_INSTRUCTION qwe>>", MalformedInstruction("qwe"), "Malformed Instruction")
 	assert.Equals(t, "__EXCEPTION__:message:<<NO_CLASS testFixture>>", NoFixture("testFixture"), "No Fixture")
+	assert.Equals(t, "__EXCEPTION__:message:<<NO_CLASS testFixtur. Closest matches: test.TestFixture, test.TextFixture>>",
+		NoFixture("testFixtur", "test.TestFixture", "test.TextFixture"), "No Fixture with closest matches")
 	assert.Equals(t, "__EXCEPTION__:message:<<NO_CONVERTER_FOR_ARGUMENT_NUMBER 1>>", NoConverterForArgumentNumber("1"), "No Covnerter For Argument Number")
 	assert.Equals(t, "__EXCEPTION__:message:<<NO_CONSTRUCTOR NewObject>>", NoConstructor("NewObject"), "No Constructor")
 	assert.Equals(t, "__EXCEPTION__:message:<<NO_INSTANCE myInstance>>", NoInstance("myInstance"), "No Instance")
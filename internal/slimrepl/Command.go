@@ -0,0 +1,108 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimrepl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Commands are human friendly lines that translate to a Slim instruction, e.g.
+//   make conv TemperatureConverter
+//   $temp = call conv convertTo 68F C
+// Arguments are separated by white space; use double quotes for arguments containing white space.
+
+// Definitions
+
+var usage = map[string]string{
+	"make":   "make <instance> <fixture> [arguments]",
+	"call":   "call <instance> <method> [arguments]",
+	"assign": "assign <symbol> <value>",
+	"import": "import <path>",
+}
+
+// Helpers
+
+// splitArguments splits a line on white space, keeping double quoted parts together.
+func splitArguments(line string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inToken, quoted := false, false
+	for _, character := range line {
+		switch {
+		case character == '"':
+			quoted = !quoted
+			inToken = true
+		case !quoted && (character == ' ' || character == '\t'):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(character)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("Missing closing quote")
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+func newInstruction(id string, command string, arguments []string) *slimentity.SlimList {
+	instruction := slimentity.NewSlimListContaining(slimentity.SlimList{id, command})
+	for _, argument := range arguments {
+		instruction.Append(argument)
+	}
+	return instruction
+}
+
+func isAssignment(tokens []string) bool {
+	return len(tokens) >= 3 && strings.HasPrefix(tokens[0], "$") && tokens[1] == "=" && tokens[2] == "call"
+}
+
+// Methods
+
+// ParseCommand converts the tokens of a command into a Slim instruction with the specified id.
+func ParseCommand(id string, tokens []string) (*slimentity.SlimList, error) {
+	if isAssignment(tokens) {
+		if len(tokens) < 5 {
+			return nil, fmt.Errorf("Usage: $<symbol> = %v", usage["call"])
+		}
+		return newInstruction(id, "callAndAssign", append([]string{tokens[0][1:]}, tokens[3:]...)), nil
+	}
+	switch tokens[0] {
+	case "make", "call":
+		if len(tokens) < 3 {
+			return nil, fmt.Errorf("Usage: %v", usage[tokens[0]])
+		}
+		return newInstruction(id, tokens[0], tokens[1:]), nil
+	case "assign":
+		if len(tokens) != 3 {
+			return nil, fmt.Errorf("Usage: %v", usage["assign"])
+		}
+		return newInstruction(id, "assign", []string{strings.TrimPrefix(tokens[1], "$"), tokens[2]}), nil
+	case "import":
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("Usage: %v", usage["import"])
+		}
+		return newInstruction(id, "import", tokens[1:]), nil
+	default:
+		return nil, fmt.Errorf("Unknown command '%v'. Type help to see the commands", tokens[0])
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimrepl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestCommandSplitArguments(t *testing.T) {
+	tokens, err := splitArguments(`call conv  convertTo "68 F" C ""`)
+	assert.Equals(t, nil, err, "no error")
+	assert.Equals(t, "call|conv|convertTo|68 F|C|", strings.Join(tokens, "|"), "tokens")
+	_, err = splitArguments(`call "conv`)
+	assert.Equals(t, "Missing closing quote", err.Error(), "unbalanced quotes")
+}
+
+func TestCommandParse(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"make conv TemperatureConverter", "[id, make, conv, TemperatureConverter]"},
+		{"make conv Fixture 1 2", "[id, make, conv, Fixture, 1, 2]"},
+		{"call conv convertTo 68F C", "[id, call, conv, convertTo, 68F, C]"},
+		{"$temp = call conv convertTo 68F C", "[id, callAndAssign, temp, conv, convertTo, 68F, C]"},
+		{"assign $x 12", "[id, assign, x, 12]"},
+		{"assign y 13", "[id, assign, y, 13]"},
+		{"import demofixtures", "[id, import, demofixtures]"},
+		{"make conv", "Usage: make <instance> <fixture> [arguments]"},
+		{"call conv", "Usage: call <instance> <method> [arguments]"},
+		{"$x = call conv", "Usage: $<symbol> = call <instance> <method> [arguments]"},
+		{"assign x", "Usage: assign <symbol> <value>"},
+		{"import", "Usage: import <path>"},
+		{"run it", "Unknown command 'run'. Type help to see the commands"},
+	}
+	for _, test := range tests {
+		tokens, _ := splitArguments(test.line)
+		instruction, err := ParseCommand("id", tokens)
+		if err != nil {
+			assert.Equals(t, test.expected, err.Error(), test.line)
+			continue
+		}
+		assert.Equals(t, test.expected, instruction.ToString(), test.line)
+	}
+}
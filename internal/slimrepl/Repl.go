@@ -0,0 +1,179 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimrepl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/interfaces"
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// The REPL (read-eval-print loop) allows trying out fixtures interactively. It translates commands into
+// Slim instructions, runs them via the Slim interpreter (as FitNesse would) and prints the results.
+
+// Definitions and constructors
+
+// Repl runs commands against a Slim interpreter.
+type Repl struct {
+	interpreter interfaces.SlimInterpreter
+	parser      interfaces.Parser
+	symbols     map[string]string
+	count       int
+}
+
+const prompt = "slim> "
+
+const help = `Commands:
+  make <instance> <fixture> [arguments]     create an instance of a fixture
+  call <instance> <method> [arguments]      call a method (or get/set a field)
+  $<symbol> = call <instance> <method> ...  call a method and assign the result to a symbol
+  assign <symbol> <value>                   assign a value to a symbol
+  import <path>                             import a fixture path
+  symbols                                   show the symbols
+  help                                      show this text
+  quit                                      leave
+Use $<symbol> in arguments to use a symbol, and double quotes for arguments containing spaces.
+`
+
+var exceptionMessageRegex = regexp.MustCompile(`message:<<(?s)(.*)>>`)
+
+// NewRepl creates a new Repl.
+func NewRepl(interpreter interfaces.SlimInterpreter) *Repl {
+	repl := new(Repl)
+	repl.interpreter = interpreter
+	repl.symbols = make(map[string]string)
+	return repl
+}
+
+// SetParser specifies the parser that expands symbols in assigned values, so symbols shows what they expand to.
+func (repl *Repl) SetParser(parser interfaces.Parser) {
+	repl.parser = parser
+}
+
+// Helpers
+
+func (repl *Repl) assignedValue(value string) string {
+	if repl.parser == nil {
+		return value
+	}
+	return repl.parser.ReplaceSymbolsIn(value)
+}
+
+func resultText(result slimentity.SlimEntity) string {
+	switch value := result.(type) {
+	case nil:
+		return slimprotocol.Null()
+	case string:
+		if slimprotocol.IsException(value) {
+			if match := exceptionMessageRegex.FindStringSubmatch(value); match != nil {
+				return "Exception: " + match[1]
+			}
+			return "Exception: " + strings.TrimPrefix(value, "__EXCEPTION__:")
+		}
+		if value == slimprotocol.Void() {
+			return "(void)"
+		}
+		return value
+	default:
+		return slimentity.ToString(result)
+	}
+}
+
+func (repl *Repl) process(instruction *slimentity.SlimList) slimentity.SlimEntity {
+	response := repl.interpreter.Process(slimentity.NewSlimListContaining(slimentity.SlimList{instruction}))
+	if response.Length() != 1 || !slimentity.IsSlimList(response.ElementAt(0)) {
+		return slimprotocol.Exception("No result")
+	}
+	return response.ElementAt(0).(*slimentity.SlimList).ElementAt(1)
+}
+
+func (repl *Repl) symbolList() string {
+	if len(repl.symbols) == 0 {
+		return "No symbols"
+	}
+	var names []string
+	for name := range repl.symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("$%v = %v", name, repl.symbols[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Methods
+
+// Execute runs a command line and returns the text to show, and whether to continue.
+func (repl *Repl) Execute(line string) (string, bool) {
+	tokens, err := splitArguments(strings.TrimSpace(line))
+	if err != nil {
+		return err.Error(), true
+	}
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return "", true
+	}
+	switch tokens[0] {
+	case "quit", "exit":
+		return "", false
+	case "help":
+		return strings.TrimSuffix(help, "\n"), true
+	case "symbols":
+		return repl.symbolList(), true
+	}
+	repl.count++
+	instruction, err := ParseCommand(fmt.Sprintf("repl_%v", repl.count), tokens)
+	if err != nil {
+		return err.Error(), true
+	}
+	result := repl.process(instruction)
+	text := resultText(result)
+	if strings.HasPrefix(text, "Exception: ") {
+		return text, true
+	}
+	switch instruction.StringAt(1) {
+	case "callAndAssign":
+		repl.symbols[instruction.StringAt(2)] = text
+		return fmt.Sprintf("$%v = %v", instruction.StringAt(2), text), true
+	case "assign":
+		if result == slimprotocol.OK() {
+			repl.symbols[instruction.StringAt(2)] = repl.assignedValue(instruction.StringAt(3))
+		}
+	}
+	return text, true
+}
+
+// Run reads commands from the reader and writes the results to the writer, until quit or the end of the input.
+func (repl *Repl) Run(reader io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(reader)
+	fmt.Fprint(writer, prompt)
+	for scanner.Scan() {
+		output, more := repl.Execute(scanner.Text())
+		if output != "" {
+			fmt.Fprintln(writer, output)
+		}
+		if !more {
+			return nil
+		}
+		fmt.Fprint(writer, prompt)
+	}
+	fmt.Fprintln(writer)
+	return scanner.Err()
+}
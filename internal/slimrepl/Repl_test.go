@@ -0,0 +1,110 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimrepl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/examples/demofixtures"
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/fixture"
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprocessor"
+)
+
+func newTestRepl() *Repl {
+	registry := fixture.NewRegistry()
+	symbols := slimprocessor.NewSymbolTable()
+	parser := slimprocessor.NewParser(symbols)
+	objectHandler := slimprocessor.NewObjectHandler(parser)
+	parser.SetObjectSerializer(objectHandler)
+	registry.AddFixturesFrom(demofixtures.NewTemperatureFactory())
+	registry.AddFixture(demofixtures.NewCounter)
+	processor := slimprocessor.NewStatementProcessor(registry, objectHandler, parser, symbols)
+	repl := NewRepl(slimprocessor.NewSlimInterpreter(processor, time.Second))
+	repl.SetParser(parser)
+	return repl
+}
+
+func TestReplExecute(t *testing.T) {
+	repl := newTestRepl()
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"", ""},
+		{"# a comment", ""},
+		{"symbols", "No symbols"},
+		{"make conv demofixtures.TemperatureConverter", "OK"},
+		{"$temp = call conv convertTo 68F C", "$temp = 20"},
+		{"call conv convertTo \"$temp C\" F", "68"},
+		{"call conv convertTo q C", "Exception: Panic: Expected float with suffix F, C or K but got 'q'"},
+		{"assign unit K", "OK"},
+		{"call conv convertTo 0C $unit", "273.15"},
+		{"make counter demofixtures.Counter", "OK"},
+		{"call counter countUp", "(void)"},
+		{"call counter value", "1"},
+		{"symbols", "$temp = 20\n$unit = K"},
+		{"import demofixtures", "OK"},
+		{"make conv2 TemperatureConverter", "OK"},
+		{"call nobody method", "Exception: NO_INSTANCE nobody"},
+		{"$failed = call nobody method", "Exception: NO_INSTANCE nobody"},
+		{"symbols", "$temp = 20\n$unit = K"},
+		{"make", "Usage: make <instance> <fixture> [arguments]"},
+		{"call \"x", "Missing closing quote"},
+	}
+	for _, test := range tests {
+		output, more := repl.Execute(test.line)
+		assert.Equals(t, test.expected, output, test.line)
+		assert.IsTrue(t, more, test.line+" continues")
+	}
+	_, more := repl.Execute("quit")
+	assert.IsTrue(t, !more, "quit stops")
+	help, _ := repl.Execute("help")
+	assert.IsTrue(t, strings.HasPrefix(help, "Commands:\n  make <instance> <fixture> [arguments]"), "help")
+}
+
+func TestReplAssignFromSymbol(t *testing.T) {
+	repl := newTestRepl()
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"make conv demofixtures.TemperatureConverter", "OK"},
+		{"assign y 5", "OK"},
+		{"assign x $y", "OK"},
+		{"call conv convertTo \"$x C\" K", "278.15"},
+		{"symbols", "$x = 5\n$y = 5"},
+	}
+	for _, test := range tests {
+		output, _ := repl.Execute(test.line)
+		assert.Equals(t, test.expected, output, test.line)
+	}
+}
+
+func TestReplRun(t *testing.T) {
+	var output strings.Builder
+	err := newTestRepl().Run(strings.NewReader("make conv demofixtures.TemperatureConverter\ncall conv convertTo 32F C\nexit\nignored\n"), &output)
+	assert.Equals(t, nil, err, "no error")
+	assert.Equals(t, "slim> OK\nslim> 0\nslim> ", output.String(), "output until exit")
+	output.Reset()
+	newTestRepl().Run(strings.NewReader("symbols"), &output)
+	assert.Equals(t, "slim> No symbols\nslim> \n", output.String(), "output until end of input")
+}
+
+func TestReplResultText(t *testing.T) {
+	assert.Equals(t, "null", resultText(nil), "nil")
+	assert.Equals(t, "[a, b]", resultText(slimentity.NewSlimListContaining(slimentity.SlimList{"a", "b"})), "list")
+	assert.Equals(t, "Exception: oops", resultText("__EXCEPTION__:oops"), "exception without message")
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A session recording contains the marshalled requests and responses of a Slim session, with timing.
+// It is written as JSON lines (one exchange per line), so it can be inspected and diffed with standard tools.
+
+// Definitions and constructors
+
+// Exchange is a request with its response, the time the request was received and how long processing took.
+type Exchange struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Request  string        `json:"request"`
+	Response string        `json:"response"`
+}
+
+// SessionRecorder writes the exchanges of a session.
+type SessionRecorder struct {
+	encoder *json.Encoder
+}
+
+// NewSessionRecorder creates a new SessionRecorder writing to writer.
+func NewSessionRecorder(writer io.Writer) *SessionRecorder {
+	recorder := new(SessionRecorder)
+	recorder.encoder = json.NewEncoder(writer)
+	recorder.encoder.SetEscapeHTML(false)
+	return recorder
+}
+
+// Methods
+
+// Record writes an exchange.
+func (recorder *SessionRecorder) Record(exchange *Exchange) error {
+	return recorder.encoder.Encode(exchange)
+}
+
+// ReadSession reads the exchanges of a recorded session.
+func ReadSession(reader io.Reader) ([]*Exchange, error) {
+	var exchanges []*Exchange
+	scanner := bufio.NewScanner(reader)
+	// requests can be big (e.g. table tables), so we don't want to be limited by the default line size
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<30)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		exchange := new(Exchange)
+		if err := json.Unmarshal(scanner.Bytes(), exchange); err != nil {
+			return nil, fmt.Errorf("Could not read exchange on line %v: %v", lineNumber, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Could not read session: %v", err)
+	}
+	return exchanges, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimserver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestSessionRecorderRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	recorder := NewSessionRecorder(&buffer)
+	start := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equals(t, nil, recorder.Record(&Exchange{Time: start, Duration: time.Millisecond, Request: "000003:[000000:]", Response: "000003:[000000:]"}), "first record")
+	assert.Equals(t, nil, recorder.Record(&Exchange{Time: start.Add(time.Second), Duration: 2 * time.Millisecond, Request: "<b>&", Response: "x"}), "second record")
+	assert.Equals(t, 2, strings.Count(buffer.String(), "\n"), "one line per exchange")
+	assert.IsTrue(t, strings.Contains(buffer.String(), `"request":"<b>&"`), "HTML not escaped")
+
+	exchanges, err := ReadSession(strings.NewReader(buffer.String() + "\n"))
+	assert.Equals(t, nil, err, "no error reading")
+	assert.Equals(t, 2, len(exchanges), "two exchanges read, empty line skipped")
+	assert.Equals(t, start, exchanges[0].Time, "time of first exchange")
+	assert.Equals(t, time.Millisecond, exchanges[0].Duration, "duration of first exchange")
+	assert.Equals(t, "000003:[000000:]", exchanges[0].Request, "request of first exchange")
+	assert.Equals(t, "<b>&", exchanges[1].Request, "request of second exchange")
+	assert.Equals(t, "x", exchanges[1].Response, "response of second exchange")
+}
+
+func TestSessionRecorderReadError(t *testing.T) {
+	_, err := ReadSession(strings.NewReader("{\"request\":\"a\"}\nnot json\n"))
+	assert.IsTrue(t, strings.HasPrefix(err.Error(), "Could not read exchange on line 2: "), "line number in error")
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimserver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/essenius/slim4go/internal/interfaces"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Replaying a session feeds the recorded requests to an interpreter, and compares the responses with the recorded ones.
+// Differences are reported per instruction, so it is clear which step of a test behaves differently.
+
+// Definitions
+
+// Difference is an instruction result that differs from the recording.
+type Difference struct {
+	Exchange int
+	ID       string
+	Recorded string
+	Replayed string
+}
+
+// ReplayReport contains the outcome of replaying a session.
+type ReplayReport struct {
+	Exchanges        int
+	RecordedDuration time.Duration
+	ReplayDuration   time.Duration
+	Differences      []*Difference
+}
+
+// Helpers
+
+// resultsByID decodes a marshalled response into the results per instruction id, in the order of the response.
+func resultsByID(response string) ([]string, map[string]string, bool) {
+	decoded, err := slimentity.ReadRequest(strings.NewReader(response))
+	if err != nil || !slimentity.IsSlimList(decoded) {
+		return nil, nil, false
+	}
+	var ids []string
+	results := make(map[string]string)
+	for _, entry := range *decoded.(*slimentity.SlimList) {
+		result, ok := entry.(*slimentity.SlimList)
+		if !ok || result.Length() != 2 || slimentity.IsSlimList(result.ElementAt(0)) {
+			return nil, nil, false
+		}
+		id := result.StringAt(0)
+		ids = append(ids, id)
+		results[id] = slimentity.ToString(result.ElementAt(1))
+	}
+	return ids, results, true
+}
+
+func compareResponses(exchangeIndex int, recorded, replayed string) []*Difference {
+	if recorded == replayed {
+		return nil
+	}
+	recordedIDs, recordedResults, ok1 := resultsByID(recorded)
+	replayedIDs, replayedResults, ok2 := resultsByID(replayed)
+	if !ok1 || !ok2 {
+		return []*Difference{{Exchange: exchangeIndex, Recorded: recorded, Replayed: replayed}}
+	}
+	var differences []*Difference
+	for _, id := range recordedIDs {
+		replayedResult, found := replayedResults[id]
+		if !found {
+			replayedResult = "<missing>"
+		}
+		if replayedResult != recordedResults[id] {
+			differences = append(differences, &Difference{Exchange: exchangeIndex, ID: id, Recorded: recordedResults[id], Replayed: replayedResult})
+		}
+	}
+	for _, id := range replayedIDs {
+		if _, found := recordedResults[id]; !found {
+			differences = append(differences, &Difference{Exchange: exchangeIndex, ID: id, Recorded: "<missing>", Replayed: replayedResults[id]})
+		}
+	}
+	return differences
+}
+
+// Methods
+
+// Replay runs the requests of the recorded exchanges through the interpreter and compares the responses.
+func Replay(exchanges []*Exchange, interpreter interfaces.SlimInterpreter) (*ReplayReport, error) {
+	report := new(ReplayReport)
+	for index, exchange := range exchanges {
+		request, err := slimentity.ReadRequest(strings.NewReader(exchange.Request))
+		if err != nil {
+			return nil, fmt.Errorf("Could not read request %v: %v", index+1, err)
+		}
+		if !slimentity.IsSlimList(request) {
+			return nil, fmt.Errorf("Request %v is not an instruction list: '%v'", index+1, request)
+		}
+		start := time.Now()
+		response := slimentity.Marshal(interpreter.Process(request.(*slimentity.SlimList)))
+		report.ReplayDuration += time.Since(start)
+		report.RecordedDuration += exchange.Duration
+		report.Exchanges++
+		report.Differences = append(report.Differences, compareResponses(index+1, exchange.Response, response)...)
+	}
+	return report, nil
+}
+
+// Passed returns whether the replayed responses are the same as the recorded ones.
+func (report *ReplayReport) Passed() bool {
+	return len(report.Differences) == 0
+}
+
+func (report *ReplayReport) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("Replayed %v exchanges in %v (recorded: %v), %v differences\n",
+		report.Exchanges, report.ReplayDuration.Round(time.Microsecond), report.RecordedDuration.Round(time.Microsecond), len(report.Differences)))
+	for _, difference := range report.Differences {
+		if difference.ID == "" {
+			builder.WriteString(fmt.Sprintf("  exchange %v: recorded [%v] but replayed [%v]\n", difference.Exchange, difference.Recorded, difference.Replayed))
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("  exchange %v, %v: recorded [%v] but replayed [%v]\n",
+			difference.Exchange, difference.ID, difference.Recorded, difference.Replayed))
+	}
+	return builder.String()
+}
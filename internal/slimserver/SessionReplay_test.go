@@ -0,0 +1,98 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimserver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// replayInterpreter responds with the results in its map for the ids of the instructions it knows.
+type replayInterpreter struct {
+	results map[string]string
+}
+
+func (interpreter *replayInterpreter) Process(instructions *slimentity.SlimList) *slimentity.SlimList {
+	response := slimentity.NewSlimList()
+	for _, entry := range *instructions {
+		id := entry.(*slimentity.SlimList).StringAt(0)
+		if result, ok := interpreter.results[id]; ok {
+			response.Append(slimentity.NewSlimListContaining(slimentity.SlimList{id, result}))
+		}
+	}
+	return response
+}
+
+func instructionRequest(ids ...string) string {
+	request := slimentity.NewSlimList()
+	for _, id := range ids {
+		request.Append(slimentity.NewSlimListContaining(slimentity.SlimList{id, "call", "actor", "method"}))
+	}
+	return slimentity.Marshal(request)
+}
+
+func resultResponse(idResultPairs ...string) string {
+	response := slimentity.NewSlimList()
+	for i := 0; i < len(idResultPairs); i += 2 {
+		response.Append(slimentity.NewSlimListContaining(slimentity.SlimList{idResultPairs[i], idResultPairs[i+1]}))
+	}
+	return slimentity.Marshal(response)
+}
+
+func TestSessionReplayPassed(t *testing.T) {
+	interpreter := &replayInterpreter{results: map[string]string{"id1": "OK", "id2": "20"}}
+	exchanges := []*Exchange{
+		{Duration: time.Millisecond, Request: instructionRequest("id1"), Response: resultResponse("id1", "OK")},
+		{Duration: time.Millisecond, Request: instructionRequest("id2"), Response: resultResponse("id2", "20")},
+	}
+	report, err := Replay(exchanges, interpreter)
+	assert.Equals(t, nil, err, "no error")
+	assert.IsTrue(t, report.Passed(), "Passed")
+	assert.Equals(t, 2, report.Exchanges, "Exchanges")
+	assert.Equals(t, 2*time.Millisecond, report.RecordedDuration, "Recorded duration")
+	assert.IsTrue(t, strings.HasPrefix(report.String(), "Replayed 2 exchanges in "), "String start")
+	assert.IsTrue(t, strings.HasSuffix(report.String(), "(recorded: 2ms), 0 differences\n"), "String end")
+}
+
+func TestSessionReplayDifferences(t *testing.T) {
+	interpreter := &replayInterpreter{results: map[string]string{"id1": "OK", "id2": "21", "id4": "new"}}
+	exchanges := []*Exchange{
+		{Request: instructionRequest("id1", "id2", "id3", "id4"), Response: resultResponse("id1", "OK", "id2", "20", "id3", "gone")},
+		{Request: instructionRequest("id1"), Response: "garbage"},
+	}
+	report, err := Replay(exchanges, interpreter)
+	assert.Equals(t, nil, err, "no error")
+	assert.IsTrue(t, !report.Passed(), "not passed")
+	assert.Equals(t, 4, len(report.Differences), "difference count")
+	assert.Equals(t, "1 id2 20 21", differenceText(report.Differences[0]), "changed result")
+	assert.Equals(t, "1 id3 gone <missing>", differenceText(report.Differences[1]), "missing result")
+	assert.Equals(t, "1 id4 <missing> new", differenceText(report.Differences[2]), "surplus result")
+	assert.Equals(t, "2  garbage "+resultResponse("id1", "OK"), differenceText(report.Differences[3]), "undecodable response")
+	assert.IsTrue(t, strings.Contains(report.String(), "  exchange 1, id2: recorded [20] but replayed [21]\n"), "String with id")
+	assert.IsTrue(t, strings.Contains(report.String(), "  exchange 2: recorded [garbage] but replayed ["), "String without id")
+}
+
+func differenceText(difference *Difference) string {
+	return strings.Join([]string{string(rune('0' + difference.Exchange)), difference.ID, difference.Recorded, difference.Replayed}, " ")
+}
+
+func TestSessionReplayErrors(t *testing.T) {
+	interpreter := &replayInterpreter{}
+	_, err1 := Replay([]*Exchange{{Request: "bogus"}}, interpreter)
+	assert.IsTrue(t, strings.HasPrefix(err1.Error(), "Could not read request 1: "), "unreadable request")
+	_, err2 := Replay([]*Exchange{{Request: "000003:bye"}}, interpreter)
+	assert.Equals(t, "Request 1 is not an instruction list: 'bye'", err2.Error(), "not a list")
+}
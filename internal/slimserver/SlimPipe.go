@@ -46,6 +46,11 @@ func (pipe *slimPipe) SendMessage(message string) error {
 	return err
 }
 
+// Write writes data on Stdout
+func (pipe *slimPipe) Write(data []byte) (int, error) {
+	return pipe.writer.Write(data)
+}
+
 // Read receives a number of bytes from Stdin
 func (pipe *slimPipe) Read(buffer []byte) (int, error) {
 	errChannel := make(chan error)
@@ -36,7 +36,10 @@ func TestSlimPipeOk(t *testing.T) {
 	assert.Equals(t, 20, count, "count")
 	assert.Equals(t, "some text to be read", string(pipeBuffer)[:count], "content")
 	pipe.SendMessage("message")
-	assert.Equals(t, "message", writeBuffer.String(), "Write")
+	assert.Equals(t, "message", writeBuffer.String(), "SendMessage")
+	written, _ := pipe.Write([]byte(" streamed"))
+	assert.Equals(t, 9, written, "Write count")
+	assert.Equals(t, "message streamed", writeBuffer.String(), "Write")
 
 	readBuffer = strings.NewReader("")
 	_, err1 := pipe.Read(pipeBuffer)
@@ -12,12 +12,19 @@
 package slimserver
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"time"
 
+	"github.com/essenius/slim4go/internal/catalog"
 	"github.com/essenius/slim4go/internal/interfaces"
 	"github.com/essenius/slim4go/internal/slimentity"
 	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 	"github.com/essenius/slim4go/internal/slimprotocol"
+	"github.com/essenius/slim4go/slimfixture"
 )
 
 // SlimServer is the main object.
@@ -25,6 +32,16 @@ type SlimServer struct {
 	fixtureRegistry interfaces.Registry
 	messenger       interfaces.SlimMessenger
 	interpreter     interfaces.SlimInterpreter
+	recorder        *SessionRecorder
+	logger          *slog.Logger
+	metricsReport   *metricsReport
+}
+
+// metricsReport specifies where the metrics summary is written at the end of a session, and in what format.
+type metricsReport struct {
+	metrics *slimmetrics.Metrics
+	writer  io.Writer
+	format  string
 }
 
 var slimServerInstance *SlimServer
@@ -35,9 +52,25 @@ func NewSlimServer(fixtureRegistry interfaces.Registry, messenger interfaces.Sli
 	server.fixtureRegistry = fixtureRegistry
 	server.messenger = messenger
 	server.interpreter = interpreter
+	server.logger = slimlog.Discard()
 	return server
 }
 
+// SetLogger specifies the logger for the requests, responses and errors of the sessions.
+func (server *SlimServer) SetLogger(logger *slog.Logger) {
+	server.logger = logger
+}
+
+// marshalled postpones marshalling an entity for the log until it is clear that it will be logged.
+type marshalled struct {
+	entity slimentity.SlimEntity
+}
+
+// LogValue returns the marshalled entity.
+func (value marshalled) LogValue() slog.Value {
+	return slog.StringValue(slimentity.Marshal(value.entity))
+}
+
 // Serve The Slim Server fetching requests, processing them, and returning results.
 func (server *SlimServer) Serve() error {
 
@@ -49,24 +82,118 @@ func (server *SlimServer) Serve() error {
 		return err2
 	}
 
+	// one decoder for the whole session, so data that was read ahead is available for the next request
+	decoder := slimentity.NewDecoder(server.messenger)
 	for {
-		request, err3 := slimentity.ReadRequest(server.messenger)
+		request, err3 := decoder.Decode()
 		if err3 != nil {
-			slimlog.Trace.Printf("Read error %v", err3)
+			server.logger.Error("Could not read request", "error", err3)
 			return err3
 		}
-		slimlog.Trace.Println("Request: ", slimentity.Marshal(request))
+		server.logger.Debug("Request", "request", marshalled{request})
 		if !slimentity.IsSlimList(request) {
 			if request.(string) == slimprotocol.Bye() {
+				server.logger.Info("Session ended")
+				server.writeMetrics()
 				return nil
 			}
 			return fmt.Errorf("Encountered unexpected command '%v'", request.(string))
 		}
-		responseMessage := server.interpreter.Process(request.(*slimentity.SlimList))
-		marshalledResponse := slimentity.Marshal(responseMessage)
-		slimlog.Trace.Println("Response: ", marshalledResponse)
-		server.messenger.SendMessage(marshalledResponse)
+		start := time.Now()
+		response := server.interpreter.Process(request.(*slimentity.SlimList))
+		if err4 := server.sendResponse(start, request, response); err4 != nil {
+			server.logger.Error("Could not send response", "error", err4)
+			return err4
+		}
+	}
+}
+
+// sendResponse sends the response to the client. If the session is recorded or responses are logged, we need the marshalled response anyway.
+// Otherwise we stream it, so big responses don't need to be built in memory.
+func (server *SlimServer) sendResponse(start time.Time, request slimentity.SlimEntity, response *slimentity.SlimList) error {
+	if server.recorder == nil && !server.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return slimentity.Encode(server.messenger, response)
+	}
+	marshalledResponse := slimentity.Marshal(response)
+	server.logger.Debug("Response", "response", marshalledResponse, "duration", time.Since(start))
+	server.record(start, request, marshalledResponse)
+	return server.messenger.SendMessage(marshalledResponse)
+}
+
+// RecordTo makes Serve record the requests and responses of the session to the writer.
+func (server *SlimServer) RecordTo(writer io.Writer) {
+	server.recorder = NewSessionRecorder(writer)
+}
+
+// record writes the exchange if the session is being recorded. A failing recording should not stop the session.
+func (server *SlimServer) record(start time.Time, request slimentity.SlimEntity, response string) {
+	if server.recorder == nil {
+		return
+	}
+	exchange := &Exchange{Time: start, Duration: time.Since(start), Request: slimentity.Marshal(request), Response: response}
+	if err := server.recorder.Record(exchange); err != nil {
+		server.logger.Error("Could not record exchange", "error", err)
+	}
+}
+
+// ReportMetricsTo makes Serve write a summary of the metrics to the writer at the end of the session, in json or csv format.
+func (server *SlimServer) ReportMetricsTo(metrics *slimmetrics.Metrics, writer io.Writer, format string) {
+	server.metricsReport = &metricsReport{metrics: metrics, writer: writer, format: format}
+}
+
+// writeMetrics writes the metrics summary if requested. Like with recording, failing to write should not fail the session.
+func (server *SlimServer) writeMetrics() {
+	if server.metricsReport == nil {
+		return
 	}
+	if err := server.metricsReport.metrics.Write(server.metricsReport.writer, server.metricsReport.format); err != nil {
+		server.logger.Error("Could not write metrics", "error", err)
+	}
+}
+
+// Replay runs a recorded session through the interpreter and reports the differences with the recorded responses.
+func (server *SlimServer) Replay(reader io.Reader) (*ReplayReport, error) {
+	exchanges, err := ReadSession(reader)
+	if err != nil {
+		return nil, err
+	}
+	return Replay(exchanges, server.interpreter)
+}
+
+// Catalog returns a description of the registered fixtures and libraries.
+func (server *SlimServer) Catalog() *catalog.Catalog {
+	return server.fixtureRegistry.Catalog()
+}
+
+// WriteCatalog writes the description of the registered fixtures and libraries in the specified format (text or json).
+func (server *SlimServer) WriteCatalog(writer io.Writer, format string) error {
+	return server.Catalog().Write(writer, format)
+}
+
+// WriteWiki generates the FitNesse fixture reference in a page folder, using the doc comments in the source folders.
+func (server *SlimServer) WriteWiki(pageFolder string, sourceFolders ...string) error {
+	fixtureCatalog := server.Catalog()
+	documentation, err := catalog.ReadDocumentation(sourceFolders...)
+	if err != nil {
+		return err
+	}
+	fixtureCatalog.AddDocumentation(documentation)
+	return fixtureCatalog.WriteWiki(pageFolder)
+}
+
+// AllowMembers restricts the members of a registered fixture that can be used to those matching one of the patterns.
+func (server *SlimServer) AllowMembers(fixtureName string, patterns ...string) error {
+	return server.fixtureRegistry.AllowMembers(fixtureName, patterns...)
+}
+
+// DenyNonFixtureMembers denies using the members of instances that are not registered fixtures or libraries.
+func (server *SlimServer) DenyNonFixtureMembers() {
+	server.fixtureRegistry.DenyNonFixtureMembers()
+}
+
+// RegisterAlias registers an alternative name for a fixture.
+func (server *SlimServer) RegisterAlias(alias string, fixtureName string) error {
+	return server.fixtureRegistry.AddAlias(alias, fixtureName)
 }
 
 // RegisterFixture registers a type as fixture using a constructor.
@@ -74,7 +201,23 @@ func (server *SlimServer) RegisterFixture(constructor interface{}) error {
 	return server.fixtureRegistry.AddFixture(constructor)
 }
 
+// RegisterLibrary registers an instantiated object as library, so its methods are available in all script tables.
+func (server *SlimServer) RegisterLibrary(library interface{}) error {
+	return server.fixtureRegistry.AddLibrary(library)
+}
+
 // RegisterFixturesFrom registers a number of fixtures using a fixture factory (having NewXxx pointer receivers).
 func (server *SlimServer) RegisterFixturesFrom(factory interface{}) error {
 	return server.fixtureRegistry.AddFixturesFrom(factory)
 }
+
+// RegisterStubs registers stubs that call methods of a registered fixture without reflection.
+// The fixture is a nil pointer of the fixture type, e.g. (*Counter)(nil).
+func (server *SlimServer) RegisterStubs(fixture interface{}, stubs slimfixture.Stubs) error {
+	return server.fixtureRegistry.AddStubs(fixture, stubs)
+}
+
+// SetReadOnlyFields makes fields of a registered fixture read-only.
+func (server *SlimServer) SetReadOnlyFields(fixtureName string, fieldNames ...string) error {
+	return server.fixtureRegistry.SetReadOnlyFields(fixtureName, fieldNames...)
+}
@@ -12,8 +12,11 @@
 package slimserver
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strings"
 	"testing"
 	"time"
@@ -22,6 +25,8 @@ import (
 
 	"github.com/essenius/slim4go/internal/assert"
 	"github.com/essenius/slim4go/internal/fixture"
+	"github.com/essenius/slim4go/internal/slimlog"
+	"github.com/essenius/slim4go/internal/slimmetrics"
 	"github.com/essenius/slim4go/internal/slimprocessor"
 	"github.com/essenius/slim4go/internal/standardlibrary"
 )
@@ -77,6 +82,14 @@ func (messenger *testMessenger) SendMessage(message string) error {
 	return nil
 }
 
+// Write emulates streaming a message. As responses in tests are small, they arrive in one write.
+func (messenger *testMessenger) Write(data []byte) (int, error) {
+	if err := messenger.SendMessage(string(data)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
 func TestServeErrorResponses(t *testing.T) {
 	messenger1 := newTestMessenger(t, []string{}, []string{}, "ListenError")
 	slimServer1 := NewSlimServer(nil, messenger1, nil)
@@ -96,7 +109,7 @@ func TestServeErrorResponses(t *testing.T) {
 	messenger4 := newTestMessenger(t, []string{"000005:bye"}, []string{"Slim -- V0.5\n"}, "Test 3 - size wrong")
 	slimServer4 := NewSlimServer(nil, messenger4, nil)
 	err4 := slimServer4.Serve()
-	assert.Equals(t, "readExactBytes: Expected 5 bytes from Slim client, but got 3", err4.Error(), "Error sending")
+	assert.Equals(t, "Expected 5 bytes but got 3", err4.Error(), "Error sending")
 }
 
 func TestServerServe(t *testing.T) {
@@ -123,8 +136,8 @@ func TestServerServe(t *testing.T) {
 	symbols := slimprocessor.NewSymbolTable()
 	parser := slimprocessor.NewParser(symbols)
 	objectHandler := slimprocessor.NewObjectHandler(parser)
-	standardLibrary := standardlibrary.New(standardlibrary.NewActorStack(), objectHandler)
-	objectHandler.Add("libraryStandard", standardLibrary)
+	standardLibrary := standardlibrary.New(standardlibrary.NewActorStack(), objectHandler, time.Second)
+	registry.AddLibrary(standardLibrary)
 	parser.SetObjectSerializer(objectHandler)
 	processor := slimprocessor.NewStatementProcessor(registry, objectHandler, parser, symbols)
 	interpreter := slimprocessor.NewSlimInterpreter(processor, time.Second)
@@ -135,3 +148,101 @@ func TestServerServe(t *testing.T) {
 	slimServer1.Serve()
 	assert.Equals(t, "Could not add fixture '1'", slimServer1.RegisterFixture(1).Error(), "Wrong argument for RegisterFixture returns an error")
 }
+
+func TestServerRecordAndReplay(t *testing.T) {
+	request := instructionRequest("id1", "id2")
+	response := resultResponse("id1", "OK", "id2", "20")
+	messenger := newTestMessenger(t, []string{request, "000003:bye"}, []string{"Slim -- V0.5\n", response}, "Record")
+	server := NewSlimServer(nil, messenger, &replayInterpreter{results: map[string]string{"id1": "OK", "id2": "20"}})
+	var recording strings.Builder
+	server.RecordTo(&recording)
+	assert.Equals(t, nil, server.Serve(), "Serve without error")
+	exchanges, err := ReadSession(strings.NewReader(recording.String()))
+	assert.Equals(t, nil, err, "Recording readable")
+	assert.Equals(t, 1, len(exchanges), "bye is not recorded")
+	assert.Equals(t, request, exchanges[0].Request, "Recorded request")
+	assert.Equals(t, response, exchanges[0].Response, "Recorded response")
+
+	report, err := server.Replay(strings.NewReader(recording.String()))
+	assert.Equals(t, nil, err, "Replay without error")
+	assert.IsTrue(t, report.Passed(), "Replay passed")
+	_, err = server.Replay(strings.NewReader("nonsense"))
+	assert.IsTrue(t, err != nil, "Replay of invalid recording fails")
+}
+
+func TestServerServeRequestsInOneRead(t *testing.T) {
+	// the bye arrives in the same read as the request, so it must not get lost
+	request := instructionRequest("id1")
+	response := resultResponse("id1", "OK")
+	messenger := newTestMessenger(t, []string{request + "000003:bye"}, []string{"Slim -- V0.5\n", response}, "One read")
+	server := NewSlimServer(nil, messenger, &replayInterpreter{results: map[string]string{"id1": "OK"}})
+	assert.Equals(t, nil, server.Serve(), "Serve ends with bye")
+}
+
+func TestServerServeLogging(t *testing.T) {
+	request := instructionRequest("id1")
+	response := resultResponse("id1", "OK")
+	messenger := newTestMessenger(t, []string{request, "000003:bye"}, []string{"Slim -- V0.5\n", response}, "Logging")
+	server := NewSlimServer(nil, messenger, &replayInterpreter{results: map[string]string{"id1": "OK"}})
+	var buffer bytes.Buffer
+	logger, _ := slimlog.NewLogger(&buffer, "text", slog.LevelDebug)
+	server.SetLogger(logger)
+	assert.Equals(t, nil, server.Serve(), "Serve without error")
+	log := buffer.String()
+	assert.IsTrue(t, strings.Contains(log, "msg=Request request="+request), "Request logged")
+	assert.IsTrue(t, strings.Contains(log, "msg=Response response="+response), "Response logged")
+	assert.IsTrue(t, strings.Contains(log, "msg=\"Session ended\""), "End of session logged")
+}
+
+func TestServerServeMetricsReport(t *testing.T) {
+	request := instructionRequest("id1")
+	messenger := newTestMessenger(t, []string{request, "000003:bye"}, []string{"Slim -- V0.5\n", resultResponse("id1", "OK")}, "Metrics")
+	server := NewSlimServer(nil, messenger, &replayInterpreter{results: map[string]string{"id1": "OK"}})
+	metrics := slimmetrics.NewMetrics()
+	metrics.Call("actor", "method", time.Second, slimmetrics.Success)
+	var report strings.Builder
+	server.ReportMetricsTo(metrics, &report, "csv")
+	assert.Equals(t, nil, server.Serve(), "Serve without error")
+	assert.Equals(t, "fixture,method,count,exceptions,timeouts,totalSeconds,meanSeconds,maxSeconds\nactor,method,1,0,0,1,1,1\n",
+		report.String(), "Summary written at bye")
+}
+
+type failingWriter struct{}
+
+func (writer failingWriter) Write(data []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestServerServeReportErrorsLogged(t *testing.T) {
+	request := instructionRequest("id1")
+	messenger := newTestMessenger(t, []string{request, "000003:bye"}, []string{"Slim -- V0.5\n", resultResponse("id1", "OK")}, "Report errors")
+	server := NewSlimServer(nil, messenger, &replayInterpreter{results: map[string]string{"id1": "OK"}})
+	var buffer bytes.Buffer
+	logger, _ := slimlog.NewLogger(&buffer, "text", slog.LevelError)
+	server.SetLogger(logger)
+	server.RecordTo(failingWriter{})
+	metrics := slimmetrics.NewMetrics()
+	metrics.Call("actor", "method", time.Second, slimmetrics.Success)
+	server.ReportMetricsTo(metrics, failingWriter{}, "csv")
+	assert.Equals(t, nil, server.Serve(), "Failing reports don't fail the session")
+	log := buffer.String()
+	assert.IsTrue(t, strings.Contains(log, "msg=\"Could not record exchange\" error=\"disk full\""), "Recording error logged")
+	assert.IsTrue(t, strings.Contains(log, "msg=\"Could not write metrics\" error=\"disk full\""), "Metrics error logged")
+}
+
+func TestServerServeWriteError(t *testing.T) {
+	request := instructionRequest("id1")
+	messenger := newTestMessenger(t, []string{request}, []string{"Slim -- V0.5\n", resultResponse("id1", "OK")}, "SendError after handshake")
+	server := NewSlimServer(nil, &failAfterHandshake{messenger}, &replayInterpreter{results: map[string]string{"id1": "OK"}})
+	assert.Equals(t, "SendError after handshake", server.Serve().Error(), "Write error ends the session")
+}
+
+// failAfterHandshake makes streaming writes fail, while the handshake (via SendMessage) succeeds.
+type failAfterHandshake struct {
+	*testMessenger
+}
+
+func (messenger *failAfterHandshake) SendMessage(message string) error {
+	messenger.writeIndex++
+	return nil
+}
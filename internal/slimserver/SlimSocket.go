@@ -17,8 +17,6 @@ import (
 	"net"
 	"strconv"
 	"time"
-
-	"github.com/essenius/slim4go/internal/slimlog"
 )
 
 type slimSocket struct {
@@ -53,7 +51,6 @@ func (socket *slimSocket) accept(listener net.Listener, channel chan error) {
 
 // Listen sets up a socket connection and starts listening.
 func (socket *slimSocket) Listen() error {
-	slimlog.Trace.Printf("Port %v", socket.port)
 	listenSpec := ":" + strconv.Itoa(socket.port)
 	socket.logger.Println("Listening at tcp ", listenSpec)
 	listener, err1 := net.Listen("tcp", listenSpec)
@@ -82,7 +79,12 @@ func (socket *slimSocket) Read(buffer []byte) (int, error) {
 
 // SendMessage writes a message to the socket connection.
 func (socket *slimSocket) SendMessage(message string) error {
-	socket.connection.SetWriteDeadline(time.Now().Add(socket.timeout))
-	_, err := socket.connection.Write([]byte(message))
+	_, err := socket.Write([]byte(message))
 	return err
 }
+
+// Write writes data to the socket connection.
+func (socket *slimSocket) Write(data []byte) (int, error) {
+	socket.connection.SetWriteDeadline(time.Now().Add(socket.timeout))
+	return socket.connection.Write(data)
+}
@@ -12,6 +12,7 @@
 package slimserver
 
 import (
+	"log/slog"
 	"os"
 	"time"
 
@@ -22,15 +23,15 @@ import (
 const defaultTimeout = 30 * time.Second
 
 // NewSlimMessenger creates a Pipe messenger if port = 1 and Socket messenger otherwise.
-func NewSlimMessenger(port int, timeout time.Duration) interfaces.SlimMessenger {
+func NewSlimMessenger(port int, timeout time.Duration, logger *slog.Logger) interfaces.SlimMessenger {
 	var messenger interfaces.SlimMessenger
 
 	slimUsesPipe := port == 1
 	if slimUsesPipe {
-		slimlog.Trace.Println("Using pipes")
+		logger.Info("Using pipes", "timeout", timeout)
 		messenger = newSlimPipe(os.Stdin, os.Stdout, slimlog.Info, timeout)
 	} else {
-		slimlog.Trace.Println("Using socket on port ", port)
+		logger.Info("Using socket", "port", port, "timeout", timeout)
 		messenger = newSlimSocket(port, slimlog.Info, timeout)
 	}
 	return messenger
@@ -16,13 +16,14 @@ import (
 	"testing"
 
 	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimlog"
 )
 
 func TestSlimMessengerNew(t *testing.T) {
-	pipeMessenger := NewSlimMessenger(1, 0)
+	pipeMessenger := NewSlimMessenger(1, 0, slimlog.Discard())
 	assert.Equals(t, reflect.TypeOf(new(slimPipe)), reflect.TypeOf(pipeMessenger), "Port 1 results in slimPipe")
 
-	socketMessenger := NewSlimMessenger(8485, 0)
+	socketMessenger := NewSlimMessenger(8485, 0, slimlog.Discard())
 	assert.Equals(t, reflect.TypeOf(new(slimSocket)), reflect.TypeOf(socketMessenger), "Port 8485 results in slimSocket")
 	assert.Equals(t, 8485, socketMessenger.(*slimSocket).port, "Port OK in slimSocket")
 }
@@ -33,6 +33,11 @@ func (actors *ActorStack) Head() interface{} {
 	return (*actors)[0]
 }
 
+// Items returns (a copy of) the items in the stack, the top first.
+func (actors *ActorStack) Items() []interface{} {
+	return append([]interface{}{}, *actors...)
+}
+
 // Length returns the number of items in the stack.
 func (actors *ActorStack) Length() int {
 	return len(*actors)
@@ -29,6 +29,11 @@ func TestSlimActorStack(t *testing.T) {
 	assert.Equals(t, 2, actors.Length(), "Length is 2 after second push")
 	assert.Equals(t, "two", actors.Head(), "name of second push OK")
 	assert.Equals(t, "one", (*actors)[1], "name of first push OK")
+	items := actors.Items()
+	assert.Equals(t, 2, len(items), "Two items")
+	assert.Equals(t, "two", items[0], "Top item first")
+	items[0] = "changed"
+	assert.Equals(t, "two", actors.Head(), "Items returns a copy")
 	actor3 := actors.Pop()
 	assert.Equals(t, 1, actors.Length(), "Length is 1 after first pop")
 	assert.Equals(t, "one", actors.Head(), "first push now on top")
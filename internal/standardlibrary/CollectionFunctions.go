@@ -0,0 +1,46 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// List and map functions of the standard library. Lists use the Slim format ([a, b, c]) and maps the hash table format.
+// Both can also be passed via symbols.
+
+// ListElement returns the element at the (zero based) index of the list.
+func (standardLibrary *StandardLibrary) ListElement(list []string, index int) slimentity.SlimEntity {
+	if index < 0 || index >= len(list) {
+		return slimprotocol.Exceptionf("Index %v out of range (length %v)", index, len(list))
+	}
+	return list[index]
+}
+
+// ListLength returns the number of elements in the list.
+func (standardLibrary *StandardLibrary) ListLength(list []string) int {
+	return len(list)
+}
+
+// MapLength returns the number of entries in the map.
+func (standardLibrary *StandardLibrary) MapLength(aMap map[string]string) int {
+	return len(aMap)
+}
+
+// MapValue returns the value for a key in the map.
+func (standardLibrary *StandardLibrary) MapValue(aMap map[string]string, key string) slimentity.SlimEntity {
+	if value, ok := aMap[key]; ok {
+		return value
+	}
+	return slimprotocol.Exceptionf("Key '%v' not found", key)
+}
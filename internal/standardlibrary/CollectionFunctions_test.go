@@ -0,0 +1,30 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestCollectionFunctions(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	list := []string{"a", "b", "c"}
+	assert.Equals(t, 3, library.ListLength(list), "ListLength")
+	assert.Equals(t, "b", library.ListElement(list, 1), "ListElement")
+	assert.Equals(t, "__EXCEPTION__:message:<<Index 3 out of range (length 3)>>", library.ListElement(list, 3), "ListElement out of range")
+	aMap := map[string]string{"key1": "value1", "key2": "value2"}
+	assert.Equals(t, 2, library.MapLength(aMap), "MapLength")
+	assert.Equals(t, "value2", library.MapValue(aMap, "key2"), "MapValue")
+	assert.Equals(t, "__EXCEPTION__:message:<<Key 'key3' not found>>", library.MapValue(aMap, "key3"), "MapValue with nonexisting key")
+}
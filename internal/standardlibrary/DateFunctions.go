@@ -0,0 +1,107 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Date and time functions of the standard library. Layouts use the Go reference time (Mon Jan 2 15:04:05 MST 2006).
+// Dates are exchanged in RFC 3339 format unless a layout or another date format is specified.
+
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+// Helpers
+
+// parseDuration extends time.ParseDuration with a day unit (d), e.g. 1d12h.
+func parseDuration(input string) (time.Duration, error) {
+	if dayIndex := strings.Index(input, "d"); dayIndex > 0 {
+		days, err := strconv.Atoi(input[:dayIndex])
+		if err != nil {
+			return 0, fmt.Errorf("Could not parse '%v' as duration", input)
+		}
+		remainder := time.Duration(0)
+		if rest := input[dayIndex+1:]; rest != "" {
+			if remainder, err = time.ParseDuration(rest); err != nil {
+				return 0, fmt.Errorf("Could not parse '%v' as duration", input)
+			}
+			if days < 0 {
+				remainder = -remainder
+			}
+		}
+		return time.Duration(days)*24*time.Hour + remainder, nil
+	}
+	duration, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("Could not parse '%v' as duration", input)
+	}
+	return duration, nil
+}
+
+// Methods
+
+// parseDate parses a date in the date format, or in one of the standard layouts.
+func (standardLibrary *StandardLibrary) parseDate(input string) (time.Time, error) {
+	if date, err := time.Parse(standardLibrary.dateFormat, input); err == nil {
+		return date, nil
+	}
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, input); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Could not parse '%v' as date", input)
+}
+
+// AddToDate adds a duration (e.g. 1h30m, -2d) to a date.
+func (standardLibrary *StandardLibrary) AddToDate(date, duration string) slimentity.SlimEntity {
+	parsedDate, err := standardLibrary.parseDate(date)
+	if err != nil {
+		return exception(err)
+	}
+	parsedDuration, err := parseDuration(duration)
+	if err != nil {
+		return exception(err)
+	}
+	return parsedDate.Add(parsedDuration).Format(standardLibrary.dateFormat)
+}
+
+// FormatDate formats a date using a layout.
+func (standardLibrary *StandardLibrary) FormatDate(date, layout string) slimentity.SlimEntity {
+	parsedDate, err := standardLibrary.parseDate(date)
+	if err != nil {
+		return exception(err)
+	}
+	return parsedDate.Format(layout)
+}
+
+// Now returns the current date and time, optionally formatted using a layout.
+func (standardLibrary *StandardLibrary) Now(layout ...string) string {
+	if len(layout) > 0 {
+		return standardLibrary.now().Format(layout[0])
+	}
+	return standardLibrary.now().Format(standardLibrary.dateFormat)
+}
+
+// SetDateFormat sets the layout of the dates that the date functions return, and that they accept besides the standard
+// layouts. An empty layout means RFC 3339.
+func (standardLibrary *StandardLibrary) SetDateFormat(layout string) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	standardLibrary.dateFormat = layout
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestDateFunctions(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	library.now = func() time.Time { return time.Date(2020, 12, 31, 23, 30, 0, 0, time.UTC) }
+	assert.Equals(t, "2020-12-31T23:30:00Z", library.Now(), "Now")
+	assert.Equals(t, "31-12-2020", library.Now("02-01-2006"), "Now with layout")
+	assert.Equals(t, "2021-01-01T00:30:00Z", library.AddToDate(library.Now(), "1h"), "Add hour")
+	assert.Equals(t, "2020-12-29T23:30:00Z", library.AddToDate(library.Now(), "-2d"), "Subtract days")
+	assert.Equals(t, "2021-01-02T11:30:00Z", library.AddToDate(library.Now(), "1d12h"), "Add days and hours")
+	assert.Equals(t, "2021-01-01T00:00:00Z", library.AddToDate("2020-12-31", "24h"), "Add to date without time")
+	assert.Equals(t, "__EXCEPTION__:message:<<Could not parse 'bogus' as duration>>", library.AddToDate("2020-12-31", "bogus"), "Wrong duration")
+	assert.Equals(t, "__EXCEPTION__:message:<<Could not parse 'bogus' as date>>", library.AddToDate("bogus", "1h"), "Wrong date")
+	assert.Equals(t, "Dec 31, 2020", library.FormatDate("2020-12-31 10:00:00", "Jan 2, 2006"), "FormatDate")
+	assert.Equals(t, "__EXCEPTION__:message:<<Could not parse '31-12-2020' as date>>", library.FormatDate("31-12-2020", "2006"), "FormatDate with wrong date")
+}
+
+func TestDateFunctionsDateFormat(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	library.now = func() time.Time { return time.Date(2020, 12, 31, 23, 30, 0, 0, time.UTC) }
+	library.SetDateFormat("02-01-2006 15:04")
+	assert.Equals(t, "31-12-2020 23:30", library.Now(), "Now in date format")
+	assert.Equals(t, "01-01-2021 00:30", library.AddToDate(library.Now(), "1h"), "Date in date format accepted and returned")
+	assert.Equals(t, "02-01-2021 00:00", library.AddToDate("2021-01-01", "24h"), "Standard layouts still accepted")
+	assert.Equals(t, "2020", library.FormatDate("31-12-2020 10:00", "2006"), "FormatDate accepts the date format")
+	library.SetDateFormat("")
+	assert.Equals(t, "2020-12-31T23:30:00Z", library.Now(), "Empty date format means RFC 3339")
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Arithmetic, comparison and random functions of the standard library.
+
+// Add returns the sum of the parameters.
+func (standardLibrary *StandardLibrary) Add(values ...float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum
+}
+
+// Divide returns the quotient of the parameters.
+func (standardLibrary *StandardLibrary) Divide(dividend, divisor float64) slimentity.SlimEntity {
+	if divisor == 0 {
+		return slimprotocol.Exception("Division by zero")
+	}
+	return dividend / divisor
+}
+
+// IsEqual returns whether both parameters have the same numerical value.
+func (standardLibrary *StandardLibrary) IsEqual(value1, value2 float64) bool {
+	return value1 == value2
+}
+
+// IsGreaterThan returns whether the first parameter is greater than the second.
+func (standardLibrary *StandardLibrary) IsGreaterThan(value1, value2 float64) bool {
+	return value1 > value2
+}
+
+// IsLessThan returns whether the first parameter is less than the second.
+func (standardLibrary *StandardLibrary) IsLessThan(value1, value2 float64) bool {
+	return value1 < value2
+}
+
+// Maximum returns the largest of the parameters.
+func (standardLibrary *StandardLibrary) Maximum(value float64, values ...float64) float64 {
+	for _, candidate := range values {
+		if candidate > value {
+			value = candidate
+		}
+	}
+	return value
+}
+
+// Minimum returns the smallest of the parameters.
+func (standardLibrary *StandardLibrary) Minimum(value float64, values ...float64) float64 {
+	for _, candidate := range values {
+		if candidate < value {
+			value = candidate
+		}
+	}
+	return value
+}
+
+// Multiply returns the product of the parameters.
+func (standardLibrary *StandardLibrary) Multiply(values ...float64) float64 {
+	product := 1.0
+	for _, value := range values {
+		product *= value
+	}
+	return product
+}
+
+// RandomInteger returns a random integer between minimum and maximum (both inclusive).
+func (standardLibrary *StandardLibrary) RandomInteger(minimum, maximum int) slimentity.SlimEntity {
+	if maximum < minimum {
+		return slimprotocol.Exceptionf("Maximum %v is less than minimum %v", maximum, minimum)
+	}
+	return minimum + standardLibrary.random.Intn(maximum-minimum+1)
+}
+
+// RandomString returns a random string of letters and digits with the specified length.
+func (standardLibrary *StandardLibrary) RandomString(length int) string {
+	const characters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = characters[standardLibrary.random.Intn(len(characters))]
+	}
+	return string(result)
+}
+
+// Subtract returns the difference of the parameters.
+func (standardLibrary *StandardLibrary) Subtract(value1, value2 float64) float64 {
+	return value1 - value2
+}
+
+// UniqueID returns a random (version 4) UUID.
+func (standardLibrary *StandardLibrary) UniqueID() slimentity.SlimEntity {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return exception(err)
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
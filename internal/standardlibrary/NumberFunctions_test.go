@@ -0,0 +1,58 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestNumberFunctionsArithmetic(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	assert.Equals(t, 6.5, library.Add(1, 2, 3.5), "Add")
+	assert.Equals(t, -1.0, library.Subtract(2, 3), "Subtract")
+	assert.Equals(t, 7.5, library.Multiply(2.5, 3), "Multiply")
+	assert.Equals(t, 2.5, library.Divide(5, 2), "Divide")
+	assert.Equals(t, "__EXCEPTION__:message:<<Division by zero>>", library.Divide(5, 0), "Divide by zero")
+	assert.Equals(t, 3.0, library.Maximum(1, 3, 2), "Maximum")
+	assert.Equals(t, 1.0, library.Minimum(2, 1, 3), "Minimum")
+	assert.Equals(t, 4.0, library.Minimum(4), "Minimum of one value")
+}
+
+func TestNumberFunctionsComparisons(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	assert.IsTrue(t, library.IsEqual(2, 2.0), "IsEqual")
+	assert.IsTrue(t, !library.IsEqual(2, 2.1), "not IsEqual")
+	assert.IsTrue(t, library.IsGreaterThan(3, 2), "IsGreaterThan")
+	assert.IsTrue(t, !library.IsGreaterThan(2, 2), "not IsGreaterThan")
+	assert.IsTrue(t, library.IsLessThan(1, 2), "IsLessThan")
+	assert.IsTrue(t, !library.IsLessThan(2, 2), "not IsLessThan")
+}
+
+func TestNumberFunctionsRandom(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	library.random = rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		value := library.RandomInteger(5, 7).(int)
+		assert.IsTrue(t, value >= 5 && value <= 7, "RandomInteger in range")
+	}
+	assert.Equals(t, 3, library.RandomInteger(3, 3), "RandomInteger with single value")
+	assert.Equals(t, "__EXCEPTION__:message:<<Maximum 1 is less than minimum 2>>", library.RandomInteger(2, 1), "RandomInteger with wrong range")
+	assert.IsTrue(t, regexp.MustCompile("^[a-zA-Z0-9]{12}$").MatchString(library.RandomString(12)), "RandomString")
+	uuidPattern := regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+	id1 := library.UniqueID().(string)
+	assert.IsTrue(t, uuidPattern.MatchString(id1), "UniqueID is a version 4 UUID")
+	assert.IsTrue(t, id1 != library.UniqueID(), "UniqueID is unique")
+}
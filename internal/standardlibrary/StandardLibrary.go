@@ -12,7 +12,9 @@
 package standardlibrary
 
 import (
+	"math/rand"
 	"reflect"
+	"time"
 
 	"github.com/essenius/slim4go/internal/interfaces"
 	"github.com/essenius/slim4go/internal/slimentity"
@@ -25,18 +27,34 @@ const scriptTableActorName = "scriptTableActor"
 
 // StandardLibrary is the library that gets added to the library list by default.
 type StandardLibrary struct {
-	actors  interfaces.Stack
-	objects interfaces.Collector
+	actors             interfaces.Stack
+	objects            interfaces.ObjectHandler
+	instructionTimeout time.Duration
+	dateFormat         string
+	now                func() time.Time
+	sleep              func(time.Duration)
+	random             *rand.Rand
 }
 
-// New instantiates a new StandardLibrary.
-func New(actors interfaces.Stack, objects interfaces.Collector) *StandardLibrary {
+// New instantiates a new StandardLibrary. The instruction timeout limits the wait functions (0 means no limit).
+func New(actors interfaces.Stack, objects interfaces.ObjectHandler, instructionTimeout time.Duration) *StandardLibrary {
 	standardLibrary := new(StandardLibrary)
 	standardLibrary.actors = actors
 	standardLibrary.objects = objects
+	standardLibrary.instructionTimeout = instructionTimeout
+	standardLibrary.dateFormat = time.RFC3339
+	standardLibrary.now = time.Now
+	standardLibrary.sleep = time.Sleep
+	standardLibrary.random = rand.New(rand.NewSource(time.Now().UnixNano()))
 	return standardLibrary
 }
 
+// Helpers
+
+func exception(err error) string {
+	return slimprotocol.Exception(err.Error())
+}
+
 // Methods
 
 // CloneSymbol creates a clone of a symbol. If the symbol points to a struct, a copy of that struct is made.
@@ -12,12 +12,20 @@
 package standardlibrary
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/essenius/slim4go/internal/apperrors"
 	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
 )
 
-type MockCollection struct{}
+// MockCollection emulates an object handler. InvokeMemberOn returns the responses one by one, repeating the last one.
+type MockCollection struct {
+	responses []string
+	calls     int
+	lastArgs  *slimentity.SlimList
+}
 
 func (collection *MockCollection) Add(name string, value interface{}) {
 }
@@ -34,10 +42,43 @@ func (collection *MockCollection) Length() int {
 	return 1
 }
 
+func (collection *MockCollection) AddObjectByConstructor(instanceName string, constructor reflect.Value, args []string) error {
+	return nil
+}
+
+func (collection *MockCollection) Deserialize(objectType reflect.Type, seralizedForm string) (interface{}, error) {
+	return nil, nil
+}
+
+func (collection *MockCollection) HasMemberOn(instance interface{}, memberName string, argCount int) bool {
+	return memberName != "nonexisting"
+}
+
+func (collection *MockCollection) InstancesWithPrefix(prefix string) []interface{} {
+	return []interface{}{}
+}
+
+func (collection *MockCollection) InvokeMemberOn(instance interface{}, memberName string, args *slimentity.SlimList) (slimentity.SlimEntity, error) {
+	if memberName == "nonexisting" {
+		return nil, &apperrors.NotFoundError{Entity: "member", Description: memberName}
+	}
+	collection.lastArgs = args
+	index := collection.calls
+	if index >= len(collection.responses) {
+		index = len(collection.responses) - 1
+	}
+	collection.calls++
+	return collection.responses[index], nil
+}
+
+func (collection *MockCollection) Serialize(instance interface{}) string {
+	return ""
+}
+
 const instanceName = "scriptTableActor"
 
 func TestStandardLibraryStack(t *testing.T) {
-	library := New(NewActorStack(), new(MockCollection))
+	library := New(NewActorStack(), new(MockCollection), 0)
 	assert.Equals(t, scriptTableActorName, library.GetFixture(), "GetFixture returns right result")
 	assert.Equals(t, 0, library.actors.Length(), "Initial actors length == 0")
 	assert.Equals(t, nil, library.PushFixture(), "Push fixture succeeds")
@@ -48,7 +89,7 @@ func TestStandardLibraryStack(t *testing.T) {
 }
 
 func TestSlimLibaryCloneSymbol(t *testing.T) {
-	library := New(NewActorStack(), nil)
+	library := New(NewActorStack(), nil, 0)
 	assert.Equals(t, "clone", library.CloneSymbol("clone"), "clone")
 	// A clone of a symbol should really be a clone, not a pointer to the same instance.
 	s := "string in variable"
@@ -0,0 +1,98 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Text functions of the standard library. Functions that can fail return a Slim exception.
+
+// Concatenate joins all parameters into one string.
+func (standardLibrary *StandardLibrary) Concatenate(values ...string) string {
+	return strings.Join(values, "")
+}
+
+// EnvironmentVariable returns the value of an environment variable, or null if it doesn't exist.
+func (standardLibrary *StandardLibrary) EnvironmentVariable(name string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return slimprotocol.Null()
+}
+
+// Extract returns the first submatch of the regular expression in the input, or the whole match if there are no groups.
+// It returns null if there is no match.
+func (standardLibrary *StandardLibrary) Extract(input, pattern string) slimentity.SlimEntity {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return exception(err)
+	}
+	match := regex.FindStringSubmatch(input)
+	switch len(match) {
+	case 0:
+		return slimprotocol.Null()
+	case 1:
+		return match[0]
+	default:
+		return match[1]
+	}
+}
+
+// Matches returns whether the input matches the regular expression.
+func (standardLibrary *StandardLibrary) Matches(input, pattern string) slimentity.SlimEntity {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return exception(err)
+	}
+	return regex.MatchString(input)
+}
+
+// Replace replaces all occurrences of a string in the input by a replacement.
+func (standardLibrary *StandardLibrary) Replace(input, old, replacement string) string {
+	return strings.ReplaceAll(input, old, replacement)
+}
+
+// ReplaceRegex replaces all matches of the regular expression in the input by the replacement, which can refer to groups via $1 etc.
+// Use ${1} if a group reference is followed by a letter or digit; symbol expressions leave digits between ${ and } alone.
+func (standardLibrary *StandardLibrary) ReplaceRegex(input, pattern, replacement string) slimentity.SlimEntity {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return exception(err)
+	}
+	return regex.ReplaceAllString(input, replacement)
+}
+
+// StringLength returns the number of characters in the input.
+func (standardLibrary *StandardLibrary) StringLength(input string) int {
+	return len([]rune(input))
+}
+
+// ToLower converts the input to lower case.
+func (standardLibrary *StandardLibrary) ToLower(input string) string {
+	return strings.ToLower(input)
+}
+
+// ToUpper converts the input to upper case.
+func (standardLibrary *StandardLibrary) ToUpper(input string) string {
+	return strings.ToUpper(input)
+}
+
+// Trim removes leading and trailing white space from the input.
+func (standardLibrary *StandardLibrary) Trim(input string) string {
+	return strings.TrimSpace(input)
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"os"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestTextFunctions(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	assert.Equals(t, "abc", library.Concatenate("a", "b", "c"), "Concatenate")
+	assert.Equals(t, "", library.Concatenate(), "Concatenate without parameters")
+	assert.Equals(t, "a-b-c", library.Replace("a b c", " ", "-"), "Replace")
+	assert.Equals(t, "x1 y2", library.ReplaceRegex("1x 2y", "([0-9])([a-z])", "$2$1"), "ReplaceRegex with groups")
+	assert.Equals(t, true, library.Matches("order 123", "[0-9]+$"), "Matches")
+	assert.Equals(t, false, library.Matches("order", "[0-9]+$"), "Does not match")
+	assert.Equals(t, "123", library.Extract("order 123 shipped", "order ([0-9]+)"), "Extract group")
+	assert.Equals(t, "123", library.Extract("order 123 shipped", "[0-9]+"), "Extract match")
+	assert.Equals(t, "null", library.Extract("order", "[0-9]+"), "Extract without match")
+	assert.Equals(t, "__EXCEPTION__:message:<<error parsing regexp: missing closing ): `(`>>", library.Matches("a", "("), "Invalid regex")
+	assert.Equals(t, "abc", library.Trim("  abc \t"), "Trim")
+	assert.Equals(t, "ABC", library.ToUpper("aBc"), "ToUpper")
+	assert.Equals(t, "abc", library.ToLower("aBc"), "ToLower")
+	assert.Equals(t, 4, library.StringLength("€uro"), "StringLength counts characters")
+}
+
+func TestTextFunctionsEnvironmentVariable(t *testing.T) {
+	library := New(NewActorStack(), nil, 0)
+	os.Setenv("SLIM4GO_TEST_VARIABLE", "value")
+	defer os.Unsetenv("SLIM4GO_TEST_VARIABLE")
+	assert.Equals(t, "value", library.EnvironmentVariable("SLIM4GO_TEST_VARIABLE"), "Existing variable")
+	assert.Equals(t, "null", library.EnvironmentVariable("SLIM4GO_NONEXISTING_VARIABLE"), "Nonexisting variable")
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Wait and poll functions of the standard library, so eventual consistency checks don't need custom fixtures.
+// Durations are specified in seconds. A wait must be shorter than the instruction timeout: the instruction would
+// time out anyway, and this way the test gets a clear message instead of a timeout while the wait continues.
+
+// Helpers
+
+func resultText(result slimentity.SlimEntity) string {
+	if text, ok := result.(string); ok {
+		return text
+	}
+	if slimentity.IsSlimList(result) {
+		return slimentity.ToString(result)
+	}
+	return fmt.Sprintf("%v", result)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func (standardLibrary *StandardLibrary) checkWaitTime(waitTime time.Duration) error {
+	if waitTime < 0 {
+		return fmt.Errorf("Wait time %v should not be negative", waitTime)
+	}
+	if standardLibrary.instructionTimeout > 0 && waitTime >= standardLibrary.instructionTimeout {
+		return fmt.Errorf("Wait time %v should be less than the instruction timeout (%v)", waitTime, standardLibrary.instructionTimeout)
+	}
+	return nil
+}
+
+// Methods
+
+// PollUntilTrue invokes a method on the current script table actor until it returns true, or until the timeout expires.
+// It returns whether the method returned true in time, so it can be used with ensure.
+func (standardLibrary *StandardLibrary) PollUntilTrue(methodName string, timeoutSeconds, intervalSeconds float64, args ...string) slimentity.SlimEntity {
+	return standardLibrary.RepeatUntil(methodName, "true", timeoutSeconds, intervalSeconds, args...)
+}
+
+// RepeatUntil invokes a method on the current script table actor until it returns the expected value, or until the timeout expires.
+// The method is invoked every interval. It returns whether the expected value was returned in time.
+func (standardLibrary *StandardLibrary) RepeatUntil(methodName, expected string, timeoutSeconds, intervalSeconds float64, args ...string) slimentity.SlimEntity {
+	actor := standardLibrary.GetFixture()
+	if actor == nil {
+		return slimprotocol.Exceptionf("No script table actor to invoke '%v' on", methodName)
+	}
+	timeout := secondsToDuration(timeoutSeconds)
+	if err := standardLibrary.checkWaitTime(timeout); err != nil {
+		return exception(err)
+	}
+	interval := secondsToDuration(intervalSeconds)
+	if interval <= 0 {
+		return slimprotocol.Exceptionf("Interval %v should be positive", interval)
+	}
+	argList := slimentity.NewSlimList()
+	for _, arg := range args {
+		argList.Append(arg)
+	}
+	deadline := standardLibrary.now().Add(timeout)
+	for {
+		result, err := standardLibrary.objects.InvokeMemberOn(actor, methodName, argList)
+		if err != nil {
+			return exception(err)
+		}
+		if resultText(result) == expected {
+			return true
+		}
+		remaining := deadline.Sub(standardLibrary.now())
+		if remaining <= 0 {
+			return false
+		}
+		if remaining < interval {
+			standardLibrary.sleep(remaining)
+		} else {
+			standardLibrary.sleep(interval)
+		}
+	}
+}
+
+// WaitSeconds waits for the specified number of seconds.
+func (standardLibrary *StandardLibrary) WaitSeconds(seconds float64) slimentity.SlimEntity {
+	waitTime := secondsToDuration(seconds)
+	if err := standardLibrary.checkWaitTime(waitTime); err != nil {
+		return exception(err)
+	}
+	standardLibrary.sleep(waitTime)
+	return slimprotocol.Void()
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package standardlibrary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+// newLibraryWithClock returns a library with a simulated clock that advances when the library sleeps.
+func newLibraryWithClock(objects *MockCollection, instructionTimeout time.Duration) (*StandardLibrary, *time.Duration) {
+	library := New(NewActorStack(), objects, instructionTimeout)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	slept := new(time.Duration)
+	library.now = func() time.Time { return start.Add(*slept) }
+	library.sleep = func(duration time.Duration) { *slept += duration }
+	return library, slept
+}
+
+func TestWaitFunctionsWaitSeconds(t *testing.T) {
+	library, slept := newLibraryWithClock(new(MockCollection), 10*time.Second)
+	assert.Equals(t, "/__VOID__/", library.WaitSeconds(1.5), "Wait succeeds")
+	assert.Equals(t, 1500*time.Millisecond, *slept, "Waited 1.5 seconds")
+	assert.Equals(t, "__EXCEPTION__:message:<<Wait time 10s should be less than the instruction timeout (10s)>>",
+		library.WaitSeconds(10), "Wait as long as the instruction timeout")
+	assert.Equals(t, "__EXCEPTION__:message:<<Wait time -1s should not be negative>>", library.WaitSeconds(-1), "Negative wait")
+	assert.Equals(t, 1500*time.Millisecond, *slept, "Failed waits did not sleep")
+}
+
+func TestWaitFunctionsRepeatUntil(t *testing.T) {
+	objects := &MockCollection{responses: []string{"1", "2", "3"}}
+	library, slept := newLibraryWithClock(objects, 0)
+	assert.Equals(t, true, library.RepeatUntil("value", "3", 5, 0.5, "arg"), "Expected value reached")
+	assert.Equals(t, 3, objects.calls, "Invoked three times")
+	assert.Equals(t, time.Second, *slept, "Slept two intervals")
+	assert.Equals(t, "[arg]", objects.lastArgs.ToString(), "Arguments passed")
+
+	objects.calls = 0
+	*slept = 0
+	assert.Equals(t, false, library.RepeatUntil("value", "4", 1.2, 0.5), "Expected value not reached")
+	assert.Equals(t, 4, objects.calls, "Invoked at start, after 0.5s, 1s and 1.2s")
+	assert.Equals(t, 1200*time.Millisecond, *slept, "Slept until the timeout")
+
+	assert.Equals(t, "__EXCEPTION__:message:<<nonexisting: member not found>>",
+		library.RepeatUntil("nonexisting", "1", 1, 0.5), "Nonexisting method")
+	assert.Equals(t, "__EXCEPTION__:message:<<Interval 0s should be positive>>", library.RepeatUntil("value", "1", 1, 0), "Zero interval")
+}
+
+func TestWaitFunctionsPollUntilTrue(t *testing.T) {
+	objects := &MockCollection{responses: []string{"false", "false", "true"}}
+	library, _ := newLibraryWithClock(objects, 2*time.Second)
+	assert.Equals(t, true, library.PollUntilTrue("isReady", 1.5, 0.1), "Polling succeeds")
+	assert.Equals(t, 3, objects.calls, "Polled three times")
+	assert.Equals(t, "__EXCEPTION__:message:<<Wait time 3s should be less than the instruction timeout (2s)>>",
+		library.PollUntilTrue("isReady", 3, 0.1), "Poll timeout exceeds instruction timeout")
+}
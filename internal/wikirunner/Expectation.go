@@ -0,0 +1,143 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Evaluation of results against expectations, supporting the FitNesse comparison syntax:
+// =~/regex/, !~/regex/, < 5, <= 5, > 5, >= 5, != value and ranges like 3 < _ <= 7.
+
+const exceptionPrefix = "__EXCEPTION__:"
+
+var exceptionMessageRegex = regexp.MustCompile(`message:<<(?s)(.*)>>`)
+var regexExpectationRegex = regexp.MustCompile(`^(=~|!~)/(.*)/$`)
+var comparisonRegex = regexp.MustCompile(`^(<=|>=|<|>|!=)\s*(.*)$`)
+var rangeRegex = regexp.MustCompile(`^(.+?)\s*(<=|<)\s*_\s*(<=|<)\s*(.+)$`)
+
+// Helpers
+
+func isException(result slimentity.SlimEntity) bool {
+	text, ok := result.(string)
+	return ok && strings.HasPrefix(text, exceptionPrefix)
+}
+
+func isAbort(result slimentity.SlimEntity) bool {
+	text, ok := result.(string)
+	return ok && (strings.HasPrefix(text, exceptionPrefix+"ABORT_SLIM_TEST") || strings.HasPrefix(text, exceptionPrefix+"ABORT_SLIM_SUITE"))
+}
+
+func isMissingMethod(result slimentity.SlimEntity) bool {
+	text, ok := result.(string)
+	return ok && strings.HasPrefix(text, exceptionPrefix+"message:<<NO_METHOD_IN_CLASS")
+}
+
+// exceptionMessage returns the readable part of an exception.
+func exceptionMessage(result slimentity.SlimEntity) string {
+	text := resultText(result)
+	if match := exceptionMessageRegex.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+	return strings.TrimPrefix(text, exceptionPrefix)
+}
+
+func resultText(result slimentity.SlimEntity) string {
+	switch value := result.(type) {
+	case nil:
+		return slimprotocol.Null()
+	case string:
+		if value == slimprotocol.Void() {
+			return ""
+		}
+		return value
+	default:
+		return slimentity.ToString(result)
+	}
+}
+
+func compareNumbers(operator string, actual, expected float64) bool {
+	switch operator {
+	case "<":
+		return actual < expected
+	case "<=":
+		return actual <= expected
+	case ">":
+		return actual > expected
+	case ">=":
+		return actual >= expected
+	default:
+		return actual != expected
+	}
+}
+
+// matchesComparison returns whether the comparison applies, and if so whether the actual value satisfies it.
+func matchesComparison(expected, actual string) (bool, bool) {
+	if match := regexExpectationRegex.FindStringSubmatch(expected); match != nil {
+		regex, err := regexp.Compile(match[2])
+		if err != nil {
+			return false, false
+		}
+		return true, regex.MatchString(actual) == (match[1] == "=~")
+	}
+	actualNumber, actualErr := strconv.ParseFloat(actual, 64)
+	if match := rangeRegex.FindStringSubmatch(expected); match != nil {
+		lower, lowerErr := strconv.ParseFloat(match[1], 64)
+		upper, upperErr := strconv.ParseFloat(match[4], 64)
+		if lowerErr == nil && upperErr == nil {
+			return true, actualErr == nil && compareNumbers(reverse(match[2]), actualNumber, lower) && compareNumbers(match[3], actualNumber, upper)
+		}
+	}
+	if match := comparisonRegex.FindStringSubmatch(expected); match != nil {
+		expectedNumber, expectedErr := strconv.ParseFloat(match[2], 64)
+		if expectedErr == nil && actualErr == nil {
+			return true, compareNumbers(match[1], actualNumber, expectedNumber)
+		}
+		if match[1] == "!=" {
+			return true, actual != match[2]
+		}
+	}
+	return false, false
+}
+
+func reverse(operator string) string {
+	return strings.Replace(operator, "<", ">", 1)
+}
+
+// Methods
+
+// Evaluate compares a result with the expected value and returns the outcome and a message explaining it.
+func Evaluate(expected string, result slimentity.SlimEntity) (Outcome, string) {
+	if isException(result) {
+		return Error, exceptionMessage(result)
+	}
+	actual := resultText(result)
+	if expected == "" {
+		return Ignore, fmt.Sprintf("[%v]", actual)
+	}
+	if actual == expected {
+		return Right, ""
+	}
+	if applies, ok := matchesComparison(expected, actual); applies {
+		if ok {
+			return Right, ""
+		}
+		return Wrong, fmt.Sprintf("[%v] does not satisfy [%v]", actual, expected)
+	}
+	return Wrong, fmt.Sprintf("expected [%v] but was [%v]", expected, actual)
+}
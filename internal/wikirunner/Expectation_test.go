@@ -0,0 +1,61 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+func TestExpectationEvaluate(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   slimentity.SlimEntity
+		outcome  Outcome
+		message  string
+	}{
+		{"abc", "abc", Right, ""},
+		{"abc", "abd", Wrong, "expected [abc] but was [abd]"},
+		{"", "abc", Ignore, "[abc]"},
+		{"", slimprotocol.Void(), Ignore, "[]"},
+		{"abc", slimprotocol.Exception("problem"), Error, "problem"},
+		{"=~/b+/", "abbc", Right, ""},
+		{"=~/x/", "abc", Wrong, "[abc] does not satisfy [=~/x/]"},
+		{"!~/x/", "abc", Right, ""},
+		{"< 5", "4", Right, ""},
+		{">= 5", "4", Wrong, "[4] does not satisfy [>= 5]"},
+		{"!=abc", "abd", Right, ""},
+		{"!= 4", "4.0", Wrong, "[4.0] does not satisfy [!= 4]"},
+		{"3 < _ <= 7", "7", Right, ""},
+		{"3 < _ <= 7", "3", Wrong, "[3] does not satisfy [3 < _ <= 7]"},
+		{"3 < _ < 7", "a", Wrong, "[a] does not satisfy [3 < _ < 7]"},
+		{"< a", "b", Wrong, "expected [< a] but was [b]"},
+		{"[a, b]", slimentity.NewSlimListContaining([]slimentity.SlimEntity{"a", "b"}), Right, ""},
+		{"null", nil, Right, ""},
+	}
+	for _, test := range tests {
+		outcome, message := Evaluate(test.expected, test.actual)
+		assert.Equals(t, test.outcome, outcome, test.expected+" outcome")
+		assert.Equals(t, test.message, message, test.expected+" message")
+	}
+}
+
+func TestExpectationExceptions(t *testing.T) {
+	assert.IsTrue(t, isAbort(slimprotocol.AbortTest("stop")), "Abort test")
+	assert.IsTrue(t, isAbort(slimprotocol.AbortSuite("stop")), "Abort suite")
+	assert.IsTrue(t, !isAbort(slimprotocol.Exception("stop")), "Normal exception")
+	assert.IsTrue(t, isMissingMethod(slimprotocol.NoMethodInFixture("reset", "Fixture", 0)), "Missing method")
+	assert.Equals(t, "ABORT_SLIM_TEST", exceptionMessage("__EXCEPTION__:ABORT_SLIM_TEST"), "Exception without message")
+}
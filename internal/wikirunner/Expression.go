@@ -0,0 +1,136 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Arithmetic expressions as used in FitNesse wiki pages (${= expression =}): numbers, + - * / %, unary minus and parentheses.
+
+// Definitions
+
+type expression struct {
+	input string
+	pos   int
+}
+
+// Helpers
+
+func (parser *expression) skipSpaces() {
+	for parser.pos < len(parser.input) && parser.input[parser.pos] == ' ' {
+		parser.pos++
+	}
+}
+
+func (parser *expression) peek() byte {
+	parser.skipSpaces()
+	if parser.pos < len(parser.input) {
+		return parser.input[parser.pos]
+	}
+	return 0
+}
+
+func (parser *expression) sum() (float64, error) {
+	result, err := parser.product()
+	for err == nil && (parser.peek() == '+' || parser.peek() == '-') {
+		operator := parser.input[parser.pos]
+		parser.pos++
+		var operand float64
+		if operand, err = parser.product(); err == nil {
+			if operator == '+' {
+				result += operand
+			} else {
+				result -= operand
+			}
+		}
+	}
+	return result, err
+}
+
+func (parser *expression) product() (float64, error) {
+	result, err := parser.unary()
+	for err == nil && (parser.peek() == '*' || parser.peek() == '/' || parser.peek() == '%') {
+		operator := parser.input[parser.pos]
+		parser.pos++
+		var operand float64
+		if operand, err = parser.unary(); err != nil {
+			break
+		}
+		switch operator {
+		case '*':
+			result *= operand
+		case '/':
+			if operand == 0 {
+				return 0, fmt.Errorf("Division by zero in '%v'", parser.input)
+			}
+			result /= operand
+		default:
+			if operand == 0 {
+				return 0, fmt.Errorf("Division by zero in '%v'", parser.input)
+			}
+			result = float64(int64(result) % int64(operand))
+		}
+	}
+	return result, err
+}
+
+func (parser *expression) unary() (float64, error) {
+	if parser.peek() == '-' {
+		parser.pos++
+		value, err := parser.unary()
+		return -value, err
+	}
+	return parser.primary()
+}
+
+func (parser *expression) primary() (float64, error) {
+	if parser.peek() == '(' {
+		parser.pos++
+		value, err := parser.sum()
+		if err != nil {
+			return 0, err
+		}
+		if parser.peek() != ')' {
+			return 0, fmt.Errorf("Missing ')' in '%v'", parser.input)
+		}
+		parser.pos++
+		return value, nil
+	}
+	start := parser.pos
+	for parser.pos < len(parser.input) && (unicode.IsDigit(rune(parser.input[parser.pos])) || parser.input[parser.pos] == '.') {
+		parser.pos++
+	}
+	value, err := strconv.ParseFloat(parser.input[start:parser.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("Expected number at position %v in '%v'", start+1, parser.input)
+	}
+	return value, nil
+}
+
+// Methods
+
+// EvaluateExpression evaluates an arithmetic expression.
+func EvaluateExpression(input string) (float64, error) {
+	parser := &expression{input: strings.TrimSpace(input)}
+	value, err := parser.sum()
+	if err != nil {
+		return 0, err
+	}
+	if parser.peek() != 0 {
+		return 0, fmt.Errorf("Unexpected '%v' in '%v'", parser.input[parser.pos:], parser.input)
+	}
+	return value, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestExpressionEvaluate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"20 * 9/5 + 32", 68},
+		{"-3 - -2", -1},
+		{"7 % 4", 3},
+		{" 1.5 ", 1.5},
+	}
+	for _, test := range tests {
+		actual, err := EvaluateExpression(test.input)
+		assert.Equals(t, nil, err, test.input+" no error")
+		assert.Equals(t, test.expected, actual, test.input)
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "Division by zero in '1 / 0'"},
+		{"1 % 0", "Division by zero in '1 % 0'"},
+		{"(1 + 2", "Missing ')' in '(1 + 2'"},
+		{"1 + a", "Expected number at position 5 in '1 + a'"},
+		{"1 2", "Unexpected '2' in '1 2'"},
+	}
+	for _, test := range tests {
+		_, err := EvaluateExpression(test.input)
+		assert.Equals(t, test.expected, err.Error(), test.input)
+	}
+}
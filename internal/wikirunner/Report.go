@@ -0,0 +1,110 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Definitions
+
+// Outcome is the result of evaluating a cell, using the FitNesse categories.
+type Outcome int
+
+// The possible outcomes.
+const (
+	Right Outcome = iota
+	Wrong
+	Ignore
+	Error
+)
+
+// Counts contains the number of outcomes per category, like FitNesse shows them.
+type Counts struct {
+	Right      int
+	Wrong      int
+	Ignores    int
+	Exceptions int
+}
+
+// Result is the outcome of a cell, with the page and line it was found on and a message explaining it.
+type Result struct {
+	Page    string
+	Line    int
+	Outcome Outcome
+	Message string
+}
+
+// Report contains the results of running a page (including its set up and tear down pages).
+type Report struct {
+	Page    string
+	Aborted bool
+	Counts
+	Results []*Result
+}
+
+// NewReport creates a new Report for a page.
+func NewReport(page string) *Report {
+	report := new(Report)
+	report.Page = page
+	return report
+}
+
+// Outcome methods
+
+func (outcome Outcome) String() string {
+	return [...]string{"right", "wrong", "ignored", "exception"}[outcome]
+}
+
+// Counts methods
+
+func (counts Counts) String() string {
+	return fmt.Sprintf("%v right, %v wrong, %v ignored, %v exceptions", counts.Right, counts.Wrong, counts.Ignores, counts.Exceptions)
+}
+
+// Report methods
+
+// Add adds the outcome of a cell to the report.
+func (report *Report) Add(page string, line int, outcome Outcome, message string) {
+	switch outcome {
+	case Right:
+		report.Right++
+	case Wrong:
+		report.Wrong++
+	case Ignore:
+		report.Ignores++
+	case Error:
+		report.Exceptions++
+	}
+	report.Results = append(report.Results, &Result{Page: page, Line: line, Outcome: outcome, Message: message})
+}
+
+// Passed returns whether the page passed, i.e. had no wrong results or exceptions.
+func (report *Report) Passed() bool {
+	return report.Wrong == 0 && report.Exceptions == 0 && !report.Aborted
+}
+
+// String returns a summary of the report, listing the wrong results and exceptions.
+func (report *Report) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%v: %v\n", report.Page, report.Counts))
+	for _, result := range report.Results {
+		if result.Outcome == Wrong || result.Outcome == Error {
+			builder.WriteString(fmt.Sprintf("  %v:%v: %v: %v\n", result.Page, result.Line, result.Outcome, result.Message))
+		}
+	}
+	if report.Aborted {
+		builder.WriteString("  aborted\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestReport(t *testing.T) {
+	report := NewReport("MyTest")
+	report.Add("MyTest", 3, Right, "")
+	report.Add("MyTest", 4, Wrong, "expected [a] but was [b]")
+	report.Add("SetUp", 1, Ignore, "[a]")
+	report.Add("MyTest", 5, Error, "problem")
+	assert.Equals(t, Counts{Right: 1, Wrong: 1, Ignores: 1, Exceptions: 1}, report.Counts, "Counts")
+	assert.Equals(t, 4, len(report.Results), "Results")
+	assert.IsTrue(t, !report.Passed(), "Not passed")
+	assert.Equals(t, "MyTest: 1 right, 1 wrong, 1 ignored, 1 exceptions\n"+
+		"  MyTest:4: wrong: expected [a] but was [b]\n"+
+		"  MyTest:5: exception: problem\n", report.String(), "String")
+	report.Aborted = true
+	assert.Equals(t, "  aborted\n", report.String()[len(report.String())-10:], "Aborted")
+
+	passed := NewReport("MyTest")
+	passed.Add("MyTest", 3, Right, "")
+	passed.Add("MyTest", 4, Ignore, "")
+	assert.IsTrue(t, passed.Passed(), "Passed")
+}
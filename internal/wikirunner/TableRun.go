@@ -0,0 +1,591 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// A table run translates a table into Slim instructions the way FitNesse does, and evaluates the results.
+// Every instruction comes with an evaluation function that gets called with its result.
+// Supported are script, decision, query (including subset and ordered), table, import, library and comment tables.
+
+// Definitions and constructors
+
+type step struct {
+	id       string
+	evaluate func(result slimentity.SlimEntity)
+}
+
+type tableRun struct {
+	id           string
+	page         string
+	table        *Table
+	instructions *slimentity.SlimList
+	steps        []*step
+	report       *Report
+	symbols      map[string]string
+	// failedInstances contains the instances that could not be made, so we don't report each call on them.
+	failedInstances map[string]bool
+}
+
+const scriptTableActor = "scriptTableActor"
+
+var symbolAssignmentRegex = regexp.MustCompile(`^\$(\w+)=$`)
+var symbolReferenceRegex = regexp.MustCompile(`\$(\w+)`)
+
+func newTableRun(id, page string, table *Table, report *Report, symbols map[string]string) *tableRun {
+	run := new(tableRun)
+	run.id = id
+	run.page = page
+	run.table = table
+	run.instructions = slimentity.NewSlimList()
+	run.report = report
+	run.symbols = symbols
+	run.failedInstances = make(map[string]bool)
+	return run
+}
+
+// Helpers
+
+func upperFirst(text string) string {
+	if text == "" {
+		return text
+	}
+	runes := []rune(text)
+	return string(unicode.ToUpper(runes[0])) + string(runes[1:])
+}
+
+// methodName converts the words in the cells into a method name, e.g. get value becomes getValue.
+func methodName(cells ...string) string {
+	words := strings.Fields(strings.Join(cells, " "))
+	for i := range words {
+		words[i] = upperFirst(words[i])
+	}
+	name := []rune(strings.Join(words, ""))
+	if len(name) == 0 {
+		return ""
+	}
+	return string(unicode.ToLower(name[0])) + string(name[1:])
+}
+
+// fixtureName converts a fixture name as used in a table into a type name, e.g. memo object becomes MemoObject.
+// Qualified names are kept as is.
+func fixtureName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	words := strings.Fields(name)
+	for i := range words {
+		words[i] = upperFirst(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// splitMethod separates the method name from the arguments. Names and arguments alternate,
+// unless a cell ends with a semicolon: then the cells up to there form the name, and the rest are arguments.
+func splitMethod(cells []string) (string, []interface{}) {
+	var nameParts []string
+	var args []interface{}
+	for i, cell := range cells {
+		if strings.HasSuffix(cell, ";") {
+			nameParts = append(nameParts, strings.TrimSuffix(cell, ";"))
+			for _, arg := range cells[i+1:] {
+				args = append(args, arg)
+			}
+			return methodName(nameParts...), args
+		}
+	}
+	for i, cell := range cells {
+		if i%2 == 0 {
+			nameParts = append(nameParts, cell)
+		} else {
+			args = append(args, cell)
+		}
+	}
+	return methodName(nameParts...), args
+}
+
+func tableArgument(rows [][]string) *slimentity.SlimList {
+	table := slimentity.NewSlimList()
+	for _, row := range rows {
+		list := slimentity.NewSlimList()
+		for _, cell := range row {
+			list.Append(cell)
+		}
+		table.Append(list)
+	}
+	return table
+}
+
+func stringArgs(values []string) []interface{} {
+	var args []interface{}
+	for _, value := range values {
+		args = append(args, value)
+	}
+	return args
+}
+
+// queryRows converts a query result (a list of rows, being lists of field name/value pairs) into maps.
+func queryRows(result slimentity.SlimEntity) ([]map[string]string, bool) {
+	list, ok := result.(*slimentity.SlimList)
+	if !ok {
+		return nil, false
+	}
+	var rows []map[string]string
+	for _, rowEntity := range *list {
+		row, ok := rowEntity.(*slimentity.SlimList)
+		if !ok {
+			return nil, false
+		}
+		fields := make(map[string]string)
+		for _, fieldEntity := range *row {
+			field, ok := fieldEntity.(*slimentity.SlimList)
+			if !ok || field.Length() != 2 {
+				return nil, false
+			}
+			fields[resultText(field.ElementAt(0))] = resultText(field.ElementAt(1))
+		}
+		rows = append(rows, fields)
+	}
+	return rows, true
+}
+
+func fieldValue(row map[string]string, name string) (string, bool) {
+	if value, ok := row[name]; ok {
+		return value, true
+	}
+	for key, value := range row {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Instructions
+
+func (run *tableRun) add(evaluate func(slimentity.SlimEntity), instruction ...interface{}) {
+	id := fmt.Sprintf("%v_%v", run.id, len(run.steps))
+	list := slimentity.NewSlimList()
+	list.Append(id)
+	for _, part := range instruction {
+		list.Append(part)
+	}
+	run.instructions.Append(list)
+	run.steps = append(run.steps, &step{id: id, evaluate: evaluate})
+}
+
+// later adds an evaluation without instruction, so the result is reported in the order of the rows.
+func (run *tableRun) later(line int, outcome Outcome, message string) {
+	run.steps = append(run.steps, &step{evaluate: func(slimentity.SlimEntity) {
+		run.addResult(line, outcome, message)
+	}})
+}
+
+func (run *tableRun) call(evaluate func(slimentity.SlimEntity), instanceName, method string, args ...interface{}) {
+	run.add(evaluate, append([]interface{}{"call", instanceName, method}, args...)...)
+}
+
+func (run *tableRun) callAndAssign(evaluate func(slimentity.SlimEntity), symbol, instanceName, method string, args ...interface{}) {
+	run.add(evaluate, append([]interface{}{"callAndAssign", symbol, instanceName, method}, args...)...)
+}
+
+func (run *tableRun) make(line int, instanceName, fixture string, args []string) {
+	evaluate := func(result slimentity.SlimEntity) {
+		delete(run.failedInstances, instanceName)
+		if run.exception(line, result) {
+			run.failedInstances[instanceName] = true
+		}
+	}
+	run.add(evaluate, append([]interface{}{"make", instanceName, fixtureName(fixture)}, stringArgs(args)...)...)
+}
+
+// Evaluations
+
+func (run *tableRun) addResult(line int, outcome Outcome, message string) {
+	run.report.Add(run.page, line, outcome, message)
+}
+
+// exception reports an exception result. It returns whether the result was an exception.
+func (run *tableRun) exception(line int, result slimentity.SlimEntity) bool {
+	if !isException(result) {
+		return false
+	}
+	if isAbort(result) {
+		run.report.Aborted = true
+	}
+	message := exceptionMessage(result)
+	if instance := strings.TrimPrefix(message, "NO_INSTANCE "); instance != message && run.failedInstances[instance] {
+		return true
+	}
+	run.addResult(line, Error, message)
+	return true
+}
+
+func (run *tableRun) noException(line int) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		run.exception(line, result)
+	}
+}
+
+// optional ignores results of methods that fixtures don't need to implement (e.g. reset and execute in decision tables).
+func (run *tableRun) optional(line int) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if !isMissingMethod(result) {
+			run.exception(line, result)
+		}
+	}
+}
+
+func (run *tableRun) replaceSymbols(text string) string {
+	return symbolReferenceRegex.ReplaceAllStringFunc(text, func(reference string) string {
+		if value, ok := run.symbols[reference[1:]]; ok {
+			return value
+		}
+		return reference
+	})
+}
+
+func (run *tableRun) expect(line int, description, expected string, negate bool) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if isException(result) {
+			run.exception(line, result)
+			return
+		}
+		outcome, message := Evaluate(run.replaceSymbols(expected), result)
+		if negate {
+			switch outcome {
+			case Right:
+				outcome, message = Wrong, fmt.Sprintf("[%v] was not expected", resultText(result))
+			case Wrong:
+				outcome, message = Right, ""
+			}
+		}
+		if message != "" {
+			message = description + ": " + message
+		}
+		run.addResult(line, outcome, message)
+	}
+}
+
+func (run *tableRun) expectBoolean(line int, description, expected string) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if run.exception(line, result) {
+			return
+		}
+		if actual := resultText(result); actual != expected {
+			run.addResult(line, Wrong, fmt.Sprintf("%v: expected [%v] but was [%v]", description, expected, actual))
+			return
+		}
+		run.addResult(line, Right, "")
+	}
+}
+
+// action evaluates a script row without keyword: true passes, false fails and other values are not counted.
+func (run *tableRun) action(line int, description string) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if run.exception(line, result) {
+			return
+		}
+		switch resultText(result) {
+		case "true":
+			run.addResult(line, Right, "")
+		case "false":
+			run.addResult(line, Wrong, description+": returned false")
+		}
+	}
+}
+
+func (run *tableRun) assignSymbol(line int, symbol string) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if !run.exception(line, result) {
+			run.symbols[symbol] = resultText(result)
+		}
+	}
+}
+
+// Table types
+
+func (run *tableRun) script(name string, args []string) {
+	if name != "" {
+		run.make(run.table.Line, scriptTableActor, name, args)
+	}
+	for index, row := range run.table.Rows[1:] {
+		run.scriptRow(run.table.Line+index+1, row)
+	}
+}
+
+func (run *tableRun) scriptRow(line int, row []string) {
+	keyword := strings.ToLower(row[0])
+	description := strings.Join(row, "|")
+	if keyword == "" || keyword == "note" || strings.HasPrefix(keyword, "#") || strings.HasPrefix(keyword, "*") {
+		return
+	}
+	minimumLength := map[string]int{"start": 2, "check": 3, "check not": 3, "ensure": 2, "reject": 2, "show": 2}
+	if length, ok := minimumLength[keyword]; ok && len(row) < length {
+		run.later(line, Error, fmt.Sprintf("%v: incomplete row", description))
+		return
+	}
+	switch keyword {
+	case "start":
+		run.make(line, scriptTableActor, row[1], row[2:])
+	case "check", "check not":
+		method, args := splitMethod(row[1 : len(row)-1])
+		run.call(run.expect(line, description, row[len(row)-1], keyword == "check not"), scriptTableActor, method, args...)
+	case "ensure":
+		method, args := splitMethod(row[1:])
+		run.call(run.expectBoolean(line, description, "true"), scriptTableActor, method, args...)
+	case "reject":
+		method, args := splitMethod(row[1:])
+		run.call(run.expectBoolean(line, description, "false"), scriptTableActor, method, args...)
+	case "show":
+		method, args := splitMethod(row[1:])
+		run.call(run.noException(line), scriptTableActor, method, args...)
+	default:
+		if match := symbolAssignmentRegex.FindStringSubmatch(row[0]); match != nil {
+			method, args := splitMethod(row[1:])
+			run.callAndAssign(run.assignSymbol(line, match[1]), match[1], scriptTableActor, method, args...)
+			return
+		}
+		method, args := splitMethod(row)
+		run.call(run.action(line, description), scriptTableActor, method, args...)
+	}
+}
+
+func (run *tableRun) decision(name string, args []string) {
+	instance := "decisionTable_" + run.id
+	run.make(run.table.Line, instance, name, args)
+	if len(run.table.Rows) < 2 {
+		return
+	}
+	headers := run.table.Rows[1]
+	run.call(run.optional(run.table.Line), instance, "table", tableArgument(run.table.Rows[1:]))
+	run.call(run.optional(run.table.Line), instance, "beginTable")
+	for index, row := range run.table.Rows[2:] {
+		line := run.table.Line + index + 2
+		run.call(run.optional(line), instance, "reset")
+		for column, header := range headers {
+			if column < len(row) && header != "" && !strings.HasPrefix(header, "#") && !strings.HasSuffix(header, "?") && !strings.HasSuffix(header, "!") {
+				run.call(run.noException(line), instance, "set"+upperFirst(methodName(header)), row[column])
+			}
+		}
+		run.call(run.optional(line), instance, "execute")
+		for column, header := range headers {
+			if column >= len(row) || !(strings.HasSuffix(header, "?") || strings.HasSuffix(header, "!")) {
+				continue
+			}
+			method := methodName(strings.TrimRight(header, "?!"))
+			if match := symbolAssignmentRegex.FindStringSubmatch(row[column]); match != nil {
+				run.callAndAssign(run.assignSymbol(line, match[1]), match[1], instance, method)
+			} else {
+				run.call(run.expect(line, header, row[column], false), instance, method)
+			}
+		}
+	}
+	run.call(run.optional(run.table.Line), instance, "endTable")
+}
+
+func (run *tableRun) query(kind, name string, args []string) {
+	instance := "queryTable_" + run.id
+	run.make(run.table.Line, instance, name, args)
+	if len(run.table.Rows) < 2 {
+		return
+	}
+	run.call(run.optional(run.table.Line), instance, "table", tableArgument(run.table.Rows[1:]))
+	run.call(run.matchQuery(kind), instance, "query")
+}
+
+func (run *tableRun) matchQuery(kind string) func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if run.exception(run.table.Line, result) {
+			return
+		}
+		actualRows, ok := queryRows(result)
+		if !ok {
+			run.addResult(run.table.Line, Error, fmt.Sprintf("Query result [%v] is not a list of rows", resultText(result)))
+			return
+		}
+		headers := run.table.Rows[1]
+		used := make([]bool, len(actualRows))
+		lastMatch := -1
+		for index, expectedRow := range run.table.Rows[2:] {
+			line := run.table.Line + index + 2
+			best, bestScore := -1, 0
+			for candidate, actualRow := range actualRows {
+				if used[candidate] {
+					continue
+				}
+				score := 0
+				for column, header := range headers {
+					value, found := fieldValue(actualRow, header)
+					if column < len(expectedRow) && found {
+						if outcome, _ := Evaluate(run.replaceSymbols(expectedRow[column]), value); outcome == Right {
+							score++
+						}
+					}
+				}
+				if score > bestScore {
+					best, bestScore = candidate, score
+				}
+			}
+			if best < 0 {
+				run.addResult(line, Wrong, fmt.Sprintf("missing row [%v]", strings.Join(expectedRow, "|")))
+				continue
+			}
+			used[best] = true
+			if kind == "ordered query" && best < lastMatch {
+				run.addResult(line, Wrong, fmt.Sprintf("row [%v] out of order", strings.Join(expectedRow, "|")))
+			}
+			lastMatch = best
+			for column, header := range headers {
+				if column >= len(expectedRow) {
+					continue
+				}
+				value, found := fieldValue(actualRows[best], header)
+				if !found {
+					run.addResult(line, Error, fmt.Sprintf("%v: field not found", header))
+					continue
+				}
+				outcome, message := Evaluate(run.replaceSymbols(expectedRow[column]), value)
+				if message != "" {
+					message = header + ": " + message
+				}
+				run.addResult(line, outcome, message)
+			}
+		}
+		if kind == "subset query" {
+			return
+		}
+		for index, actualRow := range actualRows {
+			if !used[index] {
+				run.addResult(run.table.Line+1, Wrong, fmt.Sprintf("surplus row %v", actualRow))
+			}
+		}
+	}
+}
+
+func (run *tableRun) tableTable(name string, args []string) {
+	instance := "tableTable_" + run.id
+	run.make(run.table.Line, instance, name, args)
+	run.call(run.tableTableResults(), instance, "doTable", tableArgument(run.table.Rows[1:]))
+}
+
+// tableTableResults evaluates the cells returned by doTable: pass, fail, ignore, report and error (optionally followed by
+// a colon and message), no change or empty. Anything else is shown as the actual value, and fails.
+func (run *tableRun) tableTableResults() func(slimentity.SlimEntity) {
+	return func(result slimentity.SlimEntity) {
+		if run.exception(run.table.Line, result) {
+			return
+		}
+		rows, ok := result.(*slimentity.SlimList)
+		if !ok {
+			run.addResult(run.table.Line, Error, fmt.Sprintf("Table result [%v] is not a list of rows", resultText(result)))
+			return
+		}
+		for rowIndex, rowEntity := range *rows {
+			line := run.table.Line + rowIndex + 1
+			row, ok := rowEntity.(*slimentity.SlimList)
+			if !ok {
+				run.addResult(line, Error, fmt.Sprintf("Row [%v] is not a list", resultText(rowEntity)))
+				continue
+			}
+			for column, cellEntity := range *row {
+				cell := resultText(cellEntity)
+				status, message := cell, ""
+				if index := strings.Index(cell, ":"); index >= 0 {
+					status, message = cell[:index], cell[index+1:]
+				}
+				switch strings.ToLower(status) {
+				case "", "no change", "report":
+				case "pass":
+					run.addResult(line, Right, message)
+				case "fail":
+					run.addResult(line, Wrong, strings.TrimSuffix(fmt.Sprintf("cell %v: failed: %v", column+1, message), ": "))
+				case "ignore":
+					run.addResult(line, Ignore, message)
+				case "error":
+					run.addResult(line, Error, fmt.Sprintf("cell %v: %v", column+1, message))
+				default:
+					run.addResult(line, Wrong, fmt.Sprintf("cell %v: [%v]", column+1, cell))
+				}
+			}
+		}
+	}
+}
+
+func (run *tableRun) imports() {
+	for index, row := range run.table.Rows[1:] {
+		run.add(run.noException(run.table.Line+index+1), "import", row[0])
+	}
+}
+
+func (run *tableRun) libraries() {
+	for index, row := range run.table.Rows[1:] {
+		line := run.table.Line + index + 1
+		run.make(line, fmt.Sprintf("library_%v_%v", run.id, index), row[0], row[1:])
+	}
+}
+
+// Methods
+
+// translate creates the instructions for the table. The table type is determined by the first cell, like FitNesse does.
+// A table without a recognized type is a decision table.
+func (run *tableRun) translate() {
+	header := run.table.Rows[0]
+	kind, name := strings.ToLower(header[0]), ""
+	args := header[1:]
+	if index := strings.Index(header[0], ":"); index >= 0 {
+		kind, name = strings.ToLower(strings.TrimSpace(header[0][:index])), strings.TrimSpace(header[0][index+1:])
+	}
+	switch kind {
+	case "script":
+		if name == "" && len(args) > 0 {
+			name, args = args[0], args[1:]
+		}
+		run.script(name, args)
+	case "decision", "dt", "ddt":
+		run.decision(name, args)
+	case "query", "subset query", "ordered query":
+		run.query(kind, name, args)
+	case "table":
+		run.tableTable(name, args)
+	case "import":
+		run.imports()
+	case "library":
+		run.libraries()
+	case "comment":
+	case "scenario", "define alias", "define table type":
+		run.addResult(run.table.Line, Ignore, fmt.Sprintf("%v tables are not supported", kind))
+	default:
+		if name != "" {
+			run.addResult(run.table.Line, Ignore, fmt.Sprintf("%v tables are not supported", kind))
+			return
+		}
+		run.decision(header[0], args)
+	}
+}
+
+// evaluate evaluates the results of the instructions, in the order of the instructions. After an abort, it stops.
+func (run *tableRun) evaluate(results map[string]slimentity.SlimEntity) {
+	for _, step := range run.steps {
+		if run.report.Aborted {
+			return
+		}
+		step.evaluate(results[step.id])
+	}
+}
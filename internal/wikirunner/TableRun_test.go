@@ -0,0 +1,264 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Helpers
+
+func methodOf(instruction *slimentity.SlimList) string {
+	switch instruction.StringAt(1) {
+	case "call":
+		return instruction.StringAt(3)
+	case "callAndAssign":
+		return instruction.StringAt(4)
+	default:
+		return instruction.StringAt(1)
+	}
+}
+
+func runTable(rows [][]string, respond func(instruction *slimentity.SlimList) slimentity.SlimEntity) ([]string, *Report) {
+	report := NewReport("Page")
+	run := newTableRun("t", "Page", &Table{Line: 1, Rows: rows}, report, map[string]string{})
+	run.translate()
+	interpreter := newFakeInterpreter(respond)
+	run.evaluate(resultMap(interpreter.Process(run.instructions)))
+	return interpreter.received, report
+}
+
+func messages(report *Report) []string {
+	var result []string
+	for _, entry := range report.Results {
+		result = append(result, entry.Outcome.String()+" "+entry.Message)
+	}
+	return result
+}
+
+// Tests
+
+func TestTableRunNames(t *testing.T) {
+	assert.Equals(t, "getValue", methodName("get value"), "Method name from words")
+	assert.Equals(t, "countUp", methodName("CountUp"), "Method name from title case")
+	assert.Equals(t, "convertTo", methodName("Convert", "to"), "Method name from cells")
+	assert.Equals(t, "", methodName(""), "Empty method name")
+	assert.Equals(t, "MemoObject", fixtureName("memo object"), "Fixture name from words")
+	assert.Equals(t, "demofixtures.Order", fixtureName("demofixtures.Order"), "Qualified fixture name")
+
+	method, args := splitMethod([]string{"convert", "68 F", "to", "C"})
+	assert.Equals(t, "convertTo", method, "Interleaved method name")
+	assert.Equals(t, 2, len(args), "Interleaved args")
+	method, args = splitMethod([]string{"add item;", "a", "b"})
+	assert.Equals(t, "addItem", method, "Method name before semicolon")
+	assert.Equals(t, "b", args[1], "Args after semicolon")
+}
+
+func TestTableRunScript(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		switch methodOf(instruction) {
+		case "make":
+			return slimprotocol.OK()
+		case "value":
+			return "6"
+		case "isOdd", "echo":
+			return instruction.StringAt(4)
+		case "fail":
+			return slimprotocol.Exception("failed")
+		default:
+			return slimprotocol.Void()
+		}
+	}
+	received, report := runTable([][]string{
+		{"script", "Counter", "6"},
+		{"check", "value", "6"},
+		{"count up"},
+		{"$count=", "value"},
+		{"check not", "value", "$count"},
+		{"ensure", "is odd;", "true"},
+		{"reject", "is odd", "true"},
+		{"show", "value"},
+		{"note", "just a note"},
+		{"#", "comment"},
+		{"start", "memo object", "1"},
+		{"echo", "true"},
+		{"echo", "false"},
+		{"fail"},
+		{"check", "value"},
+	}, respond)
+	assert.Equals(t, 12, len(received), "Instructions (notes, comments and incomplete rows skipped)")
+	assert.Equals(t, "[t_0, make, scriptTableActor, Counter, 6]", received[0], "Make")
+	assert.Equals(t, "[t_1, call, scriptTableActor, value]", received[1], "Check")
+	assert.Equals(t, "[t_3, callAndAssign, count, scriptTableActor, value]", received[3], "Symbol assignment")
+	assert.Equals(t, "[t_5, call, scriptTableActor, isOdd, true]", received[5], "Ensure")
+	assert.Equals(t, "[t_8, make, scriptTableActor, MemoObject, 1]", received[8], "Start")
+	assert.Equals(t, Counts{Right: 3, Wrong: 3, Ignores: 0, Exceptions: 2}, report.Counts, "Counts")
+	assert.Equals(t, strings.Join([]string{
+		"right ",
+		"wrong check not|value|$count: [6] was not expected",
+		"right ",
+		"wrong reject|is odd|true: expected [false] but was [true]",
+		"right ",
+		"wrong echo|false: returned false",
+		"exception failed",
+		"exception check|value: incomplete row",
+	}, "\n"), strings.Join(messages(report), "\n"), "Messages")
+}
+
+func TestTableRunScriptWithoutFixture(t *testing.T) {
+	received, report := runTable([][]string{{"script"}, {"count up"}},
+		func(instruction *slimentity.SlimList) slimentity.SlimEntity { return slimprotocol.Void() })
+	assert.Equals(t, "[t_0, call, scriptTableActor, countUp]", strings.Join(received, "\n"), "No make")
+	assert.Equals(t, 0, len(report.Results), "Void not counted")
+
+	received, _ = runTable([][]string{{"script:Counter", "6"}},
+		func(instruction *slimentity.SlimList) slimentity.SlimEntity { return slimprotocol.OK() })
+	assert.Equals(t, "[t_0, make, scriptTableActor, Counter, 6]", strings.Join(received, "\n"), "Fixture name after colon")
+}
+
+func TestTableRunDecision(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		switch methodOf(instruction) {
+		case "make", "setInputValue":
+			return slimprotocol.OK()
+		case "fibonacci":
+			return "1"
+		default:
+			return slimprotocol.NoMethodInFixture(methodOf(instruction), "Fibonacci", 0)
+		}
+	}
+	received, report := runTable([][]string{
+		{"Fibonacci Fixture"},
+		{"Input Value", "Fibonacci?", "#comment"},
+		{"1", "1", "by definition"},
+		{"2", "2", "wrong"},
+		{"3", "$result="},
+	}, respond)
+	assert.Equals(t, "[t_0, make, decisionTable_t, FibonacciFixture]", received[0], "Make")
+	assert.Equals(t, "[t_1, call, decisionTable_t, table, [[Input Value, Fibonacci?, #comment], [1, 1, by definition], [2, 2, wrong], [3, $result=]]]",
+		received[1], "Table")
+	assert.Equals(t, "[t_2, call, decisionTable_t, beginTable]", received[2], "Begin table")
+	assert.Equals(t, "[t_3, call, decisionTable_t, reset]", received[3], "Reset")
+	assert.Equals(t, "[t_4, call, decisionTable_t, setInputValue, 1]", received[4], "Set")
+	assert.Equals(t, "[t_5, call, decisionTable_t, execute]", received[5], "Execute")
+	assert.Equals(t, "[t_6, call, decisionTable_t, fibonacci]", received[6], "Output")
+	assert.Equals(t, "[t_14, callAndAssign, result, decisionTable_t, fibonacci]", received[14], "Output assigned to symbol")
+	assert.Equals(t, "[t_15, call, decisionTable_t, endTable]", received[15], "End table")
+	assert.Equals(t, Counts{Right: 1, Wrong: 1, Ignores: 0, Exceptions: 0}, report.Counts, "Missing optional methods not reported")
+	assert.Equals(t, "wrong Fibonacci?: expected [2] but was [1]", messages(report)[1], "Wrong message")
+}
+
+func TestTableRunDecisionMakeFails(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		if methodOf(instruction) == "make" {
+			return slimprotocol.NoFixture("Missing")
+		}
+		return slimprotocol.NoInstance("decisionTable_t")
+	}
+	_, report := runTable([][]string{{"decision:Missing"}, {"in", "out?"}, {"1", "2"}}, respond)
+	assert.Equals(t, "exception NO_CLASS Missing", strings.Join(messages(report), "\n"), "Only the make failure is reported")
+}
+
+func queryResult(rows ...[]string) *slimentity.SlimList {
+	result := slimentity.NewSlimList()
+	for _, row := range rows {
+		fields := slimentity.NewSlimList()
+		fields.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"n", row[0]}))
+		fields.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"2n", row[1]}))
+		result.Append(fields)
+	}
+	return result
+}
+
+func TestTableRunQuery(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		if methodOf(instruction) == "query" {
+			return queryResult([]string{"1", "2"}, []string{"2", "4"}, []string{"3", "6"})
+		}
+		return slimprotocol.OK()
+	}
+	received, report := runTable([][]string{{"query:test query", "3"}, {"n", "2n"}, {"2", "4"}, {"1", "3"}, {"7", "14"}}, respond)
+	assert.Equals(t, "[t_0, make, queryTable_t, TestQuery, 3]", received[0], "Make")
+	assert.Equals(t, "[t_2, call, queryTable_t, query]", received[2], "Query")
+	assert.Equals(t, Counts{Right: 3, Wrong: 3, Ignores: 0, Exceptions: 0}, report.Counts, "Query counts")
+	assert.Equals(t, strings.Join([]string{
+		"right ",
+		"right ",
+		"right ",
+		"wrong 2n: expected [3] but was [2]",
+		"wrong missing row [7|14]",
+		"wrong surplus row map[2n:6 n:3]",
+	}, "\n"), strings.Join(messages(report), "\n"), "Query messages")
+
+	_, report = runTable([][]string{{"subset query:test query", "3"}, {"N"}, {"3"}}, respond)
+	assert.Equals(t, Counts{Right: 1}, report.Counts, "Subset query, case insensitive field")
+
+	_, report = runTable([][]string{{"ordered query:test query", "3"}, {"n"}, {"3"}, {"1"}, {"2"}}, respond)
+	assert.Equals(t, "wrong row [1] out of order", messages(report)[1], "Ordered query")
+
+	_, report = runTable([][]string{{"query:test query", "3"}, {"x"}, {"1"}}, respond)
+	assert.Equals(t, "wrong missing row [1]", messages(report)[0], "Unknown field")
+
+	_, report = runTable([][]string{{"query:test query"}, {"n"}},
+		func(instruction *slimentity.SlimList) slimentity.SlimEntity { return "nonsense" })
+	assert.Equals(t, "exception Query result [nonsense] is not a list of rows", strings.Join(messages(report), "\n"), "Invalid query result")
+}
+
+func TestTableRunTableTable(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		if methodOf(instruction) != "doTable" {
+			return slimprotocol.OK()
+		}
+		result := slimentity.NewSlimList()
+		result.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"pass", "fail:reason", "ignore", "report:x"}))
+		result.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{"", "no change", "error:oops", "other"}))
+		return result
+	}
+	received, report := runTable([][]string{{"table:Table Fixture"}, {"a", "b", "c", "d"}, {"e", "f", "g", "h"}}, respond)
+	assert.Equals(t, "[t_1, call, tableTable_t, doTable, [[a, b, c, d], [e, f, g, h]]]", received[1], "Do table")
+	assert.Equals(t, strings.Join([]string{
+		"right ",
+		"wrong cell 2: failed: reason",
+		"ignored ",
+		"exception cell 3: oops",
+		"wrong cell 4: [other]",
+	}, "\n"), strings.Join(messages(report), "\n"), "Table table messages")
+}
+
+func TestTableRunOtherTables(t *testing.T) {
+	ok := func(instruction *slimentity.SlimList) slimentity.SlimEntity { return slimprotocol.OK() }
+	received, _ := runTable([][]string{{"import"}, {"demofixtures"}}, ok)
+	assert.Equals(t, "[t_0, import, demofixtures]", strings.Join(received, "\n"), "Import")
+	received, _ = runTable([][]string{{"library"}, {"echo fixture", "1"}}, ok)
+	assert.Equals(t, "[t_0, make, library_t_0, EchoFixture, 1]", strings.Join(received, "\n"), "Library")
+	received, report := runTable([][]string{{"comment"}, {"anything"}}, ok)
+	assert.Equals(t, 0, len(received)+len(report.Results), "Comment")
+	received, report = runTable([][]string{{"scenario", "do something"}}, ok)
+	assert.Equals(t, 0, len(received), "Scenario not executed")
+	assert.Equals(t, "ignored scenario tables are not supported", strings.Join(messages(report), "\n"), "Scenario reported")
+	_, report = runTable([][]string{{"baseline: Fixture"}}, ok)
+	assert.Equals(t, "ignored baseline tables are not supported", strings.Join(messages(report), "\n"), "Unknown table type reported")
+}
+
+func TestTableRunAbort(t *testing.T) {
+	respond := func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		return slimprotocol.AbortTest("stop")
+	}
+	_, report := runTable([][]string{{"script"}, {"stop"}, {"check", "value", "1"}}, respond)
+	assert.IsTrue(t, report.Aborted, "Aborted")
+	assert.Equals(t, 1, len(report.Results), "Evaluation stopped after the abort")
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A subset of the FitNesse wiki syntax: tables (optionally prefixed by ! or -), literals (!-text-!), hash tables
+// (!{key:value, ...}), single line variable definitions (!define NAME {value}), variable references (${NAME})
+// and expressions (${= 2 * ${NAME} =}). Everything else is text that doesn't influence the test.
+
+// Definitions and constructors
+
+// Table is a table on a wiki page.
+type Table struct {
+	Line int
+	Rows [][]string
+}
+
+// Page is a parsed wiki page.
+type Page struct {
+	Name   string
+	Tables []*Table
+}
+
+var defineRegex = regexp.MustCompile(`^!define\s+(\w+)\s*(?:\{(.*)\}|\((.*)\)|\[(.*)\])\s*$`)
+var variableRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+var literalRegex = regexp.MustCompile(`!-(.*?)-!`)
+var tableRegex = regexp.MustCompile(`^-?!?\|`)
+var hashTableRegex = regexp.MustCompile(`^!\{(.*)\}$`)
+var expressionRegex = regexp.MustCompile(`\$\{=(.*?)=\}`)
+
+// maxVariableDepth limits the number of substitution passes for variables referring to other variables.
+const maxVariableDepth = 10
+
+// Helpers
+
+func replaceVariables(line string, variables map[string]string) string {
+	for i := 0; i < maxVariableDepth && variableRegex.MatchString(line); i++ {
+		line = variableRegex.ReplaceAllStringFunc(line, func(reference string) string {
+			if value, ok := variables[variableRegex.FindStringSubmatch(reference)[1]]; ok {
+				return value
+			}
+			return reference
+		})
+	}
+	return line
+}
+
+// replaceExpressions evaluates the expressions in the line. Expressions that can't be evaluated remain as they are.
+func replaceExpressions(line string) string {
+	return expressionRegex.ReplaceAllStringFunc(line, func(expression string) string {
+		if value, err := EvaluateExpression(expressionRegex.FindStringSubmatch(expression)[1]); err == nil {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return expression
+	})
+}
+
+// hashTable converts a hash table into the HTML table FitNesse sends for it.
+func hashTable(cell string) string {
+	match := hashTableRegex.FindStringSubmatch(cell)
+	if match == nil {
+		return cell
+	}
+	var builder strings.Builder
+	builder.WriteString("<table>")
+	for _, entry := range strings.Split(match[1], ",") {
+		keyValue := strings.SplitN(entry, ":", 2)
+		if len(keyValue) != 2 {
+			return cell
+		}
+		builder.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td></tr>", strings.TrimSpace(keyValue[0]), strings.TrimSpace(keyValue[1])))
+	}
+	builder.WriteString("</table>")
+	return builder.String()
+}
+
+// splitCells splits a table row into cells. Separators within literals don't count.
+func splitCells(row string) []string {
+	row = strings.TrimSpace(row[strings.Index(row, "|")+1:])
+	row = strings.TrimSuffix(row, "|")
+	var cells []string
+	var cell strings.Builder
+	for len(row) > 0 {
+		switch {
+		case strings.HasPrefix(row, "!-") && strings.Contains(row[2:], "-!"):
+			end := strings.Index(row[2:], "-!") + 4
+			cell.WriteString(row[:end])
+			row = row[end:]
+		case row[0] == '|':
+			cells = append(cells, cell.String())
+			cell.Reset()
+			row = row[1:]
+		default:
+			cell.WriteByte(row[0])
+			row = row[1:]
+		}
+	}
+	cells = append(cells, cell.String())
+	for i, value := range cells {
+		cells[i] = literalRegex.ReplaceAllString(hashTable(strings.TrimSpace(value)), "$1")
+	}
+	return cells
+}
+
+// Methods
+
+// ParsePage parses the content of a wiki page.
+func ParsePage(name, content string) *Page {
+	page := new(Page)
+	page.Name = name
+	variables := make(map[string]string)
+	var table *Table
+	for index, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if match := defineRegex.FindStringSubmatch(line); match != nil {
+			variables[match[1]] = match[2] + match[3] + match[4]
+			table = nil
+			continue
+		}
+		if !tableRegex.MatchString(line) {
+			table = nil
+			continue
+		}
+		if table == nil {
+			table = &Table{Line: index + 1}
+			page.Tables = append(page.Tables, table)
+		}
+		table.Rows = append(table.Rows, splitCells(replaceExpressions(replaceVariables(line, variables))))
+	}
+	return page
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestWikiPageParse(t *testing.T) {
+	content := "Some text\r\n" +
+		"!define Name {rik}\n" +
+		"!define Greeting (hello ${Name})\n" +
+		"\n" +
+		"!|script|Echo|\n" +
+		"|check|echo|${Greeting}|hello rik|\n" +
+		"|check|echo|!-a|b-!|a|b|\n" +
+		"-|echo|${Unknown}|\n" +
+		"text between tables\n" +
+		"|Dictionary|\n" +
+		"|set|!{a:1, b : 2}|\n" +
+		"|check|${= 2 * (3 + 4) =}|\n"
+	page := ParsePage("EchoTest", content)
+	assert.Equals(t, "EchoTest", page.Name, "Name")
+	assert.Equals(t, 2, len(page.Tables), "Two tables")
+	table := page.Tables[0]
+	assert.Equals(t, 5, table.Line, "Line of the first table")
+	assert.Equals(t, 4, len(table.Rows), "Rows of the first table")
+	assert.Equals(t, "script", table.Rows[0][0], "Prefix removed")
+	assert.Equals(t, "hello rik", table.Rows[1][2], "Nested variables replaced")
+	assert.Equals(t, "a|b", table.Rows[2][2], "Separator within literal")
+	assert.Equals(t, 5, len(table.Rows[2]), "Literal counts as one cell")
+	assert.Equals(t, "${Unknown}", table.Rows[3][1], "Unknown variable stays")
+	table = page.Tables[1]
+	assert.Equals(t, 10, table.Line, "Line of the second table")
+	assert.Equals(t, "<table><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></table>", table.Rows[1][1], "Hash table")
+	assert.Equals(t, "14", table.Rows[2][1], "Expression evaluated")
+}
+
+func TestWikiPageHashTable(t *testing.T) {
+	assert.Equals(t, "!{a}", hashTable("!{a}"), "Missing colon")
+	assert.Equals(t, "{a:b}", hashTable("{a:b}"), "Not a hash table")
+}
+
+func TestWikiPageExpressions(t *testing.T) {
+	assert.Equals(t, "2.5 and ${=1/0=}", replaceExpressions("${=5/2=} and ${=1/0=}"), "Invalid expression stays")
+}
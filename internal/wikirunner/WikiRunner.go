@@ -0,0 +1,142 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/interfaces"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// The wiki runner runs FitNesse wiki pages without FitNesse: it sends the instructions of each table
+// to the Slim interpreter (as FitNesse would do via the Slim protocol) and evaluates the results.
+
+// Definitions and constructors
+
+// WikiRunner runs wiki pages against a Slim interpreter.
+type WikiRunner struct {
+	interpreter interfaces.SlimInterpreter
+	symbols     map[string]string
+	tableCount  int
+}
+
+// Pages that FitNesse includes before and after a test page, if they are in the same folder.
+var setUpPages = []string{"SuiteSetUp", "SetUp"}
+var tearDownPages = []string{"TearDown", "SuiteTearDown"}
+
+// NewWikiRunner creates a new WikiRunner.
+func NewWikiRunner(interpreter interfaces.SlimInterpreter) *WikiRunner {
+	runner := new(WikiRunner)
+	runner.interpreter = interpreter
+	runner.symbols = make(map[string]string)
+	return runner
+}
+
+// Helpers
+
+func resultMap(results *slimentity.SlimList) map[string]slimentity.SlimEntity {
+	resultsByID := make(map[string]slimentity.SlimEntity)
+	for _, entry := range *results {
+		if result, ok := entry.(*slimentity.SlimList); ok && result.Length() == 2 {
+			resultsByID[result.StringAt(0)] = result.ElementAt(1)
+		}
+	}
+	return resultsByID
+}
+
+// findPage returns the wiki file with the page name in the folder (case insensitive), or an empty string if there is none.
+func findPage(folder, pageName string) string {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(entry.Name(), pageName+".wiki") {
+			return filepath.Join(folder, entry.Name())
+		}
+	}
+	return ""
+}
+
+func readPage(fileName string) (*Page, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read wiki page: %v", err)
+	}
+	return ParsePage(strings.TrimSuffix(filepath.Base(fileName), ".wiki"), string(content)), nil
+}
+
+func pagesIn(folder string, pageNames []string) ([]*Page, error) {
+	var pages []*Page
+	for _, pageName := range pageNames {
+		if pageFile := findPage(folder, pageName); pageFile != "" {
+			page, err := readPage(pageFile)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// Methods
+
+// Run runs the tables of the pages, reporting the results in one report named after the first page.
+func (runner *WikiRunner) Run(pages ...*Page) (*Report, error) {
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("No pages to run")
+	}
+	report := NewReport(pages[0].Name)
+	for _, page := range pages {
+		for _, table := range page.Tables {
+			if report.Aborted {
+				return report, nil
+			}
+			runner.tableCount++
+			run := newTableRun(fmt.Sprintf("table%v", runner.tableCount), page.Name, table, report, runner.symbols)
+			run.translate()
+			if run.instructions.Length() > 0 {
+				run.evaluate(resultMap(runner.interpreter.Process(run.instructions)))
+			}
+		}
+	}
+	return report, nil
+}
+
+// RunFile runs a wiki file, preceded by the set up pages and followed by the tear down pages in the same folder.
+func (runner *WikiRunner) RunFile(fileName string) (*Report, error) {
+	testPage, err := readPage(fileName)
+	if err != nil {
+		return nil, err
+	}
+	folder := filepath.Dir(fileName)
+	setUp, err := pagesIn(folder, setUpPages)
+	if err != nil {
+		return nil, err
+	}
+	tearDown, err := pagesIn(folder, tearDownPages)
+	if err != nil {
+		return nil, err
+	}
+	pages := append(append(setUp, testPage), tearDown...)
+	report, err := runner.Run(pages...)
+	if err != nil {
+		return nil, err
+	}
+	report.Page = testPage.Name
+	return report, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package wikirunner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+type fakeInterpreter struct {
+	received []string
+	respond  func(instruction *slimentity.SlimList) slimentity.SlimEntity
+}
+
+func newFakeInterpreter(respond func(instruction *slimentity.SlimList) slimentity.SlimEntity) *fakeInterpreter {
+	interpreter := new(fakeInterpreter)
+	interpreter.respond = respond
+	return interpreter
+}
+
+func (interpreter *fakeInterpreter) Process(instructions *slimentity.SlimList) *slimentity.SlimList {
+	results := slimentity.NewSlimList()
+	for _, entry := range *instructions {
+		instruction := entry.(*slimentity.SlimList)
+		interpreter.received = append(interpreter.received, instruction.ToString())
+		results.Append(slimentity.NewSlimListContaining([]slimentity.SlimEntity{instruction.StringAt(0), interpreter.respond(instruction)}))
+	}
+	return results
+}
+
+func echoResponse(instruction *slimentity.SlimList) slimentity.SlimEntity {
+	if methodOf(instruction) == "echo" {
+		return instruction.StringAt(instruction.Length() - 1)
+	}
+	return slimprotocol.OK()
+}
+
+func TestWikiRunnerRun(t *testing.T) {
+	interpreter := newFakeInterpreter(echoResponse)
+	runner := NewWikiRunner(interpreter)
+	page := ParsePage("EchoTest", "|script|\n|$value=|echo|a|\n\n|script|\n|check|echo|b|$value|\n")
+	report, err := runner.Run(page)
+	assert.Equals(t, nil, err, "Run without error")
+	assert.Equals(t, "EchoTest", report.Page, "Page name")
+	assert.Equals(t, Counts{Wrong: 1}, report.Counts, "Symbols are kept between tables")
+	assert.Equals(t, "check|echo|b|$value: expected [a] but was [b]", report.Results[0].Message, "Symbol replaced in expectation")
+	assert.Equals(t, "[table2_0, call, scriptTableActor, echo, b]", interpreter.received[1], "Table ids are unique")
+
+	aborting := NewWikiRunner(newFakeInterpreter(func(instruction *slimentity.SlimList) slimentity.SlimEntity {
+		return slimprotocol.AbortSuite("stop")
+	}))
+	report, _ = aborting.Run(page)
+	assert.Equals(t, 1, len(report.Results), "Tables after an abort are skipped")
+
+	_, err = runner.Run()
+	assert.Equals(t, "No pages to run", err.Error(), "Run without pages")
+}
+
+func TestWikiRunnerRunFile(t *testing.T) {
+	folder := t.TempDir()
+	writePage := func(name, content string) {
+		assert.Equals(t, nil, os.WriteFile(filepath.Join(folder, name+".wiki"), []byte(content), 0644), "Write "+name)
+	}
+	writePage("SuiteSetup", "|import|\n|fixtures|\n")
+	writePage("TearDown", "|script|\n|check|echo|done|done|\n")
+	writePage("EchoTest", "|script|\n|check|echo|a|a|\n")
+	interpreter := newFakeInterpreter(echoResponse)
+	runner := NewWikiRunner(interpreter)
+	report, err := runner.RunFile(filepath.Join(folder, "EchoTest.wiki"))
+	assert.Equals(t, nil, err, "No error")
+	assert.Equals(t, "EchoTest", report.Page, "Report named after the test page")
+	assert.Equals(t, 3, len(interpreter.received), "Set up, test and tear down")
+	assert.Equals(t, "[table1_0, import, fixtures]", interpreter.received[0], "Set up page (case insensitive) first")
+	assert.Equals(t, "TearDown", report.Results[1].Page, "Tear down page last")
+	assert.IsTrue(t, report.Passed(), "Passed")
+
+	_, err = runner.RunFile(filepath.Join(folder, "Missing.wiki"))
+	assert.IsTrue(t, err != nil, "Error for missing page")
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package slim4gotest runs FitNesse wiki pages from go test. It is kept apart from slim4go, so that runners don't
+// depend on the testing package.
+package slim4gotest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/essenius/slim4go"
+)
+
+// Definitions
+
+// tester is the part of testing.T that RunWikiPages uses.
+type tester interface {
+	Fatal(args ...interface{})
+	Run(name string, test func(t *testing.T)) bool
+}
+
+// RunWikiPages runs FitNesse wiki pages, each as a sub test that fails if the page has wrong results or exceptions.
+// The args initialize slim4go if that didn't happen yet, e.g. []string{"test", "1"} to use pipes with the default settings.
+// The command line can't be used, as under go test it contains the test flags.
+// The test fails if there are no pages, so e.g. a glob that doesn't match anything doesn't result in a passing test.
+func RunWikiPages(t *testing.T, args []string, fileNames ...string) {
+	runWikiPages(t, args, fileNames)
+}
+
+// Helpers
+
+func runWikiPages(t tester, args []string, fileNames []string) {
+	if len(fileNames) == 0 {
+		t.Fatal("No wiki pages to run")
+	}
+	slim4go.Initialize(args)
+	for _, fileName := range fileNames {
+		t.Run(filepath.Base(fileName), func(t *testing.T) {
+			report, err := slim4go.RunWikiPage(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !report.Passed() {
+				t.Error(report)
+			}
+		})
+	}
+}
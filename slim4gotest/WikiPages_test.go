@@ -0,0 +1,73 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slim4gotest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/essenius/slim4go"
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+var testArgs = []string{"slim4gotest", "1"}
+
+type Greeter struct{}
+
+func NewGreeter() *Greeter {
+	return new(Greeter)
+}
+
+func (greeter *Greeter) Greet(name string) string {
+	return "Hello " + name
+}
+
+// fakeTester records a fatal error and stops the goroutine, like testing.T does.
+type fakeTester struct {
+	fatal string
+	runs  int
+}
+
+func (tester *fakeTester) Fatal(args ...interface{}) {
+	tester.fatal = fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func (tester *fakeTester) Run(name string, test func(t *testing.T)) bool {
+	tester.runs++
+	return true
+}
+
+func TestMain(m *testing.M) {
+	slim4go.Initialize(testArgs)
+	slim4go.RegisterFixture(NewGreeter)
+	os.Exit(m.Run())
+}
+
+func TestWikiPagesRun(t *testing.T) {
+	RunWikiPages(t, testArgs, filepath.Join("testdata", "GreeterTest.wiki"))
+}
+
+func TestWikiPagesNoPages(t *testing.T) {
+	tester := new(fakeTester)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		runWikiPages(tester, testArgs, nil)
+	}()
+	<-done
+	assert.Equals(t, "No wiki pages to run", tester.fatal, "Fatal without pages")
+	assert.Equals(t, 0, tester.runs, "No pages run")
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"fmt"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+// Definitions and constructors
+
+// Batch is a list of instructions that is sent to the Slim server in one request.
+type Batch struct {
+	instructions *slimentity.SlimList
+	prefix       string
+}
+
+// NewBatch creates a new Batch. The ids of its instructions start with the prefix.
+func NewBatch(prefix string) *Batch {
+	batch := new(Batch)
+	batch.instructions = slimentity.NewSlimList()
+	batch.prefix = prefix
+	return batch
+}
+
+// Helpers
+
+func (batch *Batch) add(command string, arguments ...string) string {
+	id := fmt.Sprintf("%v_%v", batch.prefix, batch.instructions.Length())
+	instruction := slimentity.NewSlimListContaining(slimentity.SlimList{id, command})
+	for _, argument := range arguments {
+		instruction.Append(argument)
+	}
+	batch.instructions.Append(instruction)
+	return id
+}
+
+// Methods
+
+// Assign adds an instruction to set a symbol to a value. It returns the id of the instruction.
+func (batch *Batch) Assign(symbol, value string) string {
+	return batch.add("assign", symbol, value)
+}
+
+// Call adds an instruction to call a method on an instance. It returns the id of the instruction.
+func (batch *Batch) Call(instance, method string, arguments ...string) string {
+	return batch.add("call", append([]string{instance, method}, arguments...)...)
+}
+
+// CallAndAssign adds an instruction to call a method on an instance and assign the result to a symbol.
+// It returns the id of the instruction.
+func (batch *Batch) CallAndAssign(symbol, instance, method string, arguments ...string) string {
+	return batch.add("callAndAssign", append([]string{symbol, instance, method}, arguments...)...)
+}
+
+// Import adds an instruction to import a fixture path. It returns the id of the instruction.
+func (batch *Batch) Import(path string) string {
+	return batch.add("import", path)
+}
+
+// Length returns the number of instructions in the batch.
+func (batch *Batch) Length() int {
+	return batch.instructions.Length()
+}
+
+// Make adds an instruction to create an instance of a fixture. It returns the id of the instruction.
+func (batch *Batch) Make(instance, fixture string, arguments ...string) string {
+	return batch.add("make", append([]string{instance, fixture}, arguments...)...)
+}
+
+// Marshal returns the batch in its Slim serialized representation.
+func (batch *Batch) Marshal() string {
+	return slimentity.Marshal(batch.instructions)
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestBatchInstructions(t *testing.T) {
+	batch := NewBatch("b")
+	assert.Equals(t, "b_0", batch.Import("demofixtures"), "Import id")
+	assert.Equals(t, "b_1", batch.Make("conv", "TemperatureConverter"), "Make id")
+	assert.Equals(t, "b_2", batch.Call("conv", "convertTo", "68 F", "C"), "Call id")
+	assert.Equals(t, "b_3", batch.CallAndAssign("temp", "conv", "convertTo", "68 F", "C"), "CallAndAssign id")
+	assert.Equals(t, "b_4", batch.Assign("unit", "K"), "Assign id")
+	assert.Equals(t, 5, batch.Length(), "Length")
+	assert.Equals(t, "[[b_0, import, demofixtures], [b_1, make, conv, TemperatureConverter], [b_2, call, conv, convertTo, 68 F, C], "+
+		"[b_3, callAndAssign, temp, conv, convertTo, 68 F, C], [b_4, assign, unit, K]]", batch.instructions.ToString(), "Instructions")
+	assert.Equals(t, "000060:[000001:000043:[000003:000003:b_0:000006:import:000001:x:]:]", oneImport().Marshal(), "Marshal")
+}
+
+func oneImport() *Batch {
+	batch := NewBatch("b")
+	batch.Import("x")
+	return batch
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// The Slim client drives a Slim server the way FitNesse does: it performs the version handshake,
+// sends instruction lists and parses the results. It can launch a server that uses pipes, or connect to a socket.
+
+// Definitions and constructors
+
+// Client is a connection to a Slim server.
+type Client struct {
+	reader  *bufio.Reader
+	decoder *slimentity.Decoder
+	writer  io.Writer
+	closer  func() error
+	version string
+	batches int
+}
+
+// retryInterval is the time between connection attempts, as the server may not be listening yet.
+const retryInterval = 50 * time.Millisecond
+
+// NewClient creates a Client communicating via the reader and writer, and performs the version handshake.
+func NewClient(reader io.Reader, writer io.Writer) (*Client, error) {
+	client := new(Client)
+	client.reader = bufio.NewReader(reader)
+	client.writer = writer
+	client.closer = func() error { return nil }
+	versionLine, err := client.reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("Could not read the Slim version: %v", err)
+	}
+	if !strings.HasPrefix(versionLine, "Slim -- V") {
+		return nil, fmt.Errorf("Expected a Slim version but got '%v'", strings.TrimSpace(versionLine))
+	}
+	client.version = strings.TrimSpace(strings.TrimPrefix(versionLine, "Slim -- V"))
+	// the decoder continues where the handshake left off, as it reuses the buffered reader
+	client.decoder = slimentity.NewDecoder(client.reader)
+	return client, nil
+}
+
+// Launch starts a Slim server executable that uses pipes (i.e. its arguments should specify port 1), and connects to it.
+func Launch(command string, arguments ...string) (*Client, error) {
+	process := exec.Command(command, arguments...)
+	writer, err := process.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := process.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := process.Start(); err != nil {
+		return nil, fmt.Errorf("Could not launch '%v': %v", command, err)
+	}
+	client, err := NewClient(reader, writer)
+	if err != nil {
+		process.Process.Kill()
+		process.Wait()
+		return nil, err
+	}
+	client.closer = func() error {
+		writer.Close()
+		return process.Wait()
+	}
+	return client, nil
+}
+
+// Connect connects to a Slim server listening on a socket (e.g. localhost:8485).
+// It keeps trying until the timeout expires, as the server may still be starting up.
+func Connect(address string, timeout time.Duration) (*Client, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		connection, err := net.DialTimeout("tcp", address, timeout)
+		if err == nil {
+			connection.SetDeadline(deadline)
+			client, err := NewClient(connection, connection)
+			if err != nil {
+				connection.Close()
+				return nil, err
+			}
+			connection.SetDeadline(time.Time{})
+			client.closer = connection.Close
+			return client, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Could not connect to %v within %v: %v", address, timeout, err)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// Methods
+
+// Close ends the session with the server, and closes the connection.
+func (client *Client) Close() error {
+	_, err := io.WriteString(client.writer, slimentity.Marshal(slimprotocol.Bye()))
+	closeErr := client.closer()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Execute sends the instructions of a batch to the server and returns the results.
+func (client *Client) Execute(batch *Batch) (Results, error) {
+	if _, err := io.WriteString(client.writer, batch.Marshal()); err != nil {
+		return nil, fmt.Errorf("Could not send instructions: %v", err)
+	}
+	response, err := client.decoder.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read results: %v", err)
+	}
+	return toResults(response)
+}
+
+// NewBatch creates a batch with instruction ids that are unique for this client.
+func (client *Client) NewBatch() *Batch {
+	client.batches++
+	return NewBatch(fmt.Sprintf("batch%v", client.batches))
+}
+
+// Version returns the Slim protocol version of the server.
+func (client *Client) Version() string {
+	return client.version
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/essenius/slim4go/examples/demofixtures"
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/inject"
+	"github.com/essenius/slim4go/internal/slimlog"
+)
+
+// The end to end tests run the test executable as Slim server, with the port in this environment variable.
+const serverPortVariable = "SLIMCLIENT_TEST_SERVER_PORT"
+
+func TestMain(m *testing.M) {
+	if port := os.Getenv(serverPortVariable); port != "" {
+		serve(port)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func serve(port string) {
+	inject.Context().Initialize([]string{os.Args[0], "-t", "5", port})
+	server := inject.SlimServer()
+	server.RegisterFixturesFrom(demofixtures.NewTemperatureFactory())
+	if err := server.Serve(); err != nil {
+		slimlog.Error.Fatal(err)
+	}
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func convertTemperatures(t *testing.T, client *Client) {
+	assert.Equals(t, "0.5", client.Version(), "Version")
+	batch := client.NewBatch()
+	importID := batch.Import("demofixtures")
+	makeID := batch.Make("conv", "TemperatureConverter")
+	assignID := batch.CallAndAssign("temp", "conv", "convertTo", "68 F", "C")
+	callID := batch.Call("conv", "convertTo", "$temp C", "F")
+	failID := batch.Call("conv", "convertTo", "", "K")
+	results, err := client.Execute(batch)
+	assert.Equals(t, nil, err, "Execute without error")
+	assert.Equals(t, 5, len(results), "Result count")
+	assert.Equals(t, "OK", results.Get(importID).String(), "Import")
+	assert.Equals(t, "OK", results.Get(makeID).String(), "Make")
+	assert.Equals(t, "20", results.Get(assignID).String(), "CallAndAssign")
+	assert.Equals(t, "68", results.Get(callID).String(), "Call with symbol")
+	assert.Equals(t, "Panic: Expected float with suffix F, C or K but got ''", results.Get(failID).Exception().Message, "Exception")
+
+	second := client.NewBatch()
+	symbolID := second.Call("conv", "convertTo", "$temp C", "K")
+	results, err = client.Execute(second)
+	assert.Equals(t, nil, err, "Second execute without error")
+	assert.Equals(t, "293.15", results.Get(symbolID).String(), "Symbols persist between batches")
+	assert.Equals(t, nil, client.Close(), "Close without error")
+}
+
+func TestClientPipe(t *testing.T) {
+	t.Setenv(serverPortVariable, "1")
+	client, err := Launch(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	convertTemperatures(t, client)
+}
+
+func TestClientSocket(t *testing.T) {
+	port := freePort(t)
+	server := exec.Command(os.Args[0])
+	server.Env = append(os.Environ(), fmt.Sprintf("%v=%v", serverPortVariable, port))
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Wait()
+	client, err := Connect(fmt.Sprintf("localhost:%v", port), 5*time.Second)
+	if err != nil {
+		server.Process.Kill()
+		t.Fatal(err)
+	}
+	convertTemperatures(t, client)
+}
+
+func TestClientErrors(t *testing.T) {
+	_, err1 := NewClient(strings.NewReader("Hello\n"), nil)
+	assert.Equals(t, "Expected a Slim version but got 'Hello'", err1.Error(), "Wrong handshake")
+	_, err2 := NewClient(strings.NewReader(""), nil)
+	assert.Equals(t, "Could not read the Slim version: EOF", err2.Error(), "No handshake")
+	_, err3 := Connect(fmt.Sprintf("localhost:%v", freePort(t)), 100*time.Millisecond)
+	assert.IsTrue(t, strings.HasPrefix(err3.Error(), "Could not connect to localhost:"), "No server")
+	_, err4 := Launch("nonexistent-slim-server")
+	assert.IsTrue(t, strings.HasPrefix(err4.Error(), "Could not launch 'nonexistent-slim-server': "), "No executable")
+
+	var written strings.Builder
+	client, _ := NewClient(strings.NewReader("Slim -- V0.5\n000003:bye"), &written)
+	_, err5 := client.Execute(client.NewBatch())
+	assert.Equals(t, "Expected a list of results but got 'bye'", err5.Error(), "Unexpected response")
+	_, err6 := client.Execute(client.NewBatch())
+	assert.IsTrue(t, strings.HasPrefix(err6.Error(), "Could not read results: "), "No response")
+	assert.Equals(t, nil, client.Close(), "Close")
+	assert.Equals(t, "000009:[000000:]000009:[000000:]000003:bye", written.String(), "Sent messages")
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/essenius/slim4go/internal/slimentity"
+	"github.com/essenius/slim4go/internal/slimprotocol"
+)
+
+// Definitions
+
+// Result is the outcome of an instruction. The value is a string, nil (for null) or a []interface{} (for a list)
+// containing these types recursively.
+type Result struct {
+	ID    string
+	Value interface{}
+}
+
+// Results contains the results of a batch, in the order the server returned them.
+type Results []*Result
+
+// Exception is an exception returned by the Slim server.
+type Exception struct {
+	// Message is the readable part of the exception, e.g. NO_INSTANCE conv
+	Message string
+	// Abort is set if the exception aborts the test (or suite)
+	Abort bool
+}
+
+const exceptionPrefix = "__EXCEPTION__:"
+
+var exceptionMessageRegex = regexp.MustCompile(`message:<<(?s)(.*)>>`)
+
+// Helpers
+
+func toValue(entity slimentity.SlimEntity) interface{} {
+	list, ok := entity.(*slimentity.SlimList)
+	if !ok {
+		return entity
+	}
+	values := make([]interface{}, 0, list.Length())
+	for _, entry := range *list {
+		values = append(values, toValue(entry))
+	}
+	return values
+}
+
+func toResults(response slimentity.SlimEntity) (Results, error) {
+	list, ok := response.(*slimentity.SlimList)
+	if !ok {
+		return nil, fmt.Errorf("Expected a list of results but got '%v'", response)
+	}
+	var results Results
+	for _, entry := range *list {
+		result, ok := entry.(*slimentity.SlimList)
+		if !ok || result.Length() != 2 || slimentity.IsSlimList(result.ElementAt(0)) {
+			return nil, fmt.Errorf("Expected [id, result] but got '%v'", slimentity.ToString(entry))
+		}
+		results = append(results, &Result{ID: result.StringAt(0), Value: toValue(result.ElementAt(1))})
+	}
+	return results, nil
+}
+
+// Exception methods
+
+func (exception *Exception) Error() string {
+	return exception.Message
+}
+
+// Result methods
+
+// Exception returns the exception if the result is one, and nil otherwise.
+func (result *Result) Exception() *Exception {
+	text, ok := result.Value.(string)
+	if !ok || !strings.HasPrefix(text, exceptionPrefix) {
+		return nil
+	}
+	exception := new(Exception)
+	exception.Abort = strings.HasPrefix(text, exceptionPrefix+"ABORT_SLIM_")
+	if match := exceptionMessageRegex.FindStringSubmatch(text); match != nil {
+		exception.Message = match[1]
+	} else {
+		exception.Message = strings.TrimPrefix(text, exceptionPrefix)
+	}
+	return exception
+}
+
+// IsVoid returns whether the result is the outcome of a method without return value.
+func (result *Result) IsVoid() bool {
+	return result.Value == slimprotocol.Void()
+}
+
+// List returns the result as list, and whether it is one.
+func (result *Result) List() ([]interface{}, bool) {
+	list, ok := result.Value.([]interface{})
+	return list, ok
+}
+
+// String returns the result as text: null for nil and the list notation for lists.
+func (result *Result) String() string {
+	if result.Value == nil {
+		return slimprotocol.Null()
+	}
+	return fmt.Sprintf("%v", result.Value)
+}
+
+// Results methods
+
+// Get returns the result of the instruction with the id, or nil if there is none.
+func (results Results) Get(id string) *Result {
+	for _, result := range results {
+		if result.ID == id {
+			return result
+		}
+	}
+	return nil
+}
+
+// Err returns the first exception in the results, or nil if there is none.
+func (results Results) Err() error {
+	for _, result := range results {
+		if exception := result.Exception(); exception != nil {
+			return fmt.Errorf("%v: %w", result.ID, exception)
+		}
+	}
+	return nil
+}
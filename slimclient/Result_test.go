@@ -0,0 +1,62 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+	"github.com/essenius/slim4go/internal/slimentity"
+)
+
+func TestResultConversion(t *testing.T) {
+	response := slimentity.NewSlimListContaining(slimentity.SlimList{
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_0", "OK"}),
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_1", "/__VOID__/"}),
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_2", slimentity.NewSlimListContaining(slimentity.SlimList{"a",
+			slimentity.NewSlimListContaining(slimentity.SlimList{"b", "c"})})}),
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_3", "__EXCEPTION__:message:<<NO_INSTANCE conv>>"}),
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_4", "__EXCEPTION__:ABORT_SLIM_TEST:message:<<stop>>"}),
+		slimentity.NewSlimListContaining(slimentity.SlimList{"id_5", nil}),
+	})
+	results, err := toResults(response)
+	assert.Equals(t, nil, err, "No error")
+	assert.Equals(t, 6, len(results), "Result count")
+	assert.Equals(t, "OK", results.Get("id_0").String(), "OK")
+	assert.IsTrue(t, results.Get("id_0").Exception() == nil, "OK is no exception")
+	assert.IsTrue(t, results.Get("id_1").IsVoid(), "Void")
+	list, isList := results.Get("id_2").List()
+	assert.IsTrue(t, isList, "List")
+	assert.Equals(t, 2, len(list), "List length")
+	assert.Equals(t, "[a [b c]]", results.Get("id_2").String(), "List as text")
+	_, isList = results.Get("id_0").List()
+	assert.IsTrue(t, !isList, "OK is no list")
+	exception := results.Get("id_3").Exception()
+	assert.Equals(t, "NO_INSTANCE conv", exception.Message, "Exception message")
+	assert.IsTrue(t, !exception.Abort, "No abort")
+	assert.Equals(t, "stop", results.Get("id_4").Exception().Message, "Abort message")
+	assert.IsTrue(t, results.Get("id_4").Exception().Abort, "Abort")
+	assert.Equals(t, "null", results.Get("id_5").String(), "Null")
+	assert.IsTrue(t, results.Get("id_6") == nil, "Unknown id")
+	assert.Equals(t, "id_3: NO_INSTANCE conv", results.Err().Error(), "First exception")
+	assert.Equals(t, nil, results[:3].Err(), "No exceptions")
+}
+
+func TestResultConversionErrors(t *testing.T) {
+	_, err1 := toResults("bye")
+	assert.Equals(t, "Expected a list of results but got 'bye'", err1.Error(), "No list")
+	_, err2 := toResults(slimentity.NewSlimListContaining(slimentity.SlimList{"a"}))
+	assert.Equals(t, "Expected [id, result] but got 'a'", err2.Error(), "No result pair")
+	_, err3 := toResults(slimentity.NewSlimListContaining(slimentity.SlimList{slimentity.NewSlimListContaining(slimentity.SlimList{"a", "b", "c"})}))
+	assert.IsTrue(t, strings.HasPrefix(err3.Error(), "Expected [id, result] but got '"), "Wrong length")
+}
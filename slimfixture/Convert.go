@@ -0,0 +1,74 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimfixture
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Stubs convert their arguments the same way as the reflection based calls do, with the same error messages.
+
+// bitSizes contains the bit sizes of the number types, 0 meaning the size of int.
+var bitSizes = map[string]int{
+	"int": 0, "int8": 8, "int16": 16, "int32": 32, "int64": 64,
+	"uint": 0, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "uintptr": 0,
+	"float32": 32, "float64": 64,
+}
+
+func conversionError(arg string, typeName string) error {
+	return fmt.Errorf("Could not convert '%v' to type '%v'", arg, typeName)
+}
+
+// CheckArgs returns an error if the number of arguments doesn't match the number of parameters.
+func CheckArgs(args []string, count int) error {
+	if len(args) != count {
+		return fmt.Errorf("Expected %v parameter(s) but got %v", count, len(args))
+	}
+	return nil
+}
+
+// ParseBool converts an argument to a bool.
+func ParseBool(arg string) (bool, error) {
+	result, err := strconv.ParseBool(arg)
+	if err != nil {
+		return false, conversionError(arg, "bool")
+	}
+	return result, nil
+}
+
+// ParseFloat converts an argument to a float of the type (float32 or float64).
+func ParseFloat(arg string, typeName string) (float64, error) {
+	result, err := strconv.ParseFloat(arg, bitSizes[typeName])
+	if err != nil {
+		return 0, conversionError(arg, typeName)
+	}
+	return result, nil
+}
+
+// ParseInt converts an argument to an integer of the type (e.g. int or int64). Like in Go literals, 0x means hexadecimal.
+func ParseInt(arg string, typeName string) (int64, error) {
+	result, err := strconv.ParseInt(arg, 0, bitSizes[typeName])
+	if err != nil {
+		return 0, conversionError(arg, typeName)
+	}
+	return result, nil
+}
+
+// ParseUint converts an argument to an unsigned integer of the type (e.g. uint or uint64).
+func ParseUint(arg string, typeName string) (uint64, error) {
+	result, err := strconv.ParseUint(arg, 0, bitSizes[typeName])
+	if err != nil {
+		return 0, conversionError(arg, typeName)
+	}
+	return result, nil
+}
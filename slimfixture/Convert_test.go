@@ -0,0 +1,46 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+package slimfixture
+
+import (
+	"testing"
+
+	"github.com/essenius/slim4go/internal/assert"
+)
+
+func TestConvertCheckArgs(t *testing.T) {
+	assert.Equals(t, nil, CheckArgs([]string{"a"}, 1), "Matching count")
+	assert.Equals(t, "Expected 2 parameter(s) but got 1", CheckArgs([]string{"a"}, 2).Error(), "Count mismatch")
+}
+
+func TestConvertParse(t *testing.T) {
+	boolValue, err := ParseBool("true")
+	assert.Equals(t, true, boolValue, "Bool")
+	assert.Equals(t, nil, err, "No error for bool")
+	_, err = ParseBool("maybe")
+	assert.Equals(t, "Could not convert 'maybe' to type 'bool'", err.Error(), "Bool error")
+
+	intValue, _ := ParseInt("0x10", "int")
+	assert.Equals(t, int64(16), intValue, "Hexadecimal int")
+	_, err = ParseInt("128", "int8")
+	assert.Equals(t, "Could not convert '128' to type 'int8'", err.Error(), "Out of range")
+
+	uintValue, _ := ParseUint("255", "uint8")
+	assert.Equals(t, uint64(255), uintValue, "Uint")
+	_, err = ParseUint("-1", "uint")
+	assert.Equals(t, "Could not convert '-1' to type 'uint'", err.Error(), "Negative uint")
+
+	floatValue, _ := ParseFloat("2.5", "float64")
+	assert.Equals(t, 2.5, floatValue, "Float")
+	_, err = ParseFloat("warm", "float32")
+	assert.Equals(t, "Could not convert 'warm' to type 'float32'", err.Error(), "Float error")
+}
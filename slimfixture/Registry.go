@@ -0,0 +1,35 @@
+// Copyright 2020 Rik Essenius
+//
+//   Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//   except in compliance with the License. You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software distributed under the License
+//   is distributed on an "AS IS" BASIS WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and limitations under the License.
+
+// Package slimfixture contains what fixture packages need to register themselves, without depending on the server.
+// Fixture plugins and the code that slim4gogen generates use it.
+package slimfixture
+
+// Definitions
+
+// Registry is what fixture packages use to register their fixtures, policies and stubs.
+type Registry interface {
+	AllowMembers(fixtureName string, patterns ...string) error
+	DenyNonFixtureMembers()
+	RegisterAlias(alias string, fixtureName string) error
+	RegisterFixture(constructor interface{}) error
+	RegisterFixturesFrom(factory interface{}) error
+	RegisterLibrary(library interface{}) error
+	RegisterStubs(fixture interface{}, stubs Stubs) error
+	SetReadOnlyFields(fixtureName string, fieldNames ...string) error
+}
+
+// Stub calls a fixture method without reflection. It gets the instance and the arguments (with the symbols replaced),
+// and returns the results of the method.
+type Stub func(instance interface{}, args []string) ([]interface{}, error)
+
+// Stubs contains the stubs of the methods of a fixture, by method name.
+type Stubs map[string]Stub